@@ -0,0 +1,34 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/service"
+)
+
+const defaultHoldExpiryInterval = time.Minute
+
+// runHoldExpiry releases expired holds every interval until ctx is done.
+func runHoldExpiry(ctx context.Context, svc service.Wallet, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultHoldExpiryInterval
+	}
+	// the worker acts on its own behalf, not a caller's
+	ctx = service.SystemContext(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.ExpireHolds(ctx); err != nil {
+				slog.Warn("[runHoldExpiry] failed expire holds", slog.Any("err", err))
+			}
+		}
+	}
+}
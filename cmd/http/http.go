@@ -17,7 +17,7 @@ import (
 func Start(ctx context.Context, cfg appconfig.Config) {
 	router := mux.NewRouter()
 
-	RegisterHandlers(router, cfg)
+	RegisterHandlers(ctx, router, cfg)
 
 	// Start the server
 	startServer(cfg, router)
@@ -1,19 +1,19 @@
 package http
 
 import (
+	"context"
+
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/bootstrap"
 	httpDelivery "github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/delivery/http"
-	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
-	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/service"
 	"github.com/gorilla/mux"
 )
 
-func RegisterHandlers(r *mux.Router, cfg appconfig.Config) {
+func RegisterHandlers(ctx context.Context, r *mux.Router, cfg appconfig.Config) {
+
+	service := bootstrap.NewWalletService(cfg)
 
-	dbClient := bootstrap.NewDB(cfg.Database)
-	repo := repository.NewWalletRepository(dbClient)
-	service := service.NewWalletService(repo)
+	httpDelivery.NewWalletHandler(r, service, bootstrap.NewAuthenticator(cfg.App))
 
-	httpDelivery.NewWalletHandler(r, service)
+	go runHoldExpiry(ctx, service, cfg.Wallet.Holds.ExpiryInterval)
 }
@@ -0,0 +1,29 @@
+package relay
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/bootstrap"
+)
+
+const defaultPollInterval = time.Second
+
+// Start publishes outbox events every poll interval until ctx is done.
+// Replicas can run side by side for failover; only one of them publishes at
+// a time.
+func Start(ctx context.Context, cfg appconfig.Config) {
+	relay, closer := bootstrap.NewOutboxRelay(cfg)
+	defer closer.Close()
+
+	interval := cfg.Wallet.Outbox.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	log.Println("Starting Outbox Relay...")
+	relay.Run(ctx, interval)
+	log.Println("Outbox Relay exited properly")
+}
@@ -6,10 +6,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/abdussalamfaqih/wallet-service-dev/cmd/http"
 	"github.com/abdussalamfaqih/wallet-service-dev/cmd/migrations"
+	"github.com/abdussalamfaqih/wallet-service-dev/cmd/relay"
+	"github.com/abdussalamfaqih/wallet-service-dev/cmd/scheduler"
+	"github.com/abdussalamfaqih/wallet-service-dev/cmd/token"
+	"github.com/abdussalamfaqih/wallet-service-dev/cmd/webhooks"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
 	"github.com/spf13/cobra"
 )
 
@@ -37,6 +43,30 @@ func Run() {
 				http.Start(ctx, cfg)
 			},
 		},
+		{
+			Use:   "run-scheduler",
+			Short: "Run Scheduled Transfers Worker",
+			Run: func(cmd *cobra.Command, args []string) {
+				cfg := appconfig.LoadConfig(configFile)
+				scheduler.Start(ctx, cfg)
+			},
+		},
+		{
+			Use:   "run-relay",
+			Short: "Run Outbox Event Relay",
+			Run: func(cmd *cobra.Command, args []string) {
+				cfg := appconfig.LoadConfig(configFile)
+				relay.Start(ctx, cfg)
+			},
+		},
+		{
+			Use:   "run-webhooks",
+			Short: "Run Webhook Delivery Worker",
+			Run: func(cmd *cobra.Command, args []string) {
+				cfg := appconfig.LoadConfig(configFile)
+				webhooks.Start(ctx, cfg)
+			},
+		},
 		{
 			Use:   "run-migration",
 			Short: "Run HTTP Server",
@@ -47,6 +77,25 @@ func Run() {
 		},
 	}
 
+	var (
+		tokenSubject string
+		tokenRole    string
+		tokenTTL     time.Duration
+	)
+	tokenCmd := &cobra.Command{
+		Use:   "issue-token",
+		Short: "Issue API Bearer Token",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := appconfig.LoadConfig(configFile)
+			token.Issue(cfg, tokenSubject, tokenRole, tokenTTL)
+		},
+	}
+	tokenCmd.Flags().StringVar(&tokenSubject, "subject", "", "Subject of the token, e.g. a customer id")
+	tokenCmd.Flags().StringVar(&tokenRole, "role", consts.RoleCustomer, "Role of the subject: customer, operator or admin")
+	tokenCmd.Flags().DurationVar(&tokenTTL, "ttl", time.Hour, "Validity of the token, must be positive")
+	tokenCmd.MarkFlagRequired("subject")
+	cmd = append(cmd, tokenCmd)
+
 	rootCmd.AddCommand(cmd...)
 	rootCmd.PersistentFlags().StringVar(&configFile, "config_file", "config.json", "Path to the config file (e.g. config.json)")
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/bootstrap"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/service"
+)
+
+const defaultPollInterval = 30 * time.Second
+
+// Start runs due scheduled transfers every poll interval until ctx is done.
+// Any number of replicas can run side by side; each claims its own share of
+// the due schedules.
+func Start(ctx context.Context, cfg appconfig.Config) {
+	svc := bootstrap.NewWalletService(cfg)
+
+	log.Println("Starting Scheduler...")
+	runScheduledTransfers(ctx, svc, cfg.Wallet.Schedules.PollInterval)
+	log.Println("Scheduler exited properly")
+}
+
+// runScheduledTransfers runs due scheduled transfers right away and then
+// every interval until ctx is done.
+func runScheduledTransfers(ctx context.Context, svc service.Wallet, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	// the worker acts on its own behalf, not a caller's
+	ctx = service.SystemContext(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := svc.RunScheduledTransfers(ctx); err != nil {
+			slog.Warn("[runScheduledTransfers] failed run scheduled transfers", slog.Any("err", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package token
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+)
+
+// Issue prints a bearer token for subject with role, signed with the
+// configured secret key and valid for ttl.
+func Issue(cfg appconfig.Config, subject, role string, ttl time.Duration) {
+	if cfg.App.Secret_Key == "" {
+		log.Fatal("app.secret_key is not configured")
+	}
+
+	if ttl <= 0 {
+		log.Fatal("ttl must be positive, tokens without expiry are refused")
+	}
+
+	now := time.Now()
+	claims := auth.Claims{
+		Subject:   subject,
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	token, err := auth.SignToken(cfg.App.Secret_Key, claims)
+	if err != nil {
+		log.Fatalf("failed to sign token: %v", err)
+	}
+
+	fmt.Println(token)
+}
@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/bootstrap"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/service"
+)
+
+const defaultPollInterval = 5 * time.Second
+
+// Start sends due webhook deliveries every poll interval until ctx is done.
+// Any number of replicas can run side by side; each claims its own share of
+// the due deliveries.
+func Start(ctx context.Context, cfg appconfig.Config) {
+	svc := bootstrap.NewWalletService(cfg)
+
+	log.Println("Starting Webhook Worker...")
+	runWebhookDeliveries(ctx, svc, cfg.Wallet.Webhooks.PollInterval)
+	log.Println("Webhook Worker exited properly")
+}
+
+// runWebhookDeliveries sends due deliveries right away and then every
+// interval until ctx is done.
+func runWebhookDeliveries(ctx context.Context, svc service.Wallet, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	// the worker acts on its own behalf, not a caller's
+	ctx = service.SystemContext(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := svc.RunWebhookDeliveries(ctx); err != nil {
+			slog.Warn("[runWebhookDeliveries] failed run webhook deliveries", slog.Any("err", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
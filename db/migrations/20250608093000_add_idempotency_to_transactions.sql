@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- reference_number doubles as the client supplied idempotency key,
+-- request_fingerprint lets replays with a different payload be rejected
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS request_fingerprint VARCHAR(64);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP COLUMN IF EXISTS request_fingerprint;
+-- +goose StatementEnd
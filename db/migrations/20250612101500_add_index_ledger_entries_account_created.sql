@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- serves the account history listing, newest first with keyset pagination
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_created ON ledger_entries(account_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_ledger_entries_account_created;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a reversal points at the transfer it compensates; several partial
+-- reversals may point at the same original
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_transaction_id UUID NULL REFERENCES transactions(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_original_transaction_id ON transactions(original_transaction_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_transactions_original_transaction_id;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS original_transaction_id;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- held_amount is the sum of active holds on the account; the available
+-- balance is balance - held_amount
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS held_amount DECIMAL(20, 6) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+    id UUID PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts(account_id),
+    destination_account_id INT NOT NULL REFERENCES accounts(account_id),
+    amount DECIMAL(20, 6) NOT NULL, -- always positive
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, captured, voided, expired
+    transaction_id UUID NULL REFERENCES transactions(id), -- set once captured
+    description TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- serves the expiry sweep
+CREATE INDEX IF NOT EXISTS idx_holds_active_expires_at ON holds(expires_at) WHERE status = 'active';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS holds;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS held_amount;
+-- +goose StatementEnd
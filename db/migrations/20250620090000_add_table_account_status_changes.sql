@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- CHAR(20) pads the value with spaces, VARCHAR keeps it as written
+ALTER TABLE accounts ALTER COLUMN status TYPE VARCHAR(20);
+UPDATE accounts SET status = 'active' WHERE status IS NULL;
+ALTER TABLE accounts ALTER COLUMN status SET NOT NULL;
+
+-- audit trail of account status changes: active, frozen, closed
+CREATE TABLE IF NOT EXISTS account_status_changes (
+    id BIGSERIAL PRIMARY KEY,
+    account_id INT NOT NULL REFERENCES accounts(account_id),
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    sweep_transaction_id UUID NULL REFERENCES transactions(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_status_changes_account_id ON account_status_changes(account_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_status_changes;
+
+ALTER TABLE accounts ALTER COLUMN status DROP NOT NULL;
+ALTER TABLE accounts ALTER COLUMN status TYPE CHAR(20);
+-- +goose StatementEnd
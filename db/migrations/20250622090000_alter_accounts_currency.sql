@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ISO 4217 alphabetic codes, CHAR(10) padded them with spaces
+ALTER TABLE accounts ALTER COLUMN currency TYPE VARCHAR(3) USING TRIM(currency);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE accounts ALTER COLUMN currency TYPE CHAR(10);
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- internal accounts the service books against, e.g. the per-currency FX
+-- accounts. They get negative account_ids so they can never be addressed as
+-- customer accounts.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS system_code VARCHAR(30) NULL;
+
+CREATE SEQUENCE IF NOT EXISTS system_account_id_seq;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_system_code_currency ON accounts(system_code, currency) WHERE system_code IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_accounts_system_code_currency;
+
+DROP SEQUENCE IF EXISTS system_account_id_seq;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS system_code;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- per-account overrides of the configured transfer limits; NULL keeps the
+-- configured default and 0 lifts the limit
+CREATE TABLE IF NOT EXISTS account_limits (
+    account_id INT PRIMARY KEY REFERENCES accounts(account_id),
+    min_amount DECIMAL(20, 6) NULL,
+    max_amount DECIMAL(20, 6) NULL,
+    daily_amount DECIMAL(20, 6) NULL,
+    monthly_amount DECIMAL(20, 6) NULL,
+    daily_count INT NULL,
+    monthly_count INT NULL,
+    updated_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_limits;
+-- +goose StatementEnd
@@ -0,0 +1,85 @@
+-- +goose Up
+-- +goose StatementBegin
+-- seed the system accounts for every currency in use; currencies added to
+-- the config later get theirs on first use
+INSERT INTO accounts (account_id, currency, balance, system_code)
+SELECT -nextval('system_account_id_seq'), c.currency, 0, s.code
+FROM (
+    SELECT DISTINCT currency FROM accounts WHERE system_code IS NULL
+    UNION SELECT 'USD'
+) c
+CROSS JOIN (VALUES ('funding'), ('fees'), ('fx'), ('suspense')) AS s(code)
+ORDER BY c.currency, s.code
+ON CONFLICT (system_code, currency) WHERE system_code IS NOT NULL DO NOTHING;
+
+-- deposits and withdrawals used to be booked with a single leg, give each
+-- of them the missing funding leg so every transaction balances
+INSERT INTO ledger_entries (id, transaction_id, account_id, amount, entry_type, description, created_at)
+SELECT gen_random_uuid(),
+       t.transaction_id,
+       f.account_id,
+       ABS(t.net),
+       CASE WHEN t.net > 0 THEN 'debit' ELSE 'credit' END,
+       'funding backfill',
+       t.created_at
+FROM (
+    SELECT le.transaction_id,
+           a.currency,
+           SUM(CASE WHEN le.entry_type = 'credit' THEN le.amount ELSE -le.amount END) AS net,
+           MIN(le.created_at) AS created_at
+    FROM ledger_entries le
+    JOIN accounts a ON a.account_id = le.account_id
+    GROUP BY le.transaction_id, a.currency
+) t
+JOIN accounts f ON f.system_code = 'funding' AND f.currency = t.currency
+WHERE t.net <> 0;
+
+UPDATE accounts f SET balance = f.balance + b.net, updated_at = CURRENT_TIMESTAMP
+FROM (
+    SELECT account_id,
+           SUM(CASE WHEN entry_type = 'credit' THEN amount ELSE -amount END) AS net
+    FROM ledger_entries
+    WHERE description = 'funding backfill'
+    GROUP BY account_id
+) b
+WHERE f.account_id = b.account_id;
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries(transaction_id);
+
+-- debits must equal credits within each currency of a transaction. The
+-- check is deferred to commit so a transaction's legs can be written in any
+-- order.
+CREATE OR REPLACE FUNCTION check_ledger_entries_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM ledger_entries le
+        JOIN accounts a ON a.account_id = le.account_id
+        WHERE le.transaction_id = NEW.transaction_id
+        GROUP BY a.currency
+        HAVING SUM(CASE WHEN le.entry_type = 'credit' THEN le.amount ELSE -le.amount END) <> 0
+    ) THEN
+        RAISE EXCEPTION 'ledger entries of transaction % do not balance', NEW.transaction_id
+            USING ERRCODE = 'check_violation';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER trg_ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_ledger_entries_balanced();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the seeded accounts and backfilled entries are kept, they are valid
+-- ledger data
+DROP TRIGGER IF EXISTS trg_ledger_entries_balanced ON ledger_entries;
+
+DROP FUNCTION IF EXISTS check_ledger_entries_balanced();
+
+DROP INDEX IF EXISTS idx_ledger_entries_transaction_id;
+-- +goose StatementEnd
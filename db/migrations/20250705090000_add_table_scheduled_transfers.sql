@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+    id UUID PRIMARY KEY,
+    source_account_id INT NOT NULL REFERENCES accounts(account_id),
+    destination_account_id INT NOT NULL REFERENCES accounts(account_id),
+    amount DECIMAL(20, 6) NOT NULL, -- always positive
+    description TEXT,
+    frequency VARCHAR(20) NOT NULL, -- once, daily, weekly, monthly
+    start_at TIMESTAMP NOT NULL,
+    end_at TIMESTAMP NULL, -- no occurrence after it
+    max_occurrences INT NULL, -- total number of occurrences
+    occurrences INT NOT NULL DEFAULT 0, -- occurrences run so far, successful or not
+    next_run_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, completed, cancelled, failed
+    attempts INT NOT NULL DEFAULT 0, -- failed runs of the current occurrence
+    last_error TEXT,
+    last_transaction_id UUID NULL REFERENCES transactions(id),
+    last_run_at TIMESTAMP NULL,
+    -- a worker running the schedule owns it until then; expired leases are
+    -- claimed again
+    locked_until TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- serves the worker's due scan
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_active_next_run_at ON scheduled_transfers(next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_source_account_id ON scheduled_transfers(source_account_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scheduled_transfers;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- events are written in the same transaction as the change they describe and
+-- published by the relay in id order
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    account_ids INT[] NOT NULL DEFAULT '{}', -- customer accounts the event concerns
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP NULL,
+    attempts INT NOT NULL DEFAULT 0, -- failed publish attempts
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NULL -- not retried before then
+);
+
+-- serves the relay's scan
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(id) WHERE published_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the owners of accounts. The id is the subject of a customer's bearer token.
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- accounts opened before ownership existed, and system accounts, have no
+-- owner; only operators and admins can act on them
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS customer_id UUID NULL REFERENCES customers(id);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_customer_id ON accounts(customer_id) WHERE customer_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_accounts_customer_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS customer_id;
+DROP TABLE IF EXISTS customers;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the relay claims a batch of events until locked_until and publishes them
+-- outside of any transaction
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP NULL;
+
+-- serves the relay's check for earlier events of the same accounts
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished_accounts ON outbox USING GIN (account_ids) WHERE published_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_unpublished_accounts;
+ALTER TABLE outbox DROP COLUMN IF EXISTS locked_until;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a subscription only receives events of accounts its customer owns;
+-- subscriptions created before owners existed receive nothing
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS customer_id UUID NULL REFERENCES customers(id);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_customer_id ON webhook_subscriptions(customer_id) WHERE active;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webhook_subscriptions_customer_id;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS customer_id;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a schedule runs on behalf of the principal that created it, so a
+-- customer's schedule is refused once it no longer owns the source account
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS created_by VARCHAR(255) NULL;
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS created_by_role VARCHAR(20) NULL;
+
+-- schedules created before are taken to be the current owner's; those of
+-- accounts without owner were set up by staff and run as the system
+UPDATE scheduled_transfers st SET created_by = a.customer_id::text, created_by_role = 'customer'
+FROM accounts a
+WHERE a.account_id = st.source_account_id AND a.customer_id IS NOT NULL AND st.created_by_role IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE scheduled_transfers DROP COLUMN IF EXISTS created_by_role;
+ALTER TABLE scheduled_transfers DROP COLUMN IF EXISTS created_by;
+-- +goose StatementEnd
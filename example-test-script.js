@@ -7,6 +7,10 @@ export let options = {
 };
 
 const baseUrl = 'http://localhost:8080/v1';
+const headers = {
+    'Content-Type': 'application/json',
+    'X-API-Key': __ENV.API_KEY || 'dev_operator_key_123',
+};
 
 export default function () {
     // Generate account IDs >= 1
@@ -17,9 +21,7 @@ export default function () {
     let res1 = http.post(`${baseUrl}/accounts`, JSON.stringify({
         account_id: accountID1,
         initial_balance: "200.23344"
-    }), {
-        headers: { 'Content-Type': 'application/json' }
-    });
+    }), { headers });
 
     check(res1, {
         'created account 1': (r) => r.status === 200,
@@ -29,16 +31,14 @@ export default function () {
     let res2 = http.post(`${baseUrl}/accounts`, JSON.stringify({
         account_id: accountID2,
         initial_balance: "300.99999"
-    }), {
-        headers: { 'Content-Type': 'application/json' }
-    });
+    }), { headers });
 
     check(res2, {
         'created account 2': (r) => r.status === 200,
     });
 
     // 3. Get Account #1
-    let res3 = http.get(`${baseUrl}/accounts/${accountID1}`);
+    let res3 = http.get(`${baseUrl}/accounts/${accountID1}`, { headers });
     check(res3, {
         'get account 1': (r) => r.status === 200,
         'account 1 has balance': (r) => JSON.parse(r.body).balance !== undefined,
@@ -49,9 +49,7 @@ export default function () {
         source_account_id: accountID1,
         destination_account_id: accountID2,
         amount: "100.12345"
-    }), {
-        headers: { 'Content-Type': 'application/json' }
-    });
+    }), { headers });
 
     check(res4, {
         'transaction success': (r) => r.status === 200,
@@ -2,31 +2,190 @@ package appconfig
 
 import (
 	"log"
+	"time"
 
 	"github.com/abdussalamfaqih/wallet-service-dev/pkg/config"
 )
 
+// EnvLocal is the AppConfig.Env of a developer machine.
+const EnvLocal = "local"
+
 type (
 	Config struct {
 		App      AppConfig `yaml:"app" json:"app"`
 		Database Database  `yaml:"database" json:"database"`
+		Wallet   Wallet    `yaml:"wallet" json:"wallet"`
 	}
 
+	// AppConfig holds the HTTP server settings. Secret_Key signs the HS256
+	// bearer tokens clients authenticate with; APIKeys are static keys for
+	// service-to-service callers. Without a secret only API keys are
+	// accepted. Env is EnvLocal on a developer machine, where the sample
+	// API keys of the README are accepted.
 	AppConfig struct {
-		Name       string `yaml:"name" json:"name"`
-		Port       string `yaml:"port" json:"port"`
-		Secret_Key string `yaml:"secret_key" json:"secret_key"`
+		Name       string      `yaml:"name" json:"name"`
+		Env        string      `yaml:"env" json:"env"`
+		Port       string      `yaml:"port" json:"port"`
+		Secret_Key string      `yaml:"secret_key" json:"secret_key"`
+		APIKeys    []AppAPIKey `yaml:"api_keys" json:"api_keys" mapstructure:"api_keys"`
+	}
+
+	// AppAPIKey authenticates requests carrying Key in the X-API-Key header
+	// as Subject with Role.
+	AppAPIKey struct {
+		Key     string `yaml:"key" json:"key" mapstructure:"key"`
+		Subject string `yaml:"subject" json:"subject" mapstructure:"subject"`
+		Role    string `yaml:"role" json:"role" mapstructure:"role"`
 	}
 
 	Database struct {
-		Name     string `yaml:"name" json:"name"`
-		Username string `yaml:"user" json:"username"`
-		Password string `yaml:"pass" json:"password"`
-		Host     string `yaml:"host" json:"host"`
-		Port     int    `yaml:"port" json:"port"`
+		Name     string        `yaml:"name" json:"name"`
+		Username string        `yaml:"user" json:"username"`
+		Password string        `yaml:"pass" json:"password"`
+		Host     string        `yaml:"host" json:"host"`
+		Port     int           `yaml:"port" json:"port"`
+		Retry    DatabaseRetry `yaml:"retry" json:"retry"`
+	}
+
+	// DatabaseRetry configures retries of transactions aborted by deadlocks,
+	// serialization failures or connection resets. Zero values fall back to
+	// db.DefaultRetryPolicy.
+	DatabaseRetry struct {
+		MaxAttempts    int           `yaml:"max_attempts" json:"max_attempts" mapstructure:"max_attempts"`
+		InitialBackoff time.Duration `yaml:"initial_backoff" json:"initial_backoff" mapstructure:"initial_backoff"`
+		MaxBackoff     time.Duration `yaml:"max_backoff" json:"max_backoff" mapstructure:"max_backoff"`
+		Multiplier     float64       `yaml:"multiplier" json:"multiplier" mapstructure:"multiplier"`
+	}
+
+	Wallet struct {
+		Reversal   WalletReversal       `yaml:"reversal" json:"reversal"`
+		Holds      WalletHolds          `yaml:"holds" json:"holds"`
+		Currencies []WalletCurrency     `yaml:"currencies" json:"currencies"`
+		FX         WalletFX             `yaml:"fx" json:"fx"`
+		Limits     WalletLimits         `yaml:"limits" json:"limits"`
+		Fees       map[string]WalletFee `yaml:"fees" json:"fees"`
+		Schedules  WalletSchedules      `yaml:"schedules" json:"schedules"`
+		Outbox     WalletOutbox         `yaml:"outbox" json:"outbox"`
+		Webhooks   WalletWebhooks       `yaml:"webhooks" json:"webhooks"`
+	}
+
+	// WalletReversal controls refunds of transfers. With AllowOverdraft a
+	// reversal is booked even when the original destination has since spent
+	// the funds, leaving its balance negative.
+	WalletReversal struct {
+		AllowOverdraft bool `yaml:"allow_overdraft" json:"allow_overdraft" mapstructure:"allow_overdraft"`
+	}
+
+	// WalletHolds configures authorization holds. DefaultTTL applies to holds
+	// created without expires_at and ExpiryInterval is how often expired holds
+	// are released. Zero values fall back to the service defaults.
+	WalletHolds struct {
+		DefaultTTL     time.Duration `yaml:"default_ttl" json:"default_ttl" mapstructure:"default_ttl"`
+		ExpiryInterval time.Duration `yaml:"expiry_interval" json:"expiry_interval" mapstructure:"expiry_interval"`
+	}
+
+	// WalletSchedules configures the scheduled transfer worker. PollInterval
+	// is how often it looks for due transfers. A failed run is retried after
+	// RetryBackoff, doubling per attempt, until MaxAttempts runs have failed.
+	// Zero values fall back to the service defaults.
+	WalletSchedules struct {
+		PollInterval time.Duration `yaml:"poll_interval" json:"poll_interval" mapstructure:"poll_interval"`
+		MaxAttempts  int           `yaml:"max_attempts" json:"max_attempts" mapstructure:"max_attempts"`
+		RetryBackoff time.Duration `yaml:"retry_backoff" json:"retry_backoff" mapstructure:"retry_backoff"`
+	}
+
+	// WalletOutbox configures the relay publishing domain events. Publisher
+	// is "stdout" (the default), "file", appending to FilePath, or
+	// "webhook", posting to WebhookURL with WebhookTimeout per request. The
+	// relay polls every PollInterval, reading BatchSize events at a time and
+	// holding them for Lease while it publishes them, which must outlast
+	// WebhookTimeout. It retries a failed event after RetryBackoff, doubling
+	// per attempt. Zero values fall back to the relay defaults.
+	WalletOutbox struct {
+		Publisher      string        `yaml:"publisher" json:"publisher" mapstructure:"publisher"`
+		FilePath       string        `yaml:"file_path" json:"file_path" mapstructure:"file_path"`
+		WebhookURL     string        `yaml:"webhook_url" json:"webhook_url" mapstructure:"webhook_url"`
+		WebhookTimeout time.Duration `yaml:"webhook_timeout" json:"webhook_timeout" mapstructure:"webhook_timeout"`
+		PollInterval   time.Duration `yaml:"poll_interval" json:"poll_interval" mapstructure:"poll_interval"`
+		BatchSize      int           `yaml:"batch_size" json:"batch_size" mapstructure:"batch_size"`
+		Lease          time.Duration `yaml:"lease" json:"lease" mapstructure:"lease"`
+		RetryBackoff   time.Duration `yaml:"retry_backoff" json:"retry_backoff" mapstructure:"retry_backoff"`
+	}
+
+	// WalletWebhooks configures the webhook delivery worker. PollInterval is
+	// how often it looks for due deliveries and Timeout bounds each request.
+	// A failed delivery is retried after RetryBackoff, doubling per attempt,
+	// until MaxAttempts attempts have failed. Zero values fall back to the
+	// service defaults.
+	WalletWebhooks struct {
+		PollInterval time.Duration `yaml:"poll_interval" json:"poll_interval" mapstructure:"poll_interval"`
+		Timeout      time.Duration `yaml:"timeout" json:"timeout" mapstructure:"timeout"`
+		MaxAttempts  int           `yaml:"max_attempts" json:"max_attempts" mapstructure:"max_attempts"`
+		RetryBackoff time.Duration `yaml:"retry_backoff" json:"retry_backoff" mapstructure:"retry_backoff"`
 	}
 )
 
+// WalletCurrency is one entry of the currency table accounts can be opened
+// in. Code is the ISO 4217 code and Scale its number of minor-unit digits,
+// e.g. 2 for USD and 0 for JPY. Amounts finer than Scale are rejected.
+type WalletCurrency struct {
+	Code  string `yaml:"code" json:"code" mapstructure:"code"`
+	Scale int    `yaml:"scale" json:"scale" mapstructure:"scale"`
+}
+
+// WalletFX configures cross-currency transfers. Rates maps "BASE/QUOTE" to
+// the number of quote units one base unit buys, e.g. "USD/EUR": "0.92";
+// each direction needs its own entry. Without rates transfers between
+// currencies are refused.
+type WalletFX struct {
+	Rates map[string]string `yaml:"rates" json:"rates" mapstructure:"rates"`
+}
+
+// WalletLimits are the default limits on money leaving an account, in the
+// account's currency; accounts can override each of them. MinAmount and
+// MaxAmount also bound deposits and opening balances. Amounts are decimal
+// strings, empty keeps the built-in default and "0" or a zero count disables
+// the rule. Daily and monthly windows follow the server's calendar.
+type WalletLimits struct {
+	MinAmount     string `yaml:"min_amount" json:"min_amount" mapstructure:"min_amount"`
+	MaxAmount     string `yaml:"max_amount" json:"max_amount" mapstructure:"max_amount"`
+	DailyAmount   string `yaml:"daily_amount" json:"daily_amount" mapstructure:"daily_amount"`
+	MonthlyAmount string `yaml:"monthly_amount" json:"monthly_amount" mapstructure:"monthly_amount"`
+	DailyCount    int    `yaml:"daily_count" json:"daily_count" mapstructure:"daily_count"`
+	MonthlyCount  int    `yaml:"monthly_count" json:"monthly_count" mapstructure:"monthly_count"`
+}
+
+// WalletFee is the fee on one transaction type, keyed by the type in
+// Wallet.Fees, e.g. "transfer" or "withdraw". The fee is Flat plus Percent
+// of the amount, or priced by Tiers when given, and then held between Min
+// and Max. Amounts are decimal strings in the payer's currency; empty values
+// are zero and a zero Min or Max means no cap.
+type WalletFee struct {
+	Flat    string          `yaml:"flat" json:"flat" mapstructure:"flat"`
+	Percent string          `yaml:"percent" json:"percent" mapstructure:"percent"`
+	Min     string          `yaml:"min" json:"min" mapstructure:"min"`
+	Max     string          `yaml:"max" json:"max" mapstructure:"max"`
+	Tiers   []WalletFeeTier `yaml:"tiers" json:"tiers" mapstructure:"tiers"`
+}
+
+// WalletFeeTier prices amounts up to and including UpTo; the first matching
+// tier applies and an empty UpTo matches any amount.
+type WalletFeeTier struct {
+	UpTo    string `yaml:"up_to" json:"up_to" mapstructure:"up_to"`
+	Flat    string `yaml:"flat" json:"flat" mapstructure:"flat"`
+	Percent string `yaml:"percent" json:"percent" mapstructure:"percent"`
+}
+
+// CurrencyScales returns the currency table as scales by code.
+func (w Wallet) CurrencyScales() map[string]int {
+	scales := make(map[string]int, len(w.Currencies))
+	for _, c := range w.Currencies {
+		scales[c.Code] = c.Scale
+	}
+
+	return scales
+}
+
 func LoadConfig(path string) Config {
 
 	var appConfig Config
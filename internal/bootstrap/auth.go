@@ -0,0 +1,34 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	httpDelivery "github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/delivery/http"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+)
+
+// sampleAPIKeys are published in config-local.json and the README, anyone
+// can present them.
+var sampleAPIKeys = map[string]bool{
+	"dev_operator_key_123": true,
+}
+
+// NewAuthenticator wires the API authentication from cfg. It panics on an
+// invalid API key entry, and on a sample key outside the local env.
+func NewAuthenticator(cfg appconfig.AppConfig) *httpDelivery.Authenticator {
+	keys := make(map[string]auth.Principal, len(cfg.APIKeys))
+	for _, k := range cfg.APIKeys {
+		if sampleAPIKeys[k.Key] && cfg.Env != appconfig.EnvLocal {
+			panic(fmt.Errorf("api key of %q is a published sample key, it is only accepted with app.env %q", k.Subject, appconfig.EnvLocal))
+		}
+		keys[k.Key] = auth.Principal{Subject: k.Subject, Role: k.Role}
+	}
+
+	authn, err := httpDelivery.NewAuthenticator(cfg.Secret_Key, keys)
+	if err != nil {
+		panic(err)
+	}
+
+	return authn
+}
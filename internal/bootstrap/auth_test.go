@@ -0,0 +1,28 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+)
+
+func TestNewAuthenticatorSampleKey(t *testing.T) {
+	cfg := appconfig.AppConfig{
+		Secret_Key: "test_secret",
+		APIKeys: []appconfig.AppAPIKey{
+			{Key: "dev_operator_key_123", Subject: "local-operator", Role: consts.RoleOperator},
+		},
+	}
+
+	assert.Panics(t, func() { NewAuthenticator(cfg) })
+
+	cfg.Env = appconfig.EnvLocal
+	assert.NotPanics(t, func() { NewAuthenticator(cfg) })
+
+	cfg.Env = ""
+	cfg.APIKeys[0].Key = "a_key_nobody_has_seen"
+	assert.NotPanics(t, func() { NewAuthenticator(cfg) })
+}
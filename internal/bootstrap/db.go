@@ -15,7 +15,12 @@ func NewDB(cfg appconfig.Database) *db.Repository {
 	if err != nil {
 		panic(err)
 	}
-	return db.NewRepository(session)
+	return db.NewRepository(session, db.WithRetryPolicy(db.RetryPolicy{
+		MaxAttempts:    cfg.Retry.MaxAttempts,
+		InitialBackoff: cfg.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Retry.MaxBackoff,
+		Multiplier:     cfg.Retry.Multiplier,
+	}))
 }
 
 func CreateSession(cfg *appconfig.Database) (*sql.DB, error) {
@@ -0,0 +1,49 @@
+package bootstrap
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/outbox"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+)
+
+// NewOutboxRelay wires the outbox relay and its publisher from cfg. The
+// returned io.Closer releases the publisher. It panics on an invalid outbox
+// configuration.
+func NewOutboxRelay(cfg appconfig.Config) (*outbox.Relay, io.Closer) {
+	publisher, closer, err := outboxPublisher(cfg.Wallet.Outbox)
+	if err != nil {
+		panic(err)
+	}
+
+	repo := repository.NewWalletRepository(NewDB(cfg.Database))
+
+	return outbox.NewRelay(repo, publisher,
+		outbox.WithBatchSize(cfg.Wallet.Outbox.BatchSize),
+		outbox.WithLease(cfg.Wallet.Outbox.Lease),
+		outbox.WithRetryBackoff(cfg.Wallet.Outbox.RetryBackoff),
+	), closer
+}
+
+func outboxPublisher(cfg appconfig.WalletOutbox) (outbox.Publisher, io.Closer, error) {
+	switch cfg.Publisher {
+	case "", consts.OutboxPublisherStdout:
+		return outbox.NewWriterPublisher(os.Stdout), io.NopCloser(nil), nil
+	case consts.OutboxPublisherFile:
+		if cfg.FilePath == "" {
+			return nil, nil, fmt.Errorf("outbox: file publisher needs file_path")
+		}
+		return outbox.NewFilePublisher(cfg.FilePath)
+	case consts.OutboxPublisherWebhook:
+		if cfg.WebhookURL == "" {
+			return nil, nil, fmt.Errorf("outbox: webhook publisher needs webhook_url")
+		}
+		return outbox.NewWebhookPublisher(cfg.WebhookURL, cfg.WebhookTimeout), io.NopCloser(nil), nil
+	default:
+		return nil, nil, fmt.Errorf("outbox: unknown publisher %q", cfg.Publisher)
+	}
+}
@@ -0,0 +1,152 @@
+package bootstrap
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/appconfig"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/service"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+// NewWalletService wires the wallet service and its repository from cfg. It
+// panics on an invalid wallet configuration.
+func NewWalletService(cfg appconfig.Config) service.Wallet {
+	limits, err := walletLimits(cfg.Wallet.Limits)
+	if err != nil {
+		panic(err)
+	}
+
+	fees, err := walletFees(cfg.Wallet.Fees)
+	if err != nil {
+		panic(err)
+	}
+
+	dbClient := NewDB(cfg.Database)
+	repo := repository.NewWalletRepository(dbClient, repository.WithLimits(limits))
+
+	opts := []service.Option{
+		service.WithReversalOverdraft(cfg.Wallet.Reversal.AllowOverdraft),
+		service.WithHoldTTL(cfg.Wallet.Holds.DefaultTTL),
+		service.WithCurrencies(cfg.Wallet.CurrencyScales()),
+		service.WithLimits(limits),
+		service.WithFees(fees),
+		service.WithScheduleRetry(cfg.Wallet.Schedules.MaxAttempts, cfg.Wallet.Schedules.RetryBackoff),
+		service.WithWebhookRetry(cfg.Wallet.Webhooks.MaxAttempts, cfg.Wallet.Webhooks.RetryBackoff),
+	}
+
+	if cfg.Wallet.Webhooks.Timeout > 0 {
+		opts = append(opts, service.WithWebhookClient(&http.Client{Timeout: cfg.Wallet.Webhooks.Timeout}))
+	}
+
+	if len(cfg.Wallet.FX.Rates) > 0 {
+		rates, err := service.NewStaticRateProvider(cfg.Wallet.FX.Rates)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, service.WithFXRateProvider(rates))
+	}
+
+	return service.NewWalletService(repo, opts...)
+}
+
+// walletLimits parses the configured limits on top of
+// repository.DefaultLimits.
+func walletLimits(cfg appconfig.WalletLimits) (repository.Limits, error) {
+	limits := repository.DefaultLimits
+	amounts := []struct {
+		name  string
+		value string
+		field *utils.Decimal
+	}{
+		{repository.LimitMinAmount, cfg.MinAmount, &limits.MinAmount},
+		{repository.LimitMaxAmount, cfg.MaxAmount, &limits.MaxAmount},
+		{repository.LimitDailyAmount, cfg.DailyAmount, &limits.DailyAmount},
+		{repository.LimitMonthlyAmount, cfg.MonthlyAmount, &limits.MonthlyAmount},
+	}
+	for _, a := range amounts {
+		if a.value == "" {
+			continue
+		}
+
+		v, err := utils.ParseDecimal(a.value)
+		if err != nil || v.IsNegative() {
+			return repository.Limits{}, fmt.Errorf("invalid wallet.limits.%s %q", a.name, a.value)
+		}
+		*a.field = v
+	}
+
+	limits.DailyCount = cfg.DailyCount
+	limits.MonthlyCount = cfg.MonthlyCount
+
+	return limits, nil
+}
+
+// walletFees parses the configured fee schedule.
+func walletFees(cfg map[string]appconfig.WalletFee) (service.FeeSchedule, error) {
+	fees := make(service.FeeSchedule, len(cfg))
+	for name, fee := range cfg {
+		trxType := consts.TransactionType(name)
+		if trxType != consts.TransactionTypeTransfer && trxType != consts.TransactionTypeWithdraw {
+			return nil, fmt.Errorf("wallet.fees: fees are not supported on %q", name)
+		}
+
+		p := &feeParser{path: "wallet.fees." + name}
+		rule := service.FeeRule{
+			Flat:    p.amount("flat", fee.Flat),
+			Percent: p.percent("percent", fee.Percent),
+			Min:     p.amount("min", fee.Min),
+			Max:     p.amount("max", fee.Max),
+		}
+
+		for i, t := range fee.Tiers {
+			tier := fmt.Sprintf("tiers[%d].", i)
+			rule.Tiers = append(rule.Tiers, service.FeeTier{
+				UpTo:    p.amount(tier+"up_to", t.UpTo),
+				Flat:    p.amount(tier+"flat", t.Flat),
+				Percent: p.percent(tier+"percent", t.Percent),
+			})
+		}
+
+		if p.err != nil {
+			return nil, p.err
+		}
+		fees[trxType] = rule
+	}
+
+	return fees, nil
+}
+
+// feeParser parses optional non-negative fee settings under path and keeps
+// the first error.
+type feeParser struct {
+	path string
+	err  error
+}
+
+func (p *feeParser) amount(name, value string) utils.Decimal {
+	if value == "" || p.err != nil {
+		return utils.Decimal{}
+	}
+
+	v, err := utils.ParseDecimal(value)
+	if err == nil && v.IsNegative() {
+		err = fmt.Errorf("must not be negative")
+	}
+	if err != nil {
+		p.err = fmt.Errorf("invalid %s.%s %q: %w", p.path, name, value, err)
+	}
+
+	return v
+}
+
+func (p *feeParser) percent(name, value string) utils.Decimal {
+	v := p.amount(name, value)
+	if p.err == nil && !service.ValidPercent(v) {
+		p.err = fmt.Errorf("invalid %s.%s %q: at most 4 decimal places", p.path, name, value)
+	}
+
+	return v
+}
@@ -12,4 +12,117 @@ type TransactionType string
 const (
 	TransactionTypeTransfer TransactionType = "transfer"
 	TransactionTypeDeposit  TransactionType = "deposit"
+	TransactionTypeWithdraw TransactionType = "withdraw"
+	TransactionTypeReversal TransactionType = "reversal"
+	TransactionTypeJournal  TransactionType = "journal"
+)
+
+const (
+	TransactionStatusCompleted         = "completed"
+	TransactionStatusPartiallyReversed = "partially_reversed"
+	TransactionStatusReversed          = "reversed"
+)
+
+// Valid reports whether t is one of the known transaction types.
+func (t TransactionType) Valid() bool {
+	switch t {
+	case TransactionTypeTransfer, TransactionTypeDeposit, TransactionTypeWithdraw, TransactionTypeReversal, TransactionTypeJournal:
+		return true
+	}
+	return false
+}
+
+// Batch modes of POST /v1/transactions/batch.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+const (
+	BatchItemCompleted = "completed"
+	BatchItemFailed    = "failed"
+)
+
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusVoided   = "voided"
+	HoldStatusExpired  = "expired"
+)
+
+// Outbox event types, one per committed change downstream services can react
+// to.
+const (
+	EventAccountCreated       = "account.created"
+	EventAccountStatusChanged = "account.status_changed"
+	EventTransferCompleted    = "transfer.completed"
+	EventDepositCompleted     = "deposit.completed"
+	EventWithdrawalCompleted  = "withdrawal.completed"
+	EventTransactionReversed  = "transaction.reversed"
+	EventJournalPosted        = "journal.posted"
+)
+
+// Outbox publishers the relay can deliver events with.
+const (
+	OutboxPublisherStdout  = "stdout"
+	OutboxPublisherFile    = "file"
+	OutboxPublisherWebhook = "webhook"
+)
+
+// WebhookEventAll subscribes a webhook to every event type.
+const WebhookEventAll = "*"
+
+// Webhook delivery statuses. A pending delivery is due at its next attempt;
+// a failed one used up its attempts and only goes out again when redelivered.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Scheduled transfer frequencies. A once schedule runs a single time at its
+// start; the others repeat from the start until the end date or count.
+const (
+	ScheduleFrequencyOnce    = "once"
+	ScheduleFrequencyDaily   = "daily"
+	ScheduleFrequencyWeekly  = "weekly"
+	ScheduleFrequencyMonthly = "monthly"
+)
+
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusCompleted = "completed"
+	ScheduleStatusCancelled = "cancelled"
+	ScheduleStatusFailed    = "failed"
+)
+
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// DefaultCurrency is the ISO 4217 code of accounts created without one.
+const DefaultCurrency = "USD"
+
+// System account codes. System accounts are created per currency and booked
+// against internally, never addressed by clients. Funding is the other side
+// of money entering or leaving the wallet through deposits and withdrawals;
+// suspense parks money that cannot be attributed yet.
+const (
+	SystemAccountFunding  = "funding"
+	SystemAccountFees     = "fees"
+	SystemAccountFX       = "fx"
+	SystemAccountSuspense = "suspense"
+)
+
+// Roles of an authenticated principal. Customers act on their own behalf;
+// operators and admins run support tooling and may use the admin API. The
+// system role is the background workers' own and is never accepted from a
+// token or API key.
+const (
+	RoleCustomer = "customer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+	RoleSystem   = "system"
 )
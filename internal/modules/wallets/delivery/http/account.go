@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/gorilla/mux"
+)
+
+type changeAccountStatusFunc func(ctx context.Context, accountID int, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error)
+
+func (handler *WalletHandler) FreezeAccountHandler(w http.ResponseWriter, r *http.Request) {
+	handleChangeAccountStatus(w, r, handler.ucase.FreezeAccount)
+}
+
+func (handler *WalletHandler) UnfreezeAccountHandler(w http.ResponseWriter, r *http.Request) {
+	handleChangeAccountStatus(w, r, handler.ucase.UnfreezeAccount)
+}
+
+func (handler *WalletHandler) CloseAccountHandler(w http.ResponseWriter, r *http.Request) {
+	handleChangeAccountStatus(w, r, handler.ucase.CloseAccount)
+}
+
+func handleChangeAccountStatus(w http.ResponseWriter, r *http.Request, change changeAccountStatusFunc) {
+	var reqData presentations.ChangeAccountStatus
+
+	ctx := r.Context()
+
+	accID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+	if err != nil || accID == 0 {
+		writeBadRequest(w, "invalid accountID")
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := change(ctx, accID, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) ListAccountStatusChangesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	accID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+	if err != nil || accID == 0 {
+		writeBadRequest(w, "invalid accountID")
+		return
+	}
+
+	result, err := handler.ucase.ListAccountStatusChanges(ctx, accID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) GetAccountLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	accID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+	if err != nil || accID == 0 {
+		writeBadRequest(w, "invalid accountID")
+		return
+	}
+
+	result, err := handler.ucase.GetAccountLimits(ctx, accID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) SetAccountLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.SetAccountLimits
+
+	ctx := r.Context()
+
+	accID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+	if err != nil || accID == 0 {
+		writeBadRequest(w, "invalid accountID")
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.SetAccountLimits(ctx, accID, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+	"github.com/gorilla/mux"
+)
+
+// HeaderAPIKey carries a static API key, the alternative to a bearer token
+// in the Authorization header.
+const HeaderAPIKey = "X-API-Key"
+
+// Error codes of requests refused before they reach a handler.
+const (
+	ErrCodeUnauthenticated = "UNAUTHENTICATED"
+	ErrCodeForbidden       = "FORBIDDEN"
+)
+
+// Authenticator resolves the principal of a request from its bearer token or
+// API key.
+type Authenticator struct {
+	secret  string
+	apiKeys map[[sha256.Size]byte]auth.Principal
+	now     func() time.Time
+}
+
+// NewAuthenticator returns an Authenticator accepting HS256 tokens signed
+// with secret and the given API keys, keyed by the key itself. An empty
+// secret disables bearer tokens.
+func NewAuthenticator(secret string, apiKeys map[string]auth.Principal) (*Authenticator, error) {
+	a := &Authenticator{
+		secret:  secret,
+		apiKeys: make(map[[sha256.Size]byte]auth.Principal, len(apiKeys)),
+		now:     time.Now,
+	}
+
+	for key, p := range apiKeys {
+		if key == "" || p.Subject == "" || !validRole(p.Role) {
+			return nil, fmt.Errorf("invalid api key of %q: key, subject and a known role are required", p.Subject)
+		}
+		// keys are looked up by hash, so the lookup time does not depend on
+		// how much of a guessed key matches
+		a.apiKeys[sha256.Sum256([]byte(key))] = p
+	}
+
+	if secret == "" {
+		slog.Warn("[NewAuthenticator] no secret key configured, bearer tokens are refused")
+	}
+
+	return a, nil
+}
+
+// Middleware refuses requests without valid credentials with 401 and passes
+// the others on with their principal in the request context.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.authenticate(r)
+		if err != nil {
+			slog.Warn("[Authenticator] failed authenticate", slog.String("path", r.URL.Path), slog.Any("err", err))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="wallet"`)
+			writeAuthError(w, http.StatusUnauthorized, ErrCodeUnauthenticated, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), p)))
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (auth.Principal, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || a.secret == "" {
+			return auth.Principal{}, errors.New("unsupported authorization")
+		}
+
+		claims, err := auth.ParseToken(a.secret, strings.TrimSpace(token), a.now())
+		if err != nil {
+			return auth.Principal{}, err
+		}
+
+		role := claims.Role
+		if role == "" {
+			role = consts.RoleCustomer
+		}
+
+		if !validRole(role) {
+			return auth.Principal{}, fmt.Errorf("unknown role %q", role)
+		}
+
+		return auth.Principal{Subject: claims.Subject, Role: role}, nil
+	}
+
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		p, ok := a.apiKeys[sha256.Sum256([]byte(key))]
+		if !ok {
+			return auth.Principal{}, errors.New("invalid api key")
+		}
+
+		return p, nil
+	}
+
+	return auth.Principal{}, errors.New("missing credentials")
+}
+
+// RequireRole refuses requests whose principal has none of roles with 403.
+// It runs after Authenticator.Middleware.
+func RequireRole(roles ...string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := auth.FromContext(r.Context())
+			if !ok {
+				writeAuthError(w, http.StatusUnauthorized, ErrCodeUnauthenticated, "missing credentials")
+				return
+			}
+
+			for _, role := range roles {
+				if p.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			slog.Warn("[RequireRole] forbidden", slog.String("path", r.URL.Path), slog.String("subject", p.Subject), slog.String("role", p.Role))
+			writeAuthError(w, http.StatusForbidden, ErrCodeForbidden, fmt.Sprintf("role %q may not access this resource", p.Role))
+		})
+	}
+}
+
+func validRole(role string) bool {
+	switch role {
+	case consts.RoleCustomer, consts.RoleOperator, consts.RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func writeAuthError(w http.ResponseWriter, code int, errorCode, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(ResponsePayload{
+		Code:      code,
+		ErrorCode: errorCode,
+		Message:   message,
+	})
+}
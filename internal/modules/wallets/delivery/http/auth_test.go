@@ -0,0 +1,155 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator(t *testing.T) {
+	const secret = "super_secret_key_123"
+
+	authn, err := NewAuthenticator(secret, map[string]auth.Principal{
+		"operator-key": {Subject: "support", Role: consts.RoleOperator},
+	})
+	require.NoError(t, err)
+
+	var got auth.Principal
+	r := mux.NewRouter()
+	r.Use(authn.Middleware)
+	r.HandleFunc("/v1/accounts/1", func(w http.ResponseWriter, r *http.Request) {
+		got, _ = auth.FromContext(r.Context())
+	})
+	admin := r.PathPrefix("/v1/admin").Subrouter()
+	admin.Use(RequireRole(consts.RoleOperator, consts.RoleAdmin))
+	admin.HandleFunc("/accounts/1/freeze", func(w http.ResponseWriter, r *http.Request) {
+		got, _ = auth.FromContext(r.Context())
+	})
+
+	signed := func(secret string, claims auth.Claims) string {
+		s, err := auth.SignToken(secret, claims)
+		require.NoError(t, err)
+		return "Bearer " + s
+	}
+	token := func(claims auth.Claims) string { return signed(secret, claims) }
+	inAnHour := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name      string
+		path      string
+		header    http.Header
+		code      int
+		errorCode string
+		want      auth.Principal
+	}{
+		{
+			name:      "FAILED no credentials",
+			path:      "/v1/accounts/1",
+			header:    http.Header{},
+			code:      http.StatusUnauthorized,
+			errorCode: ErrCodeUnauthenticated,
+		},
+		{
+			name:      "FAILED token of another secret",
+			path:      "/v1/accounts/1",
+			header:    http.Header{"Authorization": {signed("other", auth.Claims{Subject: "1"})}},
+			code:      http.StatusUnauthorized,
+			errorCode: ErrCodeUnauthenticated,
+		},
+		{
+			name:      "FAILED token without expiry",
+			path:      "/v1/accounts/1",
+			header:    http.Header{"Authorization": {token(auth.Claims{Subject: "1"})}},
+			code:      http.StatusUnauthorized,
+			errorCode: ErrCodeUnauthenticated,
+		},
+		{
+			name:      "FAILED expired token",
+			path:      "/v1/accounts/1",
+			header:    http.Header{"Authorization": {token(auth.Claims{Subject: "1", ExpiresAt: time.Now().Add(-time.Minute).Unix()})}},
+			code:      http.StatusUnauthorized,
+			errorCode: ErrCodeUnauthenticated,
+		},
+		{
+			name:      "FAILED unknown role",
+			path:      "/v1/accounts/1",
+			header:    http.Header{"Authorization": {token(auth.Claims{Subject: "1", Role: "root", ExpiresAt: inAnHour})}},
+			code:      http.StatusUnauthorized,
+			errorCode: ErrCodeUnauthenticated,
+		},
+		{
+			name:      "FAILED unknown api key",
+			path:      "/v1/accounts/1",
+			header:    http.Header{HeaderAPIKey: {"guess"}},
+			code:      http.StatusUnauthorized,
+			errorCode: ErrCodeUnauthenticated,
+		},
+		{
+			name:      "FAILED customer on admin route",
+			path:      "/v1/admin/accounts/1/freeze",
+			header:    http.Header{"Authorization": {token(auth.Claims{Subject: "1", ExpiresAt: inAnHour})}},
+			code:      http.StatusForbidden,
+			errorCode: ErrCodeForbidden,
+		},
+		{
+			name:   "SUCCESS token defaults to customer",
+			path:   "/v1/accounts/1",
+			header: http.Header{"Authorization": {token(auth.Claims{Subject: "1", ExpiresAt: inAnHour})}},
+			code:   http.StatusOK,
+			want:   auth.Principal{Subject: "1", Role: consts.RoleCustomer},
+		},
+		{
+			name:   "SUCCESS admin token on admin route",
+			path:   "/v1/admin/accounts/1/freeze",
+			header: http.Header{"Authorization": {token(auth.Claims{Subject: "alice", Role: consts.RoleAdmin, ExpiresAt: inAnHour})}},
+			code:   http.StatusOK,
+			want:   auth.Principal{Subject: "alice", Role: consts.RoleAdmin},
+		},
+		{
+			name:   "SUCCESS api key on admin route",
+			path:   "/v1/admin/accounts/1/freeze",
+			header: http.Header{HeaderAPIKey: {"operator-key"}},
+			code:   http.StatusOK,
+			want:   auth.Principal{Subject: "support", Role: consts.RoleOperator},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = auth.Principal{}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v[0])
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.code, rec.Code)
+			assert.Equal(t, tt.want, got)
+			if tt.errorCode == "" {
+				return
+			}
+
+			var resp ResponsePayload
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Equal(t, tt.errorCode, resp.ErrorCode)
+		})
+	}
+}
+
+func TestNewAuthenticatorRejectsInvalidKeys(t *testing.T) {
+	_, err := NewAuthenticator("secret", map[string]auth.Principal{
+		"key": {Subject: "support", Role: "root"},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/gorilla/mux"
+)
+
+func (handler *WalletHandler) CreateCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateCustomer
+
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.CreateCustomer(ctx, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) GetCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := handler.ucase.GetCustomer(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) SetAccountOwnerHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.SetAccountOwner
+
+	ctx := r.Context()
+
+	accID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+	if err != nil || accID == 0 {
+		writeBadRequest(w, "invalid accountID")
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.SetAccountOwner(ctx, accID, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/gorilla/mux"
+)
+
+func (handler *WalletHandler) CreateHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateHold
+
+	ctx := r.Context()
+
+	json.NewDecoder(r.Body).Decode(&reqData)
+	if reqData.AccountID == 0 || reqData.DestinationAccountID == 0 || reqData.Amount == "" {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.CreateHold(ctx, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) GetHoldHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := handler.ucase.GetHold(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) CaptureHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CaptureHold
+
+	ctx := r.Context()
+
+	// the body is optional, an empty one captures the whole hold
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil && err != io.EOF {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.CaptureHold(ctx, mux.Vars(r)["id"], reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) VoidHoldHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := handler.ucase.VoidHold(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+)
+
+func (handler *WalletHandler) CreateJournalHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateJournal
+
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil || len(reqData.Legs) == 0 {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	ref, ok := idempotencyKey(r, reqData.ReferenceNumber)
+	if !ok {
+		writeBadRequest(w, "Idempotency-Key header does not match reference_number")
+		return
+	}
+	reqData.ReferenceNumber = ref
+
+	result, err := handler.ucase.PostJournal(ctx, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -1,9 +1,72 @@
 package http
 
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/service"
+)
+
+// ErrCodeInvalidRequest is reported for requests rejected by the handler
+// before they reach the service, e.g. malformed JSON or path parameters.
+const ErrCodeInvalidRequest = "INVALID_REQUEST"
+
 type (
 	ResponsePayload struct {
-		Code    int         `json:"code"`
-		Message string      `json:"message"`
-		Data    interface{} `json:"data,omitempty"`
+		Code      int         `json:"code"`
+		ErrorCode string      `json:"error_code,omitempty"`
+		Message   string      `json:"message"`
+		Data      interface{} `json:"data,omitempty"`
 	}
 )
+
+var statusByKind = map[service.ErrorKind]int{
+	service.KindValidation:        http.StatusBadRequest,
+	service.KindNotFound:          http.StatusNotFound,
+	service.KindConflict:          http.StatusConflict,
+	service.KindInsufficientFunds: http.StatusUnprocessableEntity,
+	service.KindLimitExceeded:     http.StatusUnprocessableEntity,
+	service.KindRetryable:         http.StatusServiceUnavailable,
+	service.KindForbidden:         http.StatusForbidden,
+}
+
+// writeError renders a service error. Only *service.Error messages reach the
+// client; anything else is logged and reported as a generic internal error.
+func writeError(w http.ResponseWriter, err error) {
+	var e *service.Error
+	if !errors.As(err, &e) {
+		e = service.ErrInternal
+	}
+
+	code, ok := statusByKind[e.Kind]
+	if !ok {
+		code = http.StatusInternalServerError
+		slog.Error("[writeError] internal error", slog.Any("err", err))
+	}
+
+	if e.Kind == service.KindRetryable {
+		w.Header().Set("Retry-After", "1")
+	}
+
+	w.WriteHeader(code)
+	resp := ResponsePayload{
+		Code:      code,
+		ErrorCode: e.Code,
+		Message:   e.Message,
+	}
+	if e.Details != nil {
+		resp.Data = e.Details
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeBadRequest(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(ResponsePayload{
+		Code:      http.StatusBadRequest,
+		ErrorCode: ErrCodeInvalidRequest,
+		Message:   message,
+	})
+}
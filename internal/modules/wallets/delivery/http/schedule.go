@@ -0,0 +1,113 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/gorilla/mux"
+)
+
+func (handler *WalletHandler) CreateScheduledTransferHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateScheduledTransfer
+
+	ctx := r.Context()
+
+	json.NewDecoder(r.Body).Decode(&reqData)
+	if reqData.SourceAccountID == 0 || reqData.DestinationAccountID == 0 || reqData.Amount == "" || reqData.Frequency == "" {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.CreateScheduledTransfer(ctx, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) ListScheduledTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	reqData := presentations.ListScheduledTransfers{
+		Status: query.Get("status"),
+	}
+
+	if accountID := query.Get("account_id"); accountID != "" {
+		reqData.AccountID, err = strconv.Atoi(accountID)
+		if err != nil || reqData.AccountID == 0 {
+			writeBadRequest(w, "invalid accountID")
+			return
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		reqData.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			writeBadRequest(w, "invalid limit")
+			return
+		}
+	}
+
+	result, err := handler.ucase.ListScheduledTransfers(ctx, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) GetScheduledTransferHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := handler.ucase.GetScheduledTransfer(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) UpdateScheduledTransferHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.UpdateScheduledTransfer
+
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.UpdateScheduledTransfer(ctx, mux.Vars(r)["id"], reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) CancelScheduledTransferHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := handler.ucase.CancelScheduledTransfer(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -2,29 +2,83 @@ package http
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/delivery/http/middlewares"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/service"
 	"github.com/gorilla/mux"
 )
 
+// HeaderIdempotencyKey carries the client chosen key that makes retries of
+// account creation and transfers safe. It takes the place of reference_number
+// in the request body.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
 type WalletHandler struct {
 	ucase service.Wallet
 }
 
-func NewWalletHandler(r *mux.Router, ucase service.Wallet) {
+// NewWalletHandler registers the wallet API on r. Every route requires a
+// principal authenticated by authn; deposits, reversals, journals, webhooks
+// and the admin API are limited to operators and admins. Which accounts a customer
+// may act on is decided by the service.
+func NewWalletHandler(r *mux.Router, ucase service.Wallet, authn *Authenticator) {
 	handler := &WalletHandler{
 		ucase: ucase,
 	}
 
-	r.Use(middlewares.CommonMiddleware, middlewares.LoggingMiddleware)
+	r.Use(middlewares.CommonMiddleware, middlewares.LoggingMiddleware, authn.Middleware)
+
+	staffOnly := RequireRole(consts.RoleOperator, consts.RoleAdmin)
 
 	r.HandleFunc("/v1/accounts", handler.CreateAccountHandler).Methods(http.MethodPost)
 	r.HandleFunc("/v1/accounts/{account_id}", handler.GetAccountHandler).Methods(http.MethodGet)
+	r.Handle("/v1/accounts/{account_id}/deposits", staffOnly(http.HandlerFunc(handler.CreateDepositHandler))).Methods(http.MethodPost)
+	r.HandleFunc("/v1/accounts/{account_id}/transactions", handler.ListAccountTransactionsHandler).Methods(http.MethodGet)
 	r.HandleFunc("/v1/transactions", handler.CreateTransactionHandler).Methods(http.MethodPost)
+	r.HandleFunc("/v1/transactions", handler.GetTransactionByReferenceHandler).Methods(http.MethodGet)
+	r.HandleFunc("/v1/transactions/batch", handler.CreateTransactionBatchHandler).Methods(http.MethodPost)
+	r.HandleFunc("/v1/transactions/{id}", handler.GetTransactionHandler).Methods(http.MethodGet)
+	r.Handle("/v1/transactions/{id}/reversal", staffOnly(http.HandlerFunc(handler.CreateReversalHandler))).Methods(http.MethodPost)
+	r.Handle("/v1/journals", staffOnly(http.HandlerFunc(handler.CreateJournalHandler))).Methods(http.MethodPost)
+	r.HandleFunc("/v1/withdrawals", handler.CreateWithdrawalHandler).Methods(http.MethodPost)
+	r.HandleFunc("/v1/holds", handler.CreateHoldHandler).Methods(http.MethodPost)
+	r.HandleFunc("/v1/holds/{id}", handler.GetHoldHandler).Methods(http.MethodGet)
+	r.HandleFunc("/v1/holds/{id}/capture", handler.CaptureHoldHandler).Methods(http.MethodPost)
+	r.HandleFunc("/v1/holds/{id}/void", handler.VoidHoldHandler).Methods(http.MethodPost)
+	r.HandleFunc("/v1/scheduled-transfers", handler.CreateScheduledTransferHandler).Methods(http.MethodPost)
+	r.HandleFunc("/v1/scheduled-transfers", handler.ListScheduledTransfersHandler).Methods(http.MethodGet)
+	r.HandleFunc("/v1/scheduled-transfers/{id}", handler.GetScheduledTransferHandler).Methods(http.MethodGet)
+	r.HandleFunc("/v1/scheduled-transfers/{id}", handler.UpdateScheduledTransferHandler).Methods(http.MethodPatch)
+	r.HandleFunc("/v1/scheduled-transfers/{id}", handler.CancelScheduledTransferHandler).Methods(http.MethodDelete)
+
+	webhooks := r.PathPrefix("/v1/webhooks").Subrouter()
+	webhooks.Use(staffOnly)
+	webhooks.HandleFunc("", handler.CreateWebhookHandler).Methods(http.MethodPost)
+	webhooks.HandleFunc("", handler.ListWebhooksHandler).Methods(http.MethodGet)
+	webhooks.HandleFunc("/{id}", handler.GetWebhookHandler).Methods(http.MethodGet)
+	webhooks.HandleFunc("/{id}", handler.UpdateWebhookHandler).Methods(http.MethodPatch)
+	webhooks.HandleFunc("/{id}", handler.DeleteWebhookHandler).Methods(http.MethodDelete)
+	webhooks.HandleFunc("/{id}/deliveries", handler.ListWebhookDeliveriesHandler).Methods(http.MethodGet)
+	webhooks.HandleFunc("/{id}/deliveries/{delivery_id}", handler.GetWebhookDeliveryHandler).Methods(http.MethodGet)
+	webhooks.HandleFunc("/{id}/deliveries/{delivery_id}/redeliver", handler.RedeliverWebhookHandler).Methods(http.MethodPost)
+
+	admin := r.PathPrefix("/v1/admin").Subrouter()
+	admin.Use(staffOnly)
+	admin.HandleFunc("/accounts/{account_id}/freeze", handler.FreezeAccountHandler).Methods(http.MethodPost)
+	admin.HandleFunc("/accounts/{account_id}/unfreeze", handler.UnfreezeAccountHandler).Methods(http.MethodPost)
+	admin.HandleFunc("/accounts/{account_id}/close", handler.CloseAccountHandler).Methods(http.MethodPost)
+	admin.HandleFunc("/accounts/{account_id}/status-changes", handler.ListAccountStatusChangesHandler).Methods(http.MethodGet)
+	admin.HandleFunc("/accounts/{account_id}/limits", handler.GetAccountLimitsHandler).Methods(http.MethodGet)
+	admin.HandleFunc("/accounts/{account_id}/limits", handler.SetAccountLimitsHandler).Methods(http.MethodPut)
+	admin.HandleFunc("/accounts/{account_id}/owner", handler.SetAccountOwnerHandler).Methods(http.MethodPut)
+	admin.HandleFunc("/customers", handler.CreateCustomerHandler).Methods(http.MethodPost)
+	admin.HandleFunc("/customers/{id}", handler.GetCustomerHandler).Methods(http.MethodGet)
 }
 
 func (handler *WalletHandler) GetAccountHandler(w http.ResponseWriter, r *http.Request) {
@@ -32,42 +86,15 @@ func (handler *WalletHandler) GetAccountHandler(w http.ResponseWriter, r *http.R
 
 	accountID := mux.Vars(r)["account_id"]
 	accID, err := strconv.Atoi(accountID)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ResponsePayload{
-			Code:    http.StatusBadRequest,
-			Message: err.Error(),
-		})
-		return
-	}
-
-	if accID == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ResponsePayload{
-			Code:    http.StatusBadRequest,
-			Message: "invalid accountID",
-		})
+	if err != nil || accID == 0 {
+		writeBadRequest(w, "invalid accountID")
 		return
 	}
 
 	w.Header().Add("Content-Type", "application/json")
 	result, err := handler.ucase.GetAccount(ctx, accID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ResponsePayload{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
-		return
-	}
-
-	if result.AccountID == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(ResponsePayload{
-			Code:    http.StatusNotFound,
-			Message: "DATA_NOT_FOUND",
-		})
-
+		writeError(w, err)
 		return
 	}
 
@@ -82,21 +109,20 @@ func (handler *WalletHandler) CreateAccountHandler(w http.ResponseWriter, r *htt
 
 	json.NewDecoder(r.Body).Decode(&reqData)
 	if reqData.AccountID == 0 || reqData.InitialBalance == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ResponsePayload{
-			Code:    http.StatusBadRequest,
-			Message: "invalid request body",
-		})
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	ref, ok := idempotencyKey(r, reqData.ReferenceNumber)
+	if !ok {
+		writeBadRequest(w, "Idempotency-Key header does not match reference_number")
 		return
 	}
+	reqData.ReferenceNumber = ref
 
 	err := handler.ucase.CreateAccount(ctx, reqData)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ResponsePayload{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		writeError(w, err)
 		return
 	}
 
@@ -111,23 +137,203 @@ func (handler *WalletHandler) CreateTransactionHandler(w http.ResponseWriter, r
 
 	json.NewDecoder(r.Body).Decode(&reqData)
 	if reqData.SourceAccountID == 0 || reqData.DestinationAccountID == 0 || reqData.Amount == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ResponsePayload{
-			Code:    http.StatusBadRequest,
-			Message: "invalid request body",
-		})
+		writeBadRequest(w, "invalid request body")
 		return
 	}
 
-	err := handler.ucase.SubmitTransaction(ctx, reqData)
+	ref, ok := idempotencyKey(r, reqData.ReferenceNumber)
+	if !ok {
+		writeBadRequest(w, "Idempotency-Key header does not match reference_number")
+		return
+	}
+	reqData.ReferenceNumber = ref
+
+	result, err := handler.ucase.SubmitTransaction(ctx, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) CreateTransactionBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateTransactionBatch
+
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil || len(reqData.Transfers) == 0 {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.SubmitTransactionBatch(ctx, reqData)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ResponsePayload{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		writeError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) GetTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := handler.ucase.GetTransaction(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) GetTransactionByReferenceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ref := r.URL.Query().Get("reference_number")
+	if ref == "" {
+		writeBadRequest(w, "reference_number is required")
+		return
+	}
+
+	result, err := handler.ucase.GetTransactionByReference(ctx, ref)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) CreateReversalHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateReversal
+
+	ctx := r.Context()
+
+	// the body is optional, an empty one reverses the remaining amount
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil && err != io.EOF {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	ref, ok := idempotencyKey(r, reqData.ReferenceNumber)
+	if !ok {
+		writeBadRequest(w, "Idempotency-Key header does not match reference_number")
+		return
+	}
+	reqData.ReferenceNumber = ref
+
+	result, err := handler.ucase.ReverseTransaction(ctx, mux.Vars(r)["id"], reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) CreateWithdrawalHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateWithdrawal
+
+	ctx := r.Context()
+
+	json.NewDecoder(r.Body).Decode(&reqData)
+	if reqData.AccountID == 0 || reqData.Amount == "" {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	err := handler.ucase.Withdraw(ctx, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (handler *WalletHandler) CreateDepositHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateDeposit
+
+	ctx := r.Context()
+
+	accID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+	if err != nil || accID == 0 {
+		writeBadRequest(w, "invalid accountID")
+		return
+	}
+
+	json.NewDecoder(r.Body).Decode(&reqData)
+	if reqData.Amount == "" {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	err = handler.ucase.Deposit(ctx, accID, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (handler *WalletHandler) ListAccountTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	accID, err := strconv.Atoi(mux.Vars(r)["account_id"])
+	if err != nil || accID == 0 {
+		writeBadRequest(w, "invalid accountID")
+		return
+	}
+
+	query := r.URL.Query()
+	reqData := presentations.ListAccountTransactions{
+		Cursor:    query.Get("cursor"),
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		reqData.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			writeBadRequest(w, "invalid limit")
+			return
+		}
+	}
+
+	// accept both ?type=deposit&type=transfer and ?type=deposit,transfer
+	for _, t := range query["type"] {
+		reqData.Types = append(reqData.Types, strings.Split(t, ",")...)
+	}
+
+	result, err := handler.ucase.ListAccountTransactions(ctx, accID, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// idempotencyKey merges the Idempotency-Key header with the reference_number
+// body field. It reports false when both are set and disagree.
+func idempotencyKey(r *http.Request, bodyRef string) (string, bool) {
+	key := r.Header.Get(HeaderIdempotencyKey)
+	if key == "" {
+		return bodyRef, true
+	}
+
+	if bodyRef != "" && bodyRef != key {
+		return "", false
+	}
+
+	return key, true
 }
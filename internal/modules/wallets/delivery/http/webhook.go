@@ -0,0 +1,144 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/gorilla/mux"
+)
+
+func (handler *WalletHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.CreateWebhook
+
+	ctx := r.Context()
+
+	json.NewDecoder(r.Body).Decode(&reqData)
+	if reqData.URL == "" || len(reqData.EventTypes) == 0 {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.CreateWebhook(ctx, reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := handler.ucase.ListWebhooks(ctx)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := handler.ucase.GetWebhook(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var reqData presentations.UpdateWebhook
+
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		writeBadRequest(w, "invalid request body")
+		return
+	}
+
+	result, err := handler.ucase.UpdateWebhook(ctx, mux.Vars(r)["id"], reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := handler.ucase.DeleteWebhook(ctx, mux.Vars(r)["id"]); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *WalletHandler) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	reqData := presentations.ListWebhookDeliveries{
+		Status: query.Get("status"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		reqData.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			writeBadRequest(w, "invalid limit")
+			return
+		}
+	}
+
+	result, err := handler.ucase.ListWebhookDeliveries(ctx, mux.Vars(r)["id"], reqData)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) GetWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	result, err := handler.ucase.GetWebhookDelivery(ctx, vars["id"], vars["delivery_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler *WalletHandler) RedeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	result, err := handler.ucase.RedeliverWebhook(ctx, vars["id"], vars["delivery_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(result)
+}
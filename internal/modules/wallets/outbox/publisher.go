@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+)
+
+// Publisher delivers outbox events to downstream consumers. Publish returns
+// nil only once the event is delivered; on error it is retried later, so
+// consumers must tolerate duplicates, e.g. by event id.
+type Publisher interface {
+	Publish(ctx context.Context, event repository.OutboxEvent) error
+}
+
+// WriterPublisher writes every event as one JSON line to an io.Writer, such
+// as stdout or a file.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+// NewFilePublisher appends events to the file at path, creating it if
+// needed. The caller closes the returned file when done.
+func NewFilePublisher(path string) (*WriterPublisher, *os.File, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open outbox file: %w", err)
+	}
+
+	return NewWriterPublisher(f), f, nil
+}
+
+func (p *WriterPublisher) Publish(ctx context.Context, event repository.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return nil
+}
+
+const defaultWebhookTimeout = 10 * time.Second
+
+// WebhookPublisher POSTs every event as JSON to a URL. Any response other
+// than 2xx counts as a failed delivery.
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookPublisher publishes to url, giving up on a request after
+// timeout. Non-positive timeouts fall back to 10 seconds.
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event repository.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.EventID)
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+)
+
+func newTestEvent(id int64) repository.OutboxEvent {
+	return repository.OutboxEvent{
+		ID:         id,
+		EventID:    "8b0f7c8e-7e0c-4f4e-9a55-3c1d1f7b2a10",
+		Type:       consts.EventTransferCompleted,
+		AccountIDs: []int64{1, 2},
+		Data:       json.RawMessage(`{"transaction":{"id":"t1"}}`),
+		CreatedAt:  time.Date(2025, 7, 8, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWriterPublisher(&buf)
+
+	require.NoError(t, p.Publish(context.Background(), newTestEvent(1)))
+	require.NoError(t, p.Publish(context.Background(), newTestEvent(2)))
+
+	assert.Equal(t, `{"id":"8b0f7c8e-7e0c-4f4e-9a55-3c1d1f7b2a10","type":"transfer.completed","account_ids":[1,2],"data":{"transaction":{"id":"t1"}},"created_at":"2025-07-08T09:00:00Z"}`+"\n"+
+		`{"id":"8b0f7c8e-7e0c-4f4e-9a55-3c1d1f7b2a10","type":"transfer.completed","account_ids":[1,2],"data":{"transaction":{"id":"t1"}},"created_at":"2025-07-08T09:00:00Z"}`+"\n",
+		buf.String())
+}
+
+func TestFilePublisherAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	for i := 0; i < 2; i++ {
+		p, f, err := NewFilePublisher(path)
+		require.NoError(t, err)
+		require.NoError(t, p.Publish(context.Background(), newTestEvent(int64(i))))
+		require.NoError(t, f.Close())
+	}
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(content, []byte("\n")))
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "delivered", status: http.StatusNoContent},
+		{name: "rejected", status: http.StatusBadRequest, wantErr: true},
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotHeader http.Header
+				gotBody   []byte
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader = r.Header
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			err := NewWebhookPublisher(srv.URL, time.Second).Publish(context.Background(), newTestEvent(1))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, "application/json", gotHeader.Get("Content-Type"))
+			assert.Equal(t, "8b0f7c8e-7e0c-4f4e-9a55-3c1d1f7b2a10", gotHeader.Get("X-Event-Id"))
+			assert.Equal(t, consts.EventTransferCompleted, gotHeader.Get("X-Event-Type"))
+
+			var event repository.OutboxEvent
+			require.NoError(t, json.Unmarshal(gotBody, &event))
+			assert.Equal(t, consts.EventTransferCompleted, event.Type)
+		})
+	}
+}
+
+func TestWebhookPublisherTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	err := NewWebhookPublisher(srv.URL, 50*time.Millisecond).Publish(context.Background(), newTestEvent(1))
+	assert.Error(t, err)
+}
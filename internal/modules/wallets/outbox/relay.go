@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultLease        = time.Minute
+	defaultRetryBackoff = 5 * time.Second
+	maxRetryBackoff     = time.Hour
+)
+
+// Store is the part of the wallet repository the relay reads events from.
+type Store interface {
+	RelayOutbox(ctx context.Context, limit int, lease time.Duration, backoff repository.OutboxBackoff, publish repository.OutboxPublishFunc) (int, error)
+}
+
+// Relay moves events from the outbox to a Publisher. Delivery is at least
+// once and in order per account, see WalletRepository.RelayOutbox.
+type Relay struct {
+	store     Store
+	publisher Publisher
+
+	// batchSize is how many events one outbox transaction reads.
+	batchSize int
+
+	// lease is how long the relay holds the events of a batch. Publishing
+	// stops once it runs out, so it must outlast a single publish.
+	lease time.Duration
+
+	// retryBackoff is the wait before the first retry of a failed event,
+	// doubling with every further failure up to maxRetryBackoff.
+	retryBackoff time.Duration
+}
+
+// Option customises the relay.
+type Option func(*Relay)
+
+// WithBatchSize sets how many events are read per outbox transaction.
+// Non-positive values keep the default.
+func WithBatchSize(n int) Option {
+	return func(r *Relay) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithLease sets how long the events of a batch are held for publishing.
+// Non-positive values keep the default.
+func WithLease(d time.Duration) Option {
+	return func(r *Relay) {
+		if d > 0 {
+			r.lease = d
+		}
+	}
+}
+
+// WithRetryBackoff sets the wait before a failed event is retried the first
+// time. Non-positive values keep the default.
+func WithRetryBackoff(d time.Duration) Option {
+	return func(r *Relay) {
+		if d > 0 {
+			r.retryBackoff = d
+		}
+	}
+}
+
+func NewRelay(store Store, publisher Publisher, opts ...Option) *Relay {
+	r := &Relay{
+		store:        store,
+		publisher:    publisher,
+		batchSize:    defaultBatchSize,
+		lease:        defaultLease,
+		retryBackoff: defaultRetryBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// RelayOnce publishes batches until the outbox holds nothing deliverable and
+// returns how many events were published.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := r.store.RelayOutbox(ctx, r.batchSize, r.lease, r.backoff, r.publisher.Publish)
+		total += n
+		if err != nil {
+			return total, err
+		}
+
+		// a short batch means the rest is held back or not there
+		if n < r.batchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// Run relays right away and then every interval until ctx is done.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayOnce(ctx); err != nil {
+			slog.Warn("[Relay.Run] failed relay outbox", slog.Any("err", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.retryBackoff
+	for i := 1; i < attempts && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+
+	if d > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+
+	return d
+}
@@ -0,0 +1,106 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+)
+
+// fakeStore hands out its events in batches and drops the published ones.
+type fakeStore struct {
+	events []repository.OutboxEvent
+	calls  int
+	err    error
+}
+
+func (s *fakeStore) RelayOutbox(ctx context.Context, limit int, lease time.Duration, backoff repository.OutboxBackoff, publish repository.OutboxPublishFunc) (int, error) {
+	s.calls++
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	n := 0
+	var rest []repository.OutboxEvent
+	for i, e := range s.events {
+		if i >= limit || publish(ctx, e) != nil {
+			rest = append(rest, e)
+			continue
+		}
+		n++
+	}
+	s.events = rest
+
+	return n, nil
+}
+
+type publisherFunc func(ctx context.Context, event repository.OutboxEvent) error
+
+func (f publisherFunc) Publish(ctx context.Context, event repository.OutboxEvent) error {
+	return f(ctx, event)
+}
+
+func TestRelayOnce(t *testing.T) {
+	events := make([]repository.OutboxEvent, 5)
+	for i := range events {
+		events[i] = newTestEvent(int64(i + 1))
+	}
+
+	tests := []struct {
+		name      string
+		store     *fakeStore
+		failID    int64
+		want      int
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "drains the outbox in batches",
+			store:     &fakeStore{events: events},
+			want:      5,
+			wantCalls: 3,
+		},
+		{
+			name:      "stops at a short batch",
+			store:     &fakeStore{events: events},
+			failID:    2,
+			want:      1,
+			wantCalls: 1,
+		},
+		{
+			name:      "store error",
+			store:     &fakeStore{events: events, err: errors.New("connection refused")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := publisherFunc(func(ctx context.Context, e repository.OutboxEvent) error {
+				if e.ID == tt.failID {
+					return errors.New("unavailable")
+				}
+				return nil
+			})
+
+			got, err := NewRelay(tt.store, publisher, WithBatchSize(2)).RelayOnce(context.Background())
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantCalls, tt.store.calls)
+		})
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := NewRelay(&fakeStore{}, nil, WithRetryBackoff(time.Second))
+
+	assert.Equal(t, time.Second, r.backoff(1))
+	assert.Equal(t, 2*time.Second, r.backoff(2))
+	assert.Equal(t, 8*time.Second, r.backoff(4))
+	assert.Equal(t, maxRetryBackoff, r.backoff(40))
+}
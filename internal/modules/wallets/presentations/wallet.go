@@ -1,19 +1,385 @@
 package presentations
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
 type (
+	// CreateAccount opens an account. Currency is an ISO 4217 code and
+	// defaults to USD when empty. CustomerID owns the account; a customer
+	// always opens accounts for itself and may leave it out.
 	CreateAccount struct {
-		AccountID      int    `json:"account_id"`
-		InitialBalance string `json:"initial_balance"`
+		AccountID       int    `json:"account_id"`
+		Currency        string `json:"currency,omitempty"`
+		InitialBalance  string `json:"initial_balance"`
+		ReferenceNumber string `json:"reference_number,omitempty"`
+		CustomerID      string `json:"customer_id,omitempty"`
 	}
 
 	CreateTransaction struct {
 		SourceAccountID      int    `json:"source_account_id"`
 		DestinationAccountID int    `json:"destination_account_id"`
 		Amount               string `json:"amount"`
+		ReferenceNumber      string `json:"reference_number,omitempty"`
 	}
 
-	Account struct {
+	// CreateTransactionBatch submits Transfers in one request. In atomic Mode
+	// they are booked in one DB transaction or not at all; in best_effort
+	// Mode each is booked on its own and reported separately.
+	CreateTransactionBatch struct {
+		Mode      string              `json:"mode"`
+		Transfers []CreateTransaction `json:"transfers"`
+	}
+
+	TransactionBatch struct {
+		Mode      string                 `json:"mode"`
+		Succeeded int                    `json:"succeeded"`
+		Failed    int                    `json:"failed"`
+		Items     []TransactionBatchItem `json:"items"`
+	}
+
+	// TransactionBatchItem is the outcome of the transfer at Index of the
+	// batch: its Transaction when completed, its Error when failed.
+	TransactionBatchItem struct {
+		Index       int             `json:"index"`
+		Status      string          `json:"status"`
+		Transaction *Transaction    `json:"transaction,omitempty"`
+		Error       *BatchItemError `json:"error,omitempty"`
+	}
+
+	BatchItemError struct {
+		ErrorCode string            `json:"error_code"`
+		Message   string            `json:"message"`
+		Data      map[string]string `json:"data,omitempty"`
+	}
+
+	CreateWithdrawal struct {
 		AccountID int    `json:"account_id"`
-		Balance   string `json:"balance"`
+		Amount    string `json:"amount"`
+	}
+
+	CreateDeposit struct {
+		Amount string `json:"amount"`
+	}
+
+	// CreateHold reserves Amount on AccountID for a later transfer to
+	// DestinationAccountID. ExpiresAt is an optional RFC 3339 timestamp; the
+	// service default applies when it is empty.
+	CreateHold struct {
+		AccountID            int    `json:"account_id"`
+		DestinationAccountID int    `json:"destination_account_id"`
+		Amount               string `json:"amount"`
+		Description          string `json:"description,omitempty"`
+		ExpiresAt            string `json:"expires_at,omitempty"`
+	}
+
+	// CaptureHold settles a hold. An empty Amount captures the whole hold, a
+	// smaller one captures part of it and releases the rest.
+	CaptureHold struct {
+		Amount string `json:"amount,omitempty"`
+	}
+
+	// CreateJournal posts Legs as one transaction. Each leg names either a
+	// customer AccountID or a SystemAccount code, booked in the currency of
+	// the journal's customer accounts.
+	CreateJournal struct {
+		Description     string       `json:"description,omitempty"`
+		ReferenceNumber string       `json:"reference_number,omitempty"`
+		Legs            []JournalLeg `json:"legs"`
+	}
+
+	JournalLeg struct {
+		AccountID     int    `json:"account_id,omitempty"`
+		SystemAccount string `json:"system_account,omitempty"`
+		EntryType     string `json:"entry_type"`
+		Amount        string `json:"amount"`
+		Description   string `json:"description,omitempty"`
+	}
+
+	Hold struct {
+		ID                   string        `json:"id"`
+		AccountID            int           `json:"account_id"`
+		DestinationAccountID int           `json:"destination_account_id"`
+		Amount               utils.Decimal `json:"amount"`
+		Status               string        `json:"status"`
+		TransactionID        string        `json:"transaction_id,omitempty"`
+		Description          string        `json:"description,omitempty"`
+		ExpiresAt            time.Time     `json:"expires_at"`
+		CreatedAt            time.Time     `json:"created_at"`
+	}
+
+	// CreateScheduledTransfer books a transfer at StartAt, an optional RFC
+	// 3339 timestamp defaulting to now, and with a daily, weekly or monthly
+	// Frequency repeats it until EndAt or until Count transfers have run.
+	// EndAt and Count are optional and not accepted for once.
+	CreateScheduledTransfer struct {
+		SourceAccountID      int    `json:"source_account_id"`
+		DestinationAccountID int    `json:"destination_account_id"`
+		Amount               string `json:"amount"`
+		Description          string `json:"description,omitempty"`
+		Frequency            string `json:"frequency"`
+		StartAt              string `json:"start_at,omitempty"`
+		EndAt                string `json:"end_at,omitempty"`
+		Count                int    `json:"count,omitempty"`
+	}
+
+	// UpdateScheduledTransfer changes an active schedule. Fields left out
+	// keep their value; an empty EndAt or a zero Count removes that bound.
+	UpdateScheduledTransfer struct {
+		Amount      string  `json:"amount,omitempty"`
+		Description *string `json:"description,omitempty"`
+		EndAt       *string `json:"end_at,omitempty"`
+		Count       *int    `json:"count,omitempty"`
+	}
+
+	// ListScheduledTransfers holds the query parameters of the schedule
+	// listing; AccountID selects the schedules paying from that account.
+	ListScheduledTransfers struct {
+		AccountID int
+		Status    string
+		Limit     int
+	}
+
+	// ScheduledTransfer reports a schedule. Occurrences counts the transfers
+	// run so far, failed ones included, and NextRunAt is set while the
+	// schedule is active. LastError explains the latest failed run.
+	ScheduledTransfer struct {
+		ID                   string        `json:"id"`
+		SourceAccountID      int           `json:"source_account_id"`
+		DestinationAccountID int           `json:"destination_account_id"`
+		Amount               utils.Decimal `json:"amount"`
+		Description          string        `json:"description,omitempty"`
+		Frequency            string        `json:"frequency"`
+		StartAt              time.Time     `json:"start_at"`
+		EndAt                *time.Time    `json:"end_at,omitempty"`
+		Count                int           `json:"count,omitempty"`
+		Occurrences          int           `json:"occurrences"`
+		NextRunAt            *time.Time    `json:"next_run_at,omitempty"`
+		Status               string        `json:"status"`
+		Attempts             int           `json:"attempts,omitempty"`
+		LastError            string        `json:"last_error,omitempty"`
+		LastTransactionID    string        `json:"last_transaction_id,omitempty"`
+		LastRunAt            *time.Time    `json:"last_run_at,omitempty"`
+		CreatedAt            time.Time     `json:"created_at"`
+	}
+
+	// CreateWebhook subscribes URL to EventTypes, "*" for all of them, on
+	// the accounts of CustomerID. Deliveries are signed with Secret,
+	// generated when left out.
+	CreateWebhook struct {
+		CustomerID string   `json:"customer_id"`
+		URL        string   `json:"url"`
+		Secret     string   `json:"secret,omitempty"`
+		EventTypes []string `json:"event_types"`
+	}
+
+	// UpdateWebhook changes a subscription. Fields left out keep their
+	// value; with Active false no further events are queued or delivered.
+	UpdateWebhook struct {
+		URL        *string  `json:"url,omitempty"`
+		EventTypes []string `json:"event_types,omitempty"`
+		Active     *bool    `json:"active,omitempty"`
+	}
+
+	// Webhook reports a subscription. Secret is only returned on creation.
+	Webhook struct {
+		ID         string    `json:"id"`
+		CustomerID string    `json:"customer_id"`
+		URL        string    `json:"url"`
+		Secret     string    `json:"secret,omitempty"`
+		EventTypes []string  `json:"event_types"`
+		Active     bool      `json:"active"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	}
+
+	// ListWebhookDeliveries holds the query parameters of the delivery
+	// listing.
+	ListWebhookDeliveries struct {
+		Status string
+		Limit  int
+	}
+
+	// WebhookDelivery reports one event sent to a subscription. Attempts
+	// counts the failed attempts since it was queued or redelivered and
+	// NextAttemptAt is set while it is pending. Event and AttemptLog are only
+	// returned for a single delivery.
+	WebhookDelivery struct {
+		ID             string           `json:"id"`
+		WebhookID      string           `json:"webhook_id"`
+		EventID        string           `json:"event_id"`
+		EventType      string           `json:"event_type"`
+		Status         string           `json:"status"`
+		Attempts       int              `json:"attempts"`
+		NextAttemptAt  *time.Time       `json:"next_attempt_at,omitempty"`
+		LastStatusCode int              `json:"last_status_code,omitempty"`
+		LastError      string           `json:"last_error,omitempty"`
+		DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
+		CreatedAt      time.Time        `json:"created_at"`
+		Event          json.RawMessage  `json:"event,omitempty"`
+		AttemptLog     []WebhookAttempt `json:"attempt_log,omitempty"`
+	}
+
+	// WebhookAttempt is one request of a delivery. StatusCode is left out
+	// when no response was received.
+	WebhookAttempt struct {
+		StatusCode int       `json:"status_code,omitempty"`
+		Error      string    `json:"error,omitempty"`
+		DurationMs int64     `json:"duration_ms"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	// CreateReversal refunds a transfer. An empty Amount reverses whatever
+	// has not been reversed yet.
+	CreateReversal struct {
+		Amount          string `json:"amount,omitempty"`
+		Reason          string `json:"reason,omitempty"`
+		ReferenceNumber string `json:"reference_number,omitempty"`
+	}
+
+	// Account reports the ledger balance and the part of it not reserved by
+	// active holds.
+	Account struct {
+		AccountID        int           `json:"account_id"`
+		CustomerID       string        `json:"customer_id,omitempty"`
+		Currency         string        `json:"currency"`
+		Status           string        `json:"status"`
+		Balance          utils.Decimal `json:"balance"`
+		AvailableBalance utils.Decimal `json:"available_balance"`
+	}
+
+	CreateCustomer struct {
+		Name string `json:"name"`
+	}
+
+	// Customer reports an account owner with the accounts it owns.
+	Customer struct {
+		ID         string    `json:"id"`
+		Name       string    `json:"name"`
+		AccountIDs []int     `json:"account_ids"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	// SetAccountOwner hands an account to CustomerID; empty leaves it
+	// without owner.
+	SetAccountOwner struct {
+		CustomerID string `json:"customer_id"`
+	}
+
+	// ChangeAccountStatus is the body of the freeze, unfreeze and close
+	// endpoints. SweepToAccountID is only accepted when closing; the
+	// remaining balance is then transferred there first. The actor is the
+	// authenticated caller.
+	ChangeAccountStatus struct {
+		Reason           string `json:"reason"`
+		SweepToAccountID int    `json:"sweep_to_account_id,omitempty"`
+	}
+
+	AccountStatusChange struct {
+		ID                 int64     `json:"id"`
+		AccountID          int       `json:"account_id"`
+		FromStatus         string    `json:"from_status"`
+		ToStatus           string    `json:"to_status"`
+		Actor              string    `json:"actor"`
+		Reason             string    `json:"reason"`
+		SweepTransactionID string    `json:"sweep_transaction_id,omitempty"`
+		CreatedAt          time.Time `json:"created_at"`
+	}
+
+	// Limits is a set of outgoing transfer limits. In effective limits an
+	// omitted rule is unlimited; in overrides it keeps the configured
+	// default and "0" lifts the rule for the account.
+	Limits struct {
+		MinAmount     *utils.Decimal `json:"min_amount,omitempty"`
+		MaxAmount     *utils.Decimal `json:"max_amount,omitempty"`
+		DailyAmount   *utils.Decimal `json:"daily_amount,omitempty"`
+		MonthlyAmount *utils.Decimal `json:"monthly_amount,omitempty"`
+		DailyCount    *int           `json:"daily_count,omitempty"`
+		MonthlyCount  *int           `json:"monthly_count,omitempty"`
+	}
+
+	// SetAccountLimits replaces all overrides of an account; rules left out
+	// fall back to the configured defaults.
+	SetAccountLimits struct {
+		Limits
+	}
+
+	AccountLimits struct {
+		AccountID int        `json:"account_id"`
+		Effective Limits     `json:"effective"`
+		Overrides Limits     `json:"overrides"`
+		UpdatedBy string     `json:"updated_by,omitempty"`
+		UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	}
+
+	// ListAccountTransactions holds the query parameters of the account
+	// history listing. StartDate and EndDate take RFC 3339 timestamps or
+	// plain dates (YYYY-MM-DD); a plain EndDate includes that whole day.
+	ListAccountTransactions struct {
+		Cursor    string
+		Limit     int
+		StartDate string
+		EndDate   string
+		Types     []string
+	}
+
+	AccountTransaction struct {
+		TransactionID         string        `json:"transaction_id"`
+		ReferenceNumber       string        `json:"reference_number"`
+		Type                  string        `json:"type"`
+		EntryType             string        `json:"entry_type"`
+		CounterpartyAccountID *int          `json:"counterparty_account_id,omitempty"`
+		Amount                utils.Decimal `json:"amount"`
+		BalanceBefore         utils.Decimal `json:"balance_before"`
+		BalanceAfter          utils.Decimal `json:"balance_after"`
+		Description           string        `json:"description,omitempty"`
+		CreatedAt             time.Time     `json:"created_at"`
+	}
+
+	AccountTransactions struct {
+		AccountID  int                  `json:"account_id"`
+		Items      []AccountTransaction `json:"items"`
+		NextCursor string               `json:"next_cursor,omitempty"`
+	}
+
+	Transaction struct {
+		ID                    string          `json:"id"`
+		ReferenceNumber       string          `json:"reference_number"`
+		Type                  string          `json:"type"`
+		Status                string          `json:"status"`
+		Amount                utils.Decimal   `json:"amount"`
+		SourceAccountID       *int            `json:"source_account_id,omitempty"`
+		DestinationAccountID  *int            `json:"destination_account_id,omitempty"`
+		OriginalTransactionID string          `json:"original_transaction_id,omitempty"`
+		Description           string          `json:"description,omitempty"`
+		Metadata              json.RawMessage `json:"metadata,omitempty"`
+		Fee                   *Fee            `json:"fee,omitempty"`
+		CreatedAt             time.Time       `json:"created_at"`
+		Entries               []LedgerEntry   `json:"entries,omitempty"`
+	}
+
+	// Fee is the breakdown of the fee charged to the payer on top of the
+	// amount. Variable is Percent of the amount; Capped tells whether the
+	// minimum or maximum fee applied instead of Flat plus Variable.
+	Fee struct {
+		Currency string        `json:"currency"`
+		Flat     utils.Decimal `json:"flat"`
+		Percent  utils.Decimal `json:"percent"`
+		Variable utils.Decimal `json:"variable"`
+		Capped   string        `json:"capped,omitempty"`
+		Amount   utils.Decimal `json:"amount"`
+	}
+
+	LedgerEntry struct {
+		ID            string        `json:"id"`
+		AccountID     int           `json:"account_id"`
+		EntryType     string        `json:"entry_type"`
+		Amount        utils.Decimal `json:"amount"`
+		BalanceBefore utils.Decimal `json:"balance_before"`
+		BalanceAfter  utils.Decimal `json:"balance_after"`
+		CreatedAt     time.Time     `json:"created_at"`
 	}
 )
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+)
+
+// statusTransitions lists the statuses an account may move to from its
+// current one. Closed is final.
+var statusTransitions = map[string][]string{
+	consts.AccountStatusActive: {consts.AccountStatusFrozen, consts.AccountStatusClosed},
+	consts.AccountStatusFrozen: {consts.AccountStatusActive, consts.AccountStatusClosed},
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *walletRepo) ChangeAccountStatus(ctx context.Context, payload StatusChangePayload) (AccountStatusChange, error) {
+	var change AccountStatusChange
+	err := r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+
+		// lockAccounts would refuse the frozen account, lock the rows
+		// directly in the same canonical order instead
+		ids := []int{payload.AccountID}
+		if payload.Sweep != nil {
+			ids = append(ids, payload.Sweep.To.AccountID)
+		}
+		sort.Ints(ids)
+
+		accounts := make(map[int]Account, len(ids))
+		for _, id := range ids {
+			acc, err := lockAccount(ctx, repo, id)
+			if err != nil {
+				return err
+			}
+			accounts[id] = acc
+		}
+
+		acc := accounts[payload.AccountID]
+		if !canTransition(acc.Status, payload.Status) {
+			return ErrStatusTransition
+		}
+
+		change = AccountStatusChange{
+			AccountID:  payload.AccountID,
+			FromStatus: acc.Status,
+			ToStatus:   payload.Status,
+			Actor:      payload.Actor,
+			Reason:     payload.Reason,
+			CreatedAt:  payload.CreatedAt,
+		}
+
+		if payload.Status == consts.AccountStatusClosed {
+			if !acc.HeldAmount.IsZero() {
+				return ErrActiveHolds
+			}
+
+			if payload.Sweep != nil && acc.Balance.IsPositive() {
+				if err := checkAccountActive(accounts[payload.Sweep.To.AccountID]); err != nil {
+					return err
+				}
+
+				if err := checkSameCurrency(accounts); err != nil {
+					return err
+				}
+
+				sweep := *payload.Sweep
+				sweep.Amount = acc.Balance
+				sweep.Transaction.Amount = acc.Balance
+				sweep.LedgerEntryFrom.Amount = acc.Balance
+				sweep.LedgerEntryTo.Amount = acc.Balance
+				if err := bookTransfer(ctx, repo, sweep); err != nil {
+					return err
+				}
+
+				change.SweepTransactionID.String = sweep.Transaction.ID
+				change.SweepTransactionID.Valid = true
+			} else if !acc.Balance.IsZero() {
+				return ErrBalanceNotZero
+			}
+		}
+
+		_, err := repo.Exec(ctx, "UPDATE accounts SET status = $1, updated_at = $2 WHERE account_id = $3",
+			payload.Status, time.Now(), payload.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to update accounts data: %w", err)
+		}
+
+		err = repo.QueryRow(ctx, `INSERT INTO account_status_changes (account_id,
+							from_status,
+							to_status,
+							actor,
+							reason,
+							sweep_transaction_id,
+							created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			change.AccountID,
+			change.FromStatus,
+			change.ToStatus,
+			change.Actor,
+			change.Reason,
+			change.SweepTransactionID,
+			change.CreatedAt,
+		).Scan(&change.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert account_status_changes data: %w", err)
+		}
+
+		return insertEvent(ctx, repo, consts.EventAccountStatusChanged, statusChangeEvent{
+			AccountID:          change.AccountID,
+			FromStatus:         change.FromStatus,
+			ToStatus:           change.ToStatus,
+			Actor:              change.Actor,
+			Reason:             change.Reason,
+			SweepTransactionID: change.SweepTransactionID.String,
+			CreatedAt:          change.CreatedAt,
+		}, change.AccountID)
+	})
+
+	return change, err
+}
+
+func (r *walletRepo) ListAccountStatusChanges(ctx context.Context, accountID int) ([]AccountStatusChange, error) {
+	rows, err := r.db.Query(ctx, `SELECT id,
+				account_id,
+				from_status,
+				to_status,
+				actor,
+				reason,
+				sweep_transaction_id,
+				created_at
+		FROM account_status_changes WHERE account_id = $1
+		ORDER BY created_at DESC, id DESC`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account_status_changes data: %w", err)
+	}
+	defer rows.Close()
+
+	result := []AccountStatusChange{}
+	for rows.Next() {
+		var c AccountStatusChange
+		err := rows.Scan(
+			&c.ID,
+			&c.AccountID,
+			&c.FromStatus,
+			&c.ToStatus,
+			&c.Actor,
+			&c.Reason,
+			&c.SweepTransactionID,
+			&c.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account_status_changes data: %w", err)
+		}
+		result = append(result, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read account_status_changes data: %w", err)
+	}
+
+	return result, nil
+}
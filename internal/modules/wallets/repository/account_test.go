@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+func newTestStatusChange(accountID int, status string) StatusChangePayload {
+	return StatusChangePayload{
+		AccountID: accountID,
+		Status:    status,
+		Actor:     "test",
+		Reason:    "test",
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestAccountStatusLifecycle(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	acc := createTestAccount(t, repo, utils.NewDecimal(10))
+	other := createTestAccount(t, repo, utils.NewDecimal(10))
+
+	_, err := repo.ChangeAccountStatus(ctx, newTestStatusChange(acc, consts.AccountStatusFrozen))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, repo.SubmitTransaction(ctx, newTestTransfer(acc, other, utils.NewDecimal(1))), ErrAccountFrozen)
+	assert.ErrorIs(t, repo.SubmitTransaction(ctx, newTestTransfer(other, acc, utils.NewDecimal(1))), ErrAccountFrozen)
+
+	_, err = repo.ChangeAccountStatus(ctx, newTestStatusChange(acc, consts.AccountStatusFrozen))
+	assert.ErrorIs(t, err, ErrStatusTransition)
+
+	_, err = repo.ChangeAccountStatus(ctx, newTestStatusChange(acc, consts.AccountStatusClosed))
+	assert.ErrorIs(t, err, ErrBalanceNotZero)
+
+	closing := newTestStatusChange(acc, consts.AccountStatusClosed)
+	sweep := newTestTransfer(acc, other, utils.Decimal{})
+	closing.Sweep = &sweep
+	change, err := repo.ChangeAccountStatus(ctx, closing)
+	require.NoError(t, err)
+	assert.Equal(t, consts.AccountStatusFrozen, change.FromStatus)
+	assert.Equal(t, sweep.Transaction.ID, change.SweepTransactionID.String)
+
+	closed, err := repo.GetAccount(ctx, acc)
+	require.NoError(t, err)
+	assert.Equal(t, consts.AccountStatusClosed, closed.Status)
+	assert.True(t, closed.Balance.IsZero())
+
+	receiver, err := repo.GetAccount(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, "20", receiver.Balance.String())
+
+	_, err = repo.ChangeAccountStatus(ctx, newTestStatusChange(acc, consts.AccountStatusActive))
+	assert.ErrorIs(t, err, ErrStatusTransition)
+
+	changes, err := repo.ListAccountStatusChanges(ctx, acc)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, consts.AccountStatusClosed, changes[0].ToStatus)
+	assert.Equal(t, consts.AccountStatusFrozen, changes[1].ToStatus)
+}
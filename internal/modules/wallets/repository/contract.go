@@ -4,24 +4,122 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
 )
 
 type WalletRepository interface {
 	CreateAccount(ctx context.Context, payload DepositPayload) error
 	GetAccount(ctx context.Context, accountID int) (Account, error)
 	SubmitTransaction(ctx context.Context, payload TransactionPayload) error
+	SubmitTransactions(ctx context.Context, payloads []TransactionPayload) error
+	Withdraw(ctx context.Context, payload WithdrawPayload) error
+	Deposit(ctx context.Context, payload TopUpPayload) error
+	GetTransaction(ctx context.Context, id string) (Transaction, error)
+	GetTransactionByReference(ctx context.Context, referenceNumber string) (Transaction, error)
+	GetTransactionLedgerEntries(ctx context.Context, transactionID string) ([]LedgerEntry, error)
+	ListLedgerEntries(ctx context.Context, filter LedgerFilter) ([]HistoryEntry, error)
+	GetReversedAmount(ctx context.Context, transactionID string) (utils.Decimal, error)
+	ReverseTransaction(ctx context.Context, payload ReversalPayload) error
+	CreateHold(ctx context.Context, hold Hold) error
+	GetHold(ctx context.Context, id string) (Hold, error)
+	CaptureHold(ctx context.Context, payload CapturePayload) error
+	VoidHold(ctx context.Context, id string) error
+	ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error)
+	ChangeAccountStatus(ctx context.Context, payload StatusChangePayload) (AccountStatusChange, error)
+	ListAccountStatusChanges(ctx context.Context, accountID int) ([]AccountStatusChange, error)
+	GetLimitOverrides(ctx context.Context, accountID int) (LimitOverrides, error)
+	SetLimitOverrides(ctx context.Context, overrides LimitOverrides) error
+	PostJournal(ctx context.Context, payload JournalPayload) ([]LedgerEntry, error)
+	CreateScheduledTransfer(ctx context.Context, st ScheduledTransfer) error
+	GetScheduledTransfer(ctx context.Context, id string) (ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, filter ScheduledTransferFilter) ([]ScheduledTransfer, error)
+	UpdateScheduledTransfer(ctx context.Context, update ScheduledTransferUpdate) (ScheduledTransfer, error)
+	CancelScheduledTransfer(ctx context.Context, id string) (ScheduledTransfer, error)
+	ClaimScheduledTransfers(ctx context.Context, now, lockedUntil time.Time, limit int) ([]ScheduledTransfer, error)
+	RecordScheduledRun(ctx context.Context, run ScheduledRun) (bool, error)
+	RelayOutbox(ctx context.Context, limit int, lease time.Duration, backoff OutboxBackoff, publish OutboxPublishFunc) (int, error)
+	CreateWebhookSubscription(ctx context.Context, sub WebhookSubscription) error
+	GetWebhookSubscription(ctx context.Context, id string) (WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, sub WebhookSubscription) (WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id string) (bool, error)
+	GetWebhookDelivery(ctx context.Context, id string) (WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	ListWebhookAttempts(ctx context.Context, deliveryID string) ([]WebhookAttempt, error)
+	ClaimWebhookDeliveries(ctx context.Context, now, lockedUntil time.Time, limit int) ([]WebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, result WebhookAttemptResult) error
+	RedeliverWebhook(ctx context.Context, id string) (WebhookDelivery, error)
+	CreateCustomer(ctx context.Context, customer Customer) error
+	GetCustomer(ctx context.Context, id string) (Customer, error)
+	ListCustomerAccounts(ctx context.Context, customerID string) ([]int, error)
+	SetAccountCustomer(ctx context.Context, accountID int, customerID string) (bool, error)
+}
+
+// OutboxPublishFunc delivers an outbox event, a nil error marks it
+// published.
+type OutboxPublishFunc func(ctx context.Context, event OutboxEvent) error
+
+// OutboxBackoff returns how long an event waits before its next attempt
+// after failing attempts times.
+type OutboxBackoff func(attempts int) time.Duration
+
+var (
+	ErrDataNotFound        = errors.New("data not found")
+	ErrInsufficientBalance = errors.New("sender balance less than amount")
+	ErrDuplicateReference  = errors.New("reference_number already used")
+	ErrNotReversible       = errors.New("transaction cannot be reversed")
+	ErrReversalExceeded    = errors.New("reversal amount exceeds remaining amount")
+	ErrHoldNotActive       = errors.New("hold is not active")
+	ErrCaptureExceeded     = errors.New("capture amount exceeds held amount")
+	ErrAccountFrozen       = errors.New("account is frozen")
+	ErrAccountClosed       = errors.New("account is closed")
+	ErrStatusTransition    = errors.New("account status change not allowed")
+	ErrBalanceNotZero      = errors.New("account balance is not zero")
+	ErrActiveHolds         = errors.New("account has active holds")
+	ErrCurrencyMismatch    = errors.New("accounts have different currencies")
+	ErrUnbalancedEntries   = errors.New("ledger entries do not balance")
+	ErrScheduleNotActive   = errors.New("scheduled transfer is not active")
+)
+
+// BatchItemError reports the payload of a batch that failed. Nothing of the
+// batch was booked.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
 }
 
 type (
 	Account struct {
-		ID        int       `json:"id"`
-		AccountID int       `json:"account_id"`
-		Currency  string    `json:"currency"`
-		Status    string    `json:"status"`
-		Balance   float64   `json:"balance"`
+		ID        int           `json:"id"`
+		AccountID int           `json:"account_id"`
+		Currency  string        `json:"currency"`
+		Status    string        `json:"status"`
+		Balance   utils.Decimal `json:"balance"`
+		// HeldAmount is reserved by active holds and not available for debits.
+		HeldAmount utils.Decimal `json:"held_amount"`
+		// CustomerID is the owner of the account, empty when it has none.
+		CustomerID string    `json:"customer_id,omitempty"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	}
+
+	// Customer owns accounts. Its ID is the subject of its bearer tokens.
+	Customer struct {
+		ID        string    `json:"id"`
+		Name      string    `json:"name"`
 		CreatedAt time.Time `json:"created_at"`
 		UpdatedAt time.Time `json:"updated_at"`
 	}
@@ -33,36 +131,373 @@ type (
 		Description     string                 `json:"description,omitempty"`
 		FromAccountID   sql.NullInt64          `json:"from_account_id,omitempty"`
 		ToAccountID     sql.NullInt64          `json:"to_account_id,omitempty"`
-		Amount          float64                `json:"amount"`
+		Amount          utils.Decimal          `json:"amount"`
 		Metadata        json.RawMessage        `json:"metadata,omitempty"`
 		Status          string                 `json:"status"`
 		CreatedAt       time.Time              `json:"created_at"`
+
+		// RequestFingerprint identifies the request that produced the
+		// transaction when ReferenceNumber is a client idempotency key.
+		RequestFingerprint string `json:"-"`
+
+		// OriginalTransactionID links a reversal to the transfer it undoes.
+		OriginalTransactionID sql.NullString `json:"original_transaction_id,omitempty"`
 	}
 
 	LedgerEntry struct {
 		ID            string           `json:"id"`
 		TransactionID string           `json:"transaction_id"`
 		AccountID     int              `json:"account_id"`
-		Amount        float64          `json:"amount"`
+		Amount        utils.Decimal    `json:"amount"`
 		EntryType     consts.EntryType `json:"entry_type"`
-		BalanceBefore float64          `json:"balance_before,omitempty"`
-		BalanceAfter  float64          `json:"balance_after,omitempty"`
+		BalanceBefore utils.Decimal    `json:"balance_before,omitempty"`
+		BalanceAfter  utils.Decimal    `json:"balance_after,omitempty"`
 		Description   string           `json:"description,omitempty"`
 		CreatedAt     time.Time        `json:"created_at"`
 	}
 
+	// HistoryEntry is a ledger entry joined with the transaction it belongs
+	// to, as seen from the ledger entry's account.
+	HistoryEntry struct {
+		LedgerEntry
+		TransactionType       consts.TransactionType
+		ReferenceNumber       string
+		CounterpartyAccountID sql.NullInt64
+	}
+
+	// LedgerFilter selects ledger entries of one account, newest first.
+	// Zero values disable the corresponding filter.
+	LedgerFilter struct {
+		AccountID int
+		Types     []consts.TransactionType
+		// From is inclusive, To is exclusive
+		From time.Time
+		To   time.Time
+		// After continues a listing right after the given entry
+		After *LedgerCursor
+		Limit int
+	}
+
+	LedgerCursor struct {
+		CreatedAt time.Time
+		ID        string
+	}
+
+	// DepositPayload opens Account with its balance funded by the funding
+	// system account of its currency. LedgerEntryFunding debits that
+	// account; its account id and balances are filled in by the repository.
 	DepositPayload struct {
-		Account     Account
-		Transaction Transaction
-		LedgerEntry LedgerEntry
+		Account            Account
+		Transaction        Transaction
+		LedgerEntry        LedgerEntry
+		LedgerEntryFunding LedgerEntry
+	}
+
+	// WithdrawPayload debits Amount from AccountID and credits it to the
+	// funding system account of its currency. The funding account id and
+	// the ledger entry balances are filled in by the repository from the
+	// locked account rows.
+	WithdrawPayload struct {
+		AccountID          int
+		Amount             utils.Decimal
+		Transaction        Transaction
+		LedgerEntry        LedgerEntry
+		LedgerEntryFunding LedgerEntry
+		Fee                *FeeTransfer
+	}
+
+	// TopUpPayload credits Amount to the existing AccountID and debits it
+	// from the funding system account of its currency. The funding account
+	// id and the ledger entry balances are filled in by the repository from
+	// the locked account rows.
+	TopUpPayload struct {
+		AccountID          int
+		Amount             utils.Decimal
+		Transaction        Transaction
+		LedgerEntry        LedgerEntry
+		LedgerEntryFunding LedgerEntry
 	}
 
+	// TransactionPayload moves Amount from From to To. Only the account IDs
+	// are read; balances are locked and re-read inside the DB transaction and
+	// the ledger entry balances are filled in from those values.
+	//
+	// With FX set From and To hold different currencies: From pays Amount
+	// and To receives FX.DestinationAmount. With Fee set From pays the fee on
+	// top of Amount.
 	TransactionPayload struct {
 		From            Account
 		To              Account
-		Amount          float64
+		Amount          utils.Decimal
 		Transaction     Transaction
 		LedgerEntryFrom LedgerEntry
 		LedgerEntryTo   LedgerEntry
+		FX              *FXTransfer
+		Fee             *FeeTransfer
+	}
+
+	// FeeTransfer charges Amount to the payer of a movement as a separate
+	// debit leg of the same transaction and credits it to the fees system
+	// account of Currency. The revenue account id and all balances are
+	// filled in by the repository.
+	FeeTransfer struct {
+		Currency           string
+		Amount             utils.Decimal
+		LedgerEntryPayer   LedgerEntry
+		LedgerEntryRevenue LedgerEntry
+	}
+
+	// FXTransfer routes a cross-currency transfer through the system FX
+	// account of each currency so both ledgers stay balanced: the source
+	// currency's FX account is credited the amount paid and the destination
+	// currency's FX account debited the amount received. The FX account ids
+	// and all balances are filled in by the repository.
+	FXTransfer struct {
+		SourceCurrency         string
+		DestinationCurrency    string
+		DestinationAmount      utils.Decimal
+		LedgerEntrySource      LedgerEntry
+		LedgerEntryDestination LedgerEntry
+	}
+
+	// JournalPayload books Legs as one transaction in Currency. Every
+	// account of the journal must hold Currency and every account the legs
+	// debit on balance must have that net debit available. The system
+	// account ids and the ledger entry balances are filled in by the
+	// repository.
+	JournalPayload struct {
+		Currency    string
+		Transaction Transaction
+		Legs        []JournalLeg
+	}
+
+	// JournalLeg is one ledger entry of a journal. With SystemCode set it
+	// posts to that system account of the journal's currency instead of
+	// AccountID.
+	JournalLeg struct {
+		LedgerEntry
+		SystemCode string
+	}
+
+	// StatusChangePayload moves AccountID to Status and records the change in
+	// the audit trail. Closing requires a zero balance and no active holds;
+	// with Sweep set a positive balance is first transferred to
+	// Sweep.To, with the amounts filled in from the locked account row.
+	StatusChangePayload struct {
+		AccountID int
+		Status    string
+		Actor     string
+		Reason    string
+		CreatedAt time.Time
+		Sweep     *TransactionPayload
+	}
+
+	AccountStatusChange struct {
+		ID                 int64          `json:"id"`
+		AccountID          int            `json:"account_id"`
+		FromStatus         string         `json:"from_status"`
+		ToStatus           string         `json:"to_status"`
+		Actor              string         `json:"actor"`
+		Reason             string         `json:"reason"`
+		SweepTransactionID sql.NullString `json:"sweep_transaction_id,omitempty"`
+		CreatedAt          time.Time      `json:"created_at"`
+	}
+
+	// Hold reserves Amount on AccountID for a later transfer to
+	// DestinationAccountID. While active it counts towards the account's
+	// HeldAmount.
+	Hold struct {
+		ID                   string         `json:"id"`
+		AccountID            int            `json:"account_id"`
+		DestinationAccountID int            `json:"destination_account_id"`
+		Amount               utils.Decimal  `json:"amount"`
+		Status               string         `json:"status"`
+		TransactionID        sql.NullString `json:"transaction_id,omitempty"`
+		Description          string         `json:"description,omitempty"`
+		ExpiresAt            time.Time      `json:"expires_at"`
+		CreatedAt            time.Time      `json:"created_at"`
+		UpdatedAt            time.Time      `json:"updated_at"`
+	}
+
+	// ScheduledTransfer books a transfer of Amount from SourceAccountID to
+	// DestinationAccountID at StartAt and, unless Frequency is once, again
+	// every day, week or month after it. It stops after MaxOccurrences
+	// occurrences or at EndAt, whichever comes first. Occurrences counts the
+	// occurrences run so far and NextRunAt is when the next one is due, or
+	// its retry after Attempts failed runs. Every run acts on behalf of the
+	// principal CreatedBy with CreatedByRole, or of the system when unset.
+	ScheduledTransfer struct {
+		ID                   string         `json:"id"`
+		SourceAccountID      int            `json:"source_account_id"`
+		DestinationAccountID int            `json:"destination_account_id"`
+		Amount               utils.Decimal  `json:"amount"`
+		Description          string         `json:"description,omitempty"`
+		Frequency            string         `json:"frequency"`
+		StartAt              time.Time      `json:"start_at"`
+		EndAt                sql.NullTime   `json:"end_at,omitempty"`
+		MaxOccurrences       sql.NullInt64  `json:"max_occurrences,omitempty"`
+		Occurrences          int            `json:"occurrences"`
+		NextRunAt            time.Time      `json:"next_run_at"`
+		Status               string         `json:"status"`
+		Attempts             int            `json:"attempts"`
+		LastError            string         `json:"last_error,omitempty"`
+		LastTransactionID    sql.NullString `json:"last_transaction_id,omitempty"`
+		LastRunAt            sql.NullTime   `json:"last_run_at,omitempty"`
+		CreatedBy            string         `json:"created_by,omitempty"`
+		CreatedByRole        string         `json:"created_by_role,omitempty"`
+		CreatedAt            time.Time      `json:"created_at"`
+		UpdatedAt            time.Time      `json:"updated_at"`
+	}
+
+	// ScheduledTransferFilter selects schedules, newest first. Zero values
+	// disable the corresponding filter.
+	ScheduledTransferFilter struct {
+		AccountID int
+		Status    string
+		Limit     int
+	}
+
+	// ScheduledTransferUpdate replaces the editable fields of schedule ID.
+	ScheduledTransferUpdate struct {
+		ID             string
+		Amount         utils.Decimal
+		Description    string
+		EndAt          sql.NullTime
+		MaxOccurrences sql.NullInt64
+	}
+
+	// ScheduledRun is the outcome of running occurrence Occurrence of
+	// schedule ID. With Advance set the schedule moves on to its next
+	// occurrence, due at NextRunAt; otherwise the same occurrence is retried
+	// at NextRunAt. Status applies unless the schedule was cancelled while
+	// it ran. TransactionID is the booked transfer and Error the reason a
+	// run failed.
+	ScheduledRun struct {
+		ID            string
+		Occurrence    int
+		Advance       bool
+		NextRunAt     time.Time
+		Status        string
+		Attempts      int
+		TransactionID string
+		Error         string
+		RunAt         time.Time
+	}
+
+	// OutboxEvent is a domain event written alongside the change it
+	// describes. ID orders the events, EventID identifies it to consumers
+	// and stays the same across redeliveries. AccountIDs are the customer
+	// accounts it concerns.
+	OutboxEvent struct {
+		ID            int64           `json:"-"`
+		EventID       string          `json:"id"`
+		Type          string          `json:"type"`
+		AccountIDs    []int64         `json:"account_ids"`
+		Data          json.RawMessage `json:"data"`
+		CreatedAt     time.Time       `json:"created_at"`
+		Attempts      int             `json:"-"`
+		NextAttemptAt sql.NullTime    `json:"-"`
+	}
+
+	// WebhookSubscription receives every event of EventTypes, or of any type
+	// when they include consts.WebhookEventAll, on the accounts CustomerID
+	// owns while Active. Deliveries are signed with Secret.
+	WebhookSubscription struct {
+		ID         string    `json:"id"`
+		CustomerID string    `json:"customer_id"`
+		URL        string    `json:"url"`
+		Secret     string    `json:"-"`
+		EventTypes []string  `json:"event_types"`
+		Active     bool      `json:"active"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	}
+
+	// WebhookDelivery is one event due to one subscription. Payload is the
+	// request body, the event as the outbox relay publishes it. Attempts
+	// counts the failed attempts since the delivery was created or last
+	// redelivered.
+	WebhookDelivery struct {
+		ID             string          `json:"id"`
+		SubscriptionID string          `json:"subscription_id"`
+		EventID        string          `json:"event_id"`
+		EventType      string          `json:"event_type"`
+		Payload        json.RawMessage `json:"payload"`
+		Status         string          `json:"status"`
+		Attempts       int             `json:"attempts"`
+		NextAttemptAt  time.Time       `json:"next_attempt_at"`
+		LastStatusCode sql.NullInt64   `json:"last_status_code,omitempty"`
+		LastError      string          `json:"last_error,omitempty"`
+		DeliveredAt    sql.NullTime    `json:"delivered_at,omitempty"`
+		CreatedAt      time.Time       `json:"created_at"`
+		UpdatedAt      time.Time       `json:"updated_at"`
+	}
+
+	// WebhookDeliveryFilter selects the deliveries of a subscription, newest
+	// first. An empty Status selects all.
+	WebhookDeliveryFilter struct {
+		SubscriptionID string
+		Status         string
+		Limit          int
+	}
+
+	// WebhookAttempt logs one request of a delivery. StatusCode is zero
+	// when no response was received.
+	WebhookAttempt struct {
+		ID         int64     `json:"id"`
+		DeliveryID string    `json:"delivery_id"`
+		StatusCode int       `json:"status_code,omitempty"`
+		Error      string    `json:"error,omitempty"`
+		Duration   int64     `json:"duration_ms"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	// WebhookAttemptResult logs Attempt and moves delivery Attempt.DeliveryID
+	// to Status, with Attempts failed attempts and the next one at
+	// NextAttemptAt, and releases its claim.
+	WebhookAttemptResult struct {
+		Attempt       WebhookAttempt
+		Status        string
+		Attempts      int
+		NextAttemptAt time.Time
+	}
+
+	// CapturePayload turns an active hold into a transfer. The whole hold is
+	// released and Transfer, which may be for less than the held amount, is
+	// booked the same way SubmitTransaction books it.
+	CapturePayload struct {
+		HoldID   string
+		Transfer TransactionPayload
+	}
+
+	// ReversalPayload moves Amount back from the original transfer's
+	// destination to its source. Transaction.OriginalTransactionID names the
+	// transfer; it is locked and its remaining amount re-checked inside the DB
+	// transaction. LedgerEntryFrom debits the original destination and
+	// LedgerEntryTo credits the original source. Unless AllowNegativeBalance
+	// is set the destination must still hold Amount.
+	ReversalPayload struct {
+		From                 Account
+		To                   Account
+		Amount               utils.Decimal
+		Transaction          Transaction
+		LedgerEntryFrom      LedgerEntry
+		LedgerEntryTo        LedgerEntry
+		FeeRefund            *FeeRefund
+		AllowNegativeBalance bool
+	}
+
+	// FeeRefund gives the payer of a transfer back the share of its fee
+	// matching the amount reversed, debited from the fees system account of
+	// Currency. Fee is the whole fee charged on the transfer and Scale the
+	// currency's minor units. The refunded Amount, the fees account id and
+	// all balances are filled in by the repository.
+	FeeRefund struct {
+		Currency           string
+		Fee                utils.Decimal
+		Scale              int
+		Amount             utils.Decimal
+		LedgerEntryRevenue LedgerEntry
+		LedgerEntryPayer   LedgerEntry
 	}
 )
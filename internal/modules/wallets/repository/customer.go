@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+func (r *walletRepo) CreateCustomer(ctx context.Context, customer Customer) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO customers (id, name, created_at, updated_at) VALUES ($1, $2, $3, $3)`,
+		customer.ID, customer.Name, customer.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert customers data: %w", err)
+	}
+
+	return nil
+}
+
+// GetCustomer returns customer id, or the zero value when it does not exist.
+func (r *walletRepo) GetCustomer(ctx context.Context, id string) (Customer, error) {
+	var result Customer
+	err := r.db.QueryRow(ctx, `SELECT id, name, created_at, updated_at FROM customers WHERE id = $1`, id).
+		Scan(&result.ID, &result.Name, &result.CreatedAt, &result.UpdatedAt)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return Customer{}, nil
+	}
+
+	if err != nil {
+		return Customer{}, fmt.Errorf("failed to query customers data: %w", err)
+	}
+
+	return result, nil
+}
+
+// ListCustomerAccounts returns the account_ids owned by a customer in
+// ascending order.
+func (r *walletRepo) ListCustomerAccounts(ctx context.Context, customerID string) ([]int, error) {
+	rows, err := r.db.Query(ctx, `SELECT account_id FROM accounts WHERE customer_id = $1 ORDER BY account_id`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts data: %w", err)
+	}
+	defer rows.Close()
+
+	var result []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to query accounts data: %w", err)
+		}
+		result = append(result, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read accounts data: %w", err)
+	}
+
+	return result, nil
+}
+
+// SetAccountCustomer makes customerID the owner of an account, or leaves it
+// without owner when customerID is empty, and reports whether the account
+// exists. System accounts cannot be owned.
+func (r *walletRepo) SetAccountCustomer(ctx context.Context, accountID int, customerID string) (bool, error) {
+	res, err := r.db.Exec(ctx, `UPDATE accounts SET customer_id = NULLIF($2, '')::uuid, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $1 AND account_id > 0`,
+		accountID, customerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update accounts data: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update accounts data: %w", err)
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+)
+
+const holdColumns = `id,
+					account_id,
+					destination_account_id,
+					amount,
+					status,
+					transaction_id,
+					COALESCE(description, ''),
+					expires_at,
+					created_at,
+					updated_at`
+
+func scanHold(row *sql.Row) (Hold, error) {
+	var result Hold
+	err := row.Scan(
+		&result.ID,
+		&result.AccountID,
+		&result.DestinationAccountID,
+		&result.Amount,
+		&result.Status,
+		&result.TransactionID,
+		&result.Description,
+		&result.ExpiresAt,
+		&result.CreatedAt,
+		&result.UpdatedAt,
+	)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return Hold{}, nil
+	}
+
+	if err != nil {
+		return Hold{}, fmt.Errorf("failed to query holds data: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *walletRepo) GetHold(ctx context.Context, id string) (Hold, error) {
+	return scanHold(r.db.QueryRow(ctx, `SELECT `+holdColumns+` FROM holds WHERE id = $1`, id))
+}
+
+func (r *walletRepo) CreateHold(ctx context.Context, hold Hold) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+
+		accounts, err := lockAccounts(ctx, repo, hold.AccountID, hold.DestinationAccountID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkSameCurrency(accounts); err != nil {
+			return err
+		}
+
+		if availableBalance(accounts[hold.AccountID]).Cmp(hold.Amount) < 0 {
+			return ErrInsufficientBalance
+		}
+
+		if err := r.checkOutgoingLimits(ctx, repo, hold.AccountID, hold.Amount, utils.Decimal{}); err != nil {
+			return err
+		}
+
+		_, err = repo.Exec(ctx, `INSERT INTO holds (id,
+							account_id,
+							destination_account_id,
+							amount,
+							status,
+							description,
+							expires_at,
+							created_at,
+							updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)`,
+			hold.ID,
+			hold.AccountID,
+			hold.DestinationAccountID,
+			hold.Amount,
+			hold.Status,
+			hold.Description,
+			hold.ExpiresAt,
+			hold.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert holds data: %w", err)
+		}
+
+		return adjustHeldAmount(ctx, repo, hold.AccountID, hold.Amount)
+	})
+}
+
+func (r *walletRepo) CaptureHold(ctx context.Context, payload CapturePayload) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+
+		hold, err := lockActiveHold(ctx, repo, payload.HoldID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if payload.Transfer.Amount.Cmp(hold.Amount) > 0 {
+			return ErrCaptureExceeded
+		}
+
+		// take the account locks in canonical order before touching
+		// held_amount, transfer re-locks them at no cost
+		if _, err := lockAccounts(ctx, repo, hold.AccountID, hold.DestinationAccountID); err != nil {
+			return err
+		}
+
+		if err := adjustHeldAmount(ctx, repo, hold.AccountID, hold.Amount.Neg()); err != nil {
+			return err
+		}
+
+		if err := r.transfer(ctx, repo, payload.Transfer); err != nil {
+			return err
+		}
+
+		return setHoldStatus(ctx, repo, consts.HoldStatusCaptured, payload.Transfer.Transaction.ID, hold.ID)
+	})
+}
+
+func (r *walletRepo) VoidHold(ctx context.Context, id string) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+
+		hold, err := lockActiveHold(ctx, repo, id, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if err := adjustHeldAmount(ctx, repo, hold.AccountID, hold.Amount.Neg()); err != nil {
+			return err
+		}
+
+		return setHoldStatus(ctx, repo, consts.HoldStatusVoided, "", hold.ID)
+	})
+}
+
+// ExpireHolds releases up to limit active holds whose expiry is at or before
+// now and reports how many it released. Holds locked by a concurrent capture
+// or void are skipped and picked up by a later run if still active.
+func (r *walletRepo) ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error) {
+	var expired int
+	err := r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+		expired = 0
+
+		// ordered by account_id so the account rows below are locked in
+		// canonical order
+		rows, err := repo.Query(ctx, `SELECT id, account_id, amount FROM holds
+			WHERE status = $1 AND expires_at <= $2
+			ORDER BY account_id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED`, consts.HoldStatusActive, now, limit)
+		if err != nil {
+			return fmt.Errorf("failed to query holds data: %w", err)
+		}
+		defer rows.Close()
+
+		var (
+			ids      []string
+			held     = map[int]utils.Decimal{}
+			accounts []int
+		)
+		for rows.Next() {
+			var (
+				id        string
+				accountID int
+				amount    utils.Decimal
+			)
+			if err := rows.Scan(&id, &accountID, &amount); err != nil {
+				return fmt.Errorf("failed to scan holds data: %w", err)
+			}
+
+			if _, ok := held[accountID]; !ok {
+				accounts = append(accounts, accountID)
+			}
+			ids = append(ids, id)
+			sum, err := held[accountID].CheckedAdd(amount)
+			if err != nil {
+				return err
+			}
+			held[accountID] = sum
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read holds data: %w", err)
+		}
+		rows.Close()
+
+		for _, accountID := range accounts {
+			if err := adjustHeldAmount(ctx, repo, accountID, held[accountID].Neg()); err != nil {
+				return err
+			}
+		}
+
+		if len(ids) > 0 {
+			_, err = repo.Exec(ctx, `UPDATE holds SET status = $1, updated_at = $2 WHERE id = ANY($3)`,
+				consts.HoldStatusExpired, time.Now(), pq.Array(ids))
+			if err != nil {
+				return fmt.Errorf("failed to update holds data: %w", err)
+			}
+		}
+
+		expired = len(ids)
+		return nil
+	})
+
+	return expired, err
+}
+
+// lockActiveHold locks the hold row for the rest of the surrounding
+// transaction. A hold that does not exist, is no longer active or has passed
+// its expiry yields ErrHoldNotActive.
+func lockActiveHold(ctx context.Context, repo *db.Repository, id string, now time.Time) (Hold, error) {
+	hold, err := scanHold(repo.QueryRow(ctx, `SELECT `+holdColumns+` FROM holds WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return Hold{}, err
+	}
+
+	if hold.ID == "" || hold.Status != consts.HoldStatusActive || !hold.ExpiresAt.After(now) {
+		return Hold{}, ErrHoldNotActive
+	}
+
+	return hold, nil
+}
+
+// adjustHeldAmount adds delta to the account's held_amount. The UPDATE locks
+// the row; callers touching several accounts must go in account_id order.
+func adjustHeldAmount(ctx context.Context, repo *db.Repository, accountID int, delta utils.Decimal) error {
+	_, err := repo.Exec(ctx, `UPDATE accounts SET held_amount = held_amount + $1, updated_at = $2
+		WHERE account_id = $3`, delta, time.Now(), accountID)
+	if err != nil {
+		return fmt.Errorf("failed to update accounts data: %w", err)
+	}
+
+	return nil
+}
+
+func setHoldStatus(ctx context.Context, repo *db.Repository, status, transactionID, id string) error {
+	_, err := repo.Exec(ctx, `UPDATE holds SET status = $1, transaction_id = NULLIF($2, '')::uuid, updated_at = $3
+		WHERE id = $4`, status, transactionID, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update holds data: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+func newTestHold(from, to int, amount utils.Decimal, expiresAt time.Time) Hold {
+	return Hold{
+		ID:                   uuid.NewString(),
+		AccountID:            from,
+		DestinationAccountID: to,
+		Amount:               amount,
+		Status:               consts.HoldStatusActive,
+		ExpiresAt:            expiresAt,
+		CreatedAt:            time.Now(),
+	}
+}
+
+func TestHoldLifecycle(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	from := createTestAccount(t, repo, utils.NewDecimal(100))
+	to := createTestAccount(t, repo, utils.NewDecimal(1))
+
+	hold := newTestHold(from, to, utils.NewDecimal(70), time.Now().Add(time.Hour))
+	require.NoError(t, repo.CreateHold(ctx, hold))
+
+	// the held amount is no longer available for other debits
+	assert.ErrorIs(t, repo.SubmitTransaction(ctx, newTestTransfer(from, to, utils.NewDecimal(40))), ErrInsufficientBalance)
+	assert.ErrorIs(t, repo.CreateHold(ctx, newTestHold(from, to, utils.NewDecimal(31), time.Now().Add(time.Hour))), ErrInsufficientBalance)
+
+	acc, err := repo.GetAccount(ctx, from)
+	require.NoError(t, err)
+	assert.Equal(t, "100", acc.Balance.String())
+	assert.Equal(t, "70", acc.HeldAmount.String())
+
+	capture := CapturePayload{HoldID: hold.ID, Transfer: newTestTransfer(from, to, utils.NewDecimal(60))}
+	require.NoError(t, repo.CaptureHold(ctx, capture))
+	assert.ErrorIs(t, repo.CaptureHold(ctx, capture), ErrHoldNotActive)
+	assert.ErrorIs(t, repo.VoidHold(ctx, hold.ID), ErrHoldNotActive)
+
+	acc, err = repo.GetAccount(ctx, from)
+	require.NoError(t, err)
+	assert.Equal(t, "40", acc.Balance.String())
+	assert.Equal(t, "0", acc.HeldAmount.String())
+
+	stored, err := repo.GetHold(ctx, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, consts.HoldStatusCaptured, stored.Status)
+	assert.Equal(t, capture.Transfer.Transaction.ID, stored.TransactionID.String)
+}
+
+func TestExpireHoldsReleasesStaleHolds(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	from := createTestAccount(t, repo, utils.NewDecimal(100))
+	to := createTestAccount(t, repo, utils.NewDecimal(1))
+
+	stale := newTestHold(from, to, utils.NewDecimal(30), time.Now().Add(time.Second))
+	fresh := newTestHold(from, to, utils.NewDecimal(20), time.Now().Add(time.Hour))
+	require.NoError(t, repo.CreateHold(ctx, stale))
+	require.NoError(t, repo.CreateHold(ctx, fresh))
+
+	_, err := repo.ExpireHolds(ctx, time.Now().Add(time.Minute), 1000)
+	require.NoError(t, err)
+
+	acc, err := repo.GetAccount(ctx, from)
+	require.NoError(t, err)
+	assert.Equal(t, "20", acc.HeldAmount.String())
+
+	stored, err := repo.GetHold(ctx, stale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, consts.HoldStatusExpired, stored.Status)
+
+	stored, err = repo.GetHold(ctx, fresh.ID)
+	require.NoError(t, err)
+	assert.Equal(t, consts.HoldStatusActive, stored.Status)
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+)
+
+// PostJournal books all legs of payload in one DB transaction and returns
+// them as written. Every account is locked in ascending account_id order
+// before any balance is read, so journals sharing accounts queue instead of
+// deadlocking.
+func (r *walletRepo) PostJournal(ctx context.Context, payload JournalPayload) ([]LedgerEntry, error) {
+	entries := make([]LedgerEntry, len(payload.Legs))
+	err := r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+
+		system, err := resolveSystemAccounts(ctx, repo, payload)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int, len(payload.Legs))
+		for i, leg := range payload.Legs {
+			entries[i] = leg.LedgerEntry
+			if leg.SystemCode != "" {
+				entries[i].AccountID = system[leg.SystemCode]
+			}
+			ids[i] = entries[i].AccountID
+		}
+
+		accounts, err := lockAccounts(ctx, repo, ids...)
+		if err != nil {
+			return err
+		}
+
+		for _, acc := range accounts {
+			if acc.Currency != payload.Currency {
+				return ErrCurrencyMismatch
+			}
+		}
+
+		if err := r.checkJournalDebits(ctx, repo, accounts, system, entries); err != nil {
+			return err
+		}
+
+		if err := insertTransaction(ctx, repo, payload.Transaction); err != nil {
+			return err
+		}
+
+		for i, e := range entries {
+			delta := e.Amount
+			if e.EntryType == consts.EntryTypeDebit {
+				delta = delta.Neg()
+			}
+
+			entries[i].BalanceBefore, entries[i].BalanceAfter, err = adjustAccountBalance(ctx, repo, e.AccountID, delta)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := insertLedgerEntries(ctx, repo, entries...); err != nil {
+			return err
+		}
+
+		return insertTransactionEvent(ctx, repo, consts.EventJournalPosted, payload.Transaction, entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// resolveSystemAccounts returns the account_id of every system account the
+// journal posts to by code. Codes are resolved in sorted order so two first
+// uses cannot deadlock on each other's inserts.
+func resolveSystemAccounts(ctx context.Context, repo *db.Repository, payload JournalPayload) (map[string]int, error) {
+	system := make(map[string]int)
+	for _, leg := range payload.Legs {
+		if leg.SystemCode != "" {
+			system[leg.SystemCode] = 0
+		}
+	}
+
+	codes := make([]string, 0, len(system))
+	for code := range system {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		accountID, err := systemAccount(ctx, repo, code, payload.Currency)
+		if err != nil {
+			return nil, err
+		}
+		system[code] = accountID
+	}
+
+	return system, nil
+}
+
+// checkJournalDebits requires every customer account the journal debits on
+// balance to have that net debit available and within its limits. System
+// accounts may go negative.
+func (r *walletRepo) checkJournalDebits(ctx context.Context, repo *db.Repository, accounts map[int]Account, system map[string]int, entries []LedgerEntry) error {
+	isSystem := make(map[int]bool, len(system))
+	for _, id := range system {
+		isSystem[id] = true
+	}
+
+	net := make(map[int]utils.Decimal, len(accounts))
+	for _, e := range entries {
+		amount := e.Amount
+		if e.EntryType == consts.EntryTypeCredit {
+			amount = amount.Neg()
+		}
+		sum, err := net[e.AccountID].CheckedAdd(amount)
+		if err != nil {
+			return err
+		}
+		net[e.AccountID] = sum
+	}
+
+	ids := make([]int, 0, len(net))
+	for id := range net {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		debit := net[id]
+		if isSystem[id] || !debit.IsPositive() {
+			continue
+		}
+
+		if availableBalance(accounts[id]).Cmp(debit) < 0 {
+			return ErrInsufficientBalance
+		}
+
+		if err := r.checkOutgoingLimits(ctx, repo, id, debit, utils.Decimal{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+func newTestJournal(legs ...JournalLeg) JournalPayload {
+	trxID := uuid.NewString()
+	total := utils.Decimal{}
+	for i := range legs {
+		legs[i].ID = uuid.NewString()
+		legs[i].TransactionID = trxID
+		legs[i].CreatedAt = time.Now()
+		if legs[i].EntryType == consts.EntryTypeDebit {
+			total = total.Add(legs[i].Amount)
+		}
+	}
+
+	return JournalPayload{
+		Currency: consts.DefaultCurrency,
+		Transaction: Transaction{
+			ID:              trxID,
+			ReferenceNumber: uuid.NewString(),
+			Type:            consts.TransactionTypeJournal,
+			Amount:          total,
+			Status:          "completed",
+			CreatedAt:       time.Now(),
+		},
+		Legs: legs,
+	}
+}
+
+func journalLeg(accountID int, entryType consts.EntryType, amount string) JournalLeg {
+	return JournalLeg{LedgerEntry: LedgerEntry{AccountID: accountID, EntryType: entryType, Amount: utils.MustParseDecimal(amount)}}
+}
+
+func TestPostJournalSplit(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	payer := createTestAccount(t, repo, utils.NewDecimal(100))
+	first := createTestAccount(t, repo, utils.NewDecimal(0))
+	second := createTestAccount(t, repo, utils.NewDecimal(0))
+
+	fee := journalLeg(0, consts.EntryTypeCredit, "0.5")
+	fee.SystemCode = consts.SystemAccountFees
+
+	entries, err := repo.PostJournal(ctx, newTestJournal(
+		journalLeg(payer, consts.EntryTypeDebit, "60"),
+		journalLeg(first, consts.EntryTypeCredit, "40"),
+		journalLeg(second, consts.EntryTypeCredit, "19.5"),
+		fee,
+	))
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, "40", entries[0].BalanceAfter.String())
+	assert.Less(t, entries[3].AccountID, 0)
+
+	for accountID, want := range map[int]string{payer: "40", first: "40", second: "19.5"} {
+		acc, err := repo.GetAccount(ctx, accountID)
+		require.NoError(t, err)
+		assert.Equal(t, want, acc.Balance.String())
+	}
+
+	// the payer's net debit of 50 is checked against its balance of 40
+	_, err = repo.PostJournal(ctx, newTestJournal(
+		journalLeg(payer, consts.EntryTypeDebit, "70"),
+		journalLeg(payer, consts.EntryTypeCredit, "20"),
+		journalLeg(first, consts.EntryTypeCredit, "50"),
+	))
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+	_, err = repo.PostJournal(ctx, newTestJournal(
+		journalLeg(payer, consts.EntryTypeDebit, "10"),
+		journalLeg(first, consts.EntryTypeCredit, "9"),
+	))
+	assert.ErrorIs(t, err, ErrUnbalancedEntries)
+
+	acc, err := repo.GetAccount(ctx, payer)
+	require.NoError(t, err)
+	assert.Equal(t, "40", acc.Balance.String())
+}
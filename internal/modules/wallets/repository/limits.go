@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+)
+
+// Limit rules, as reported by LimitError.
+const (
+	LimitMinAmount     = "min_amount"
+	LimitMaxAmount     = "max_amount"
+	LimitDailyAmount   = "daily_amount"
+	LimitMonthlyAmount = "monthly_amount"
+	LimitDailyCount    = "daily_count"
+	LimitMonthlyCount  = "monthly_count"
+)
+
+// limitedTypes are the transaction types counted towards an account's
+// outgoing totals.
+var limitedTypes = []consts.TransactionType{
+	consts.TransactionTypeTransfer,
+	consts.TransactionTypeWithdraw,
+	consts.TransactionTypeJournal,
+}
+
+// LimitError reports the limit rule an outgoing movement breached.
+type LimitError struct {
+	Rule  string
+	Limit string
+}
+
+func (e *LimitError) Error() string {
+	if e.Rule == LimitMinAmount {
+		return fmt.Sprintf("%s limit of %s not met", e.Rule, e.Limit)
+	}
+
+	return fmt.Sprintf("%s limit of %s exceeded", e.Rule, e.Limit)
+}
+
+// Limits bounds the money an account may send. The amounts are in the
+// account's currency and the windows are calendar days and months. Zero
+// values disable the corresponding rule.
+type Limits struct {
+	MinAmount     utils.Decimal `json:"min_amount"`
+	MaxAmount     utils.Decimal `json:"max_amount"`
+	DailyAmount   utils.Decimal `json:"daily_amount"`
+	MonthlyAmount utils.Decimal `json:"monthly_amount"`
+	DailyCount    int           `json:"daily_count"`
+	MonthlyCount  int           `json:"monthly_count"`
+}
+
+// DefaultLimits applies when no limits are configured.
+var DefaultLimits = Limits{
+	MinAmount: utils.NewDecimal(1),
+	MaxAmount: utils.NewDecimal(1000000),
+}
+
+// LimitOverrides replaces the configured Limits for one account. Nil fields
+// keep the default; a zero value lifts the rule for the account.
+type LimitOverrides struct {
+	AccountID     int            `json:"account_id"`
+	MinAmount     *utils.Decimal `json:"min_amount,omitempty"`
+	MaxAmount     *utils.Decimal `json:"max_amount,omitempty"`
+	DailyAmount   *utils.Decimal `json:"daily_amount,omitempty"`
+	MonthlyAmount *utils.Decimal `json:"monthly_amount,omitempty"`
+	DailyCount    *int           `json:"daily_count,omitempty"`
+	MonthlyCount  *int           `json:"monthly_count,omitempty"`
+	UpdatedBy     string         `json:"updated_by,omitempty"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// Apply returns l with the overrides set in o.
+func (l Limits) Apply(o LimitOverrides) Limits {
+	if o.MinAmount != nil {
+		l.MinAmount = *o.MinAmount
+	}
+	if o.MaxAmount != nil {
+		l.MaxAmount = *o.MaxAmount
+	}
+	if o.DailyAmount != nil {
+		l.DailyAmount = *o.DailyAmount
+	}
+	if o.MonthlyAmount != nil {
+		l.MonthlyAmount = *o.MonthlyAmount
+	}
+	if o.DailyCount != nil {
+		l.DailyCount = *o.DailyCount
+	}
+	if o.MonthlyCount != nil {
+		l.MonthlyCount = *o.MonthlyCount
+	}
+
+	return l
+}
+
+// CheckAmount applies the per-transaction rules to amount.
+func (l Limits) CheckAmount(amount utils.Decimal) error {
+	if l.MinAmount.IsPositive() && amount.Cmp(l.MinAmount) < 0 {
+		return &LimitError{Rule: LimitMinAmount, Limit: l.MinAmount.String()}
+	}
+
+	if l.MaxAmount.IsPositive() && amount.Cmp(l.MaxAmount) > 0 {
+		return &LimitError{Rule: LimitMaxAmount, Limit: l.MaxAmount.String()}
+	}
+
+	return nil
+}
+
+// check applies every rule to an outgoing amount on top of the account's
+// usage so far. The per-transaction rules look at amount alone, the totals
+// at everything debited including fee.
+func (l Limits) check(amount, fee utils.Decimal, usage limitUsage) error {
+	if err := l.CheckAmount(amount); err != nil {
+		return err
+	}
+
+	if l.DailyCount > 0 && usage.dailyCount+1 > l.DailyCount {
+		return &LimitError{Rule: LimitDailyCount, Limit: fmt.Sprint(l.DailyCount)}
+	}
+
+	if l.MonthlyCount > 0 && usage.monthlyCount+1 > l.MonthlyCount {
+		return &LimitError{Rule: LimitMonthlyCount, Limit: fmt.Sprint(l.MonthlyCount)}
+	}
+
+	// a sum out of range is above any limit
+	debited, err := amount.CheckedAdd(fee)
+	if err != nil {
+		debited = utils.MaxDecimal
+	}
+
+	if l.DailyAmount.IsPositive() && exceeds(usage.dailyAmount, debited, l.DailyAmount) {
+		return &LimitError{Rule: LimitDailyAmount, Limit: l.DailyAmount.String()}
+	}
+
+	if l.MonthlyAmount.IsPositive() && exceeds(usage.monthlyAmount, debited, l.MonthlyAmount) {
+		return &LimitError{Rule: LimitMonthlyAmount, Limit: l.MonthlyAmount.String()}
+	}
+
+	return nil
+}
+
+// exceeds reports whether used plus debited is above limit.
+func exceeds(used, debited, limit utils.Decimal) bool {
+	total, err := used.CheckedAdd(debited)
+	return err != nil || total.Cmp(limit) > 0
+}
+
+func (l Limits) windowed() bool {
+	return l.DailyAmount.IsPositive() || l.MonthlyAmount.IsPositive() || l.DailyCount > 0 || l.MonthlyCount > 0
+}
+
+// limitUsage is what an account sent in the current day and month.
+type limitUsage struct {
+	dailyAmount   utils.Decimal
+	dailyCount    int
+	monthlyAmount utils.Decimal
+	monthlyCount  int
+}
+
+func (r *walletRepo) GetLimitOverrides(ctx context.Context, accountID int) (LimitOverrides, error) {
+	return limitOverrides(ctx, r.db, accountID)
+}
+
+// SetLimitOverrides stores o as the account's complete set of overrides,
+// replacing any previous one.
+func (r *walletRepo) SetLimitOverrides(ctx context.Context, o LimitOverrides) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO account_limits (account_id,
+						min_amount,
+						max_amount,
+						daily_amount,
+						monthly_amount,
+						daily_count,
+						monthly_count,
+						updated_by,
+						updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (account_id) DO UPDATE SET
+			min_amount = EXCLUDED.min_amount,
+			max_amount = EXCLUDED.max_amount,
+			daily_amount = EXCLUDED.daily_amount,
+			monthly_amount = EXCLUDED.monthly_amount,
+			daily_count = EXCLUDED.daily_count,
+			monthly_count = EXCLUDED.monthly_count,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`,
+		o.AccountID,
+		o.MinAmount,
+		o.MaxAmount,
+		o.DailyAmount,
+		o.MonthlyAmount,
+		o.DailyCount,
+		o.MonthlyCount,
+		o.UpdatedBy,
+		o.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert account_limits data: %w", err)
+	}
+
+	return nil
+}
+
+func limitOverrides(ctx context.Context, repo *db.Repository, accountID int) (LimitOverrides, error) {
+	result := LimitOverrides{AccountID: accountID}
+	err := repo.QueryRow(ctx, `SELECT min_amount,
+						max_amount,
+						daily_amount,
+						monthly_amount,
+						daily_count,
+						monthly_count,
+						updated_by,
+						updated_at
+		FROM account_limits WHERE account_id = $1`, accountID).Scan(
+		&result.MinAmount,
+		&result.MaxAmount,
+		&result.DailyAmount,
+		&result.MonthlyAmount,
+		&result.DailyCount,
+		&result.MonthlyCount,
+		&result.UpdatedBy,
+		&result.UpdatedAt,
+	)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return LimitOverrides{AccountID: accountID}, nil
+	}
+
+	if err != nil {
+		return LimitOverrides{}, fmt.Errorf("failed to query account_limits data: %w", err)
+	}
+
+	return result, nil
+}
+
+// checkOutgoingLimits evaluates the account's effective limits for sending
+// amount plus fee now. Callers must hold the account lock, so concurrent
+// movements from the same account are counted in order.
+func (r *walletRepo) checkOutgoingLimits(ctx context.Context, repo *db.Repository, accountID int, amount, fee utils.Decimal) error {
+	overrides, err := limitOverrides(ctx, repo, accountID)
+	if err != nil {
+		return err
+	}
+
+	limits := r.limits.Apply(overrides)
+	if !limits.windowed() {
+		return limits.CheckAmount(amount)
+	}
+
+	usage, err := outgoingUsage(ctx, repo, accountID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return limits.check(amount, fee, usage)
+}
+
+// outgoingUsage sums the account's debits of limitedTypes since the start of
+// now's day and month, fees included, and counts the transactions they
+// belong to. Reversals do not give the limit back.
+func outgoingUsage(ctx context.Context, repo *db.Repository, accountID int, now time.Time) (limitUsage, error) {
+	year, month, day := now.Date()
+	dayStart := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+
+	types := make([]string, len(limitedTypes))
+	for i, t := range limitedTypes {
+		types[i] = string(t)
+	}
+
+	var usage limitUsage
+	err := repo.QueryRow(ctx, `SELECT COALESCE(SUM(le.amount) FILTER (WHERE le.created_at >= $2), 0),
+					COUNT(DISTINCT le.transaction_id) FILTER (WHERE le.created_at >= $2),
+					COALESCE(SUM(le.amount), 0),
+					COUNT(DISTINCT le.transaction_id)
+		FROM ledger_entries le
+		JOIN transactions t ON t.id = le.transaction_id
+		WHERE le.account_id = $1
+			AND le.entry_type = $3
+			AND le.created_at >= $4
+			AND t.type = ANY($5)`,
+		accountID, dayStart, consts.EntryTypeDebit, monthStart, pq.Array(types),
+	).Scan(&usage.dailyAmount, &usage.dailyCount, &usage.monthlyAmount, &usage.monthlyCount)
+	if err != nil {
+		return limitUsage{}, fmt.Errorf("failed to query ledger_entries data: %w", err)
+	}
+
+	return usage, nil
+}
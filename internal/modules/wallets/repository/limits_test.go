@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+func TestLimitsCheck(t *testing.T) {
+	limits := Limits{
+		MinAmount:     utils.NewDecimal(1),
+		MaxAmount:     utils.NewDecimal(100),
+		DailyAmount:   utils.NewDecimal(150),
+		MonthlyAmount: utils.NewDecimal(1000),
+		DailyCount:    3,
+	}
+
+	testTables := []struct {
+		name   string
+		amount string
+		fee    utils.Decimal
+		usage  limitUsage
+		rule   string
+	}{
+		{name: "within limits", amount: "50", usage: limitUsage{dailyAmount: utils.NewDecimal(100), dailyCount: 2}},
+		{name: "below minimum", amount: "0.5", rule: LimitMinAmount},
+		{name: "above maximum", amount: "100.01", rule: LimitMaxAmount},
+		{name: "daily count", amount: "1", usage: limitUsage{dailyCount: 3}, rule: LimitDailyCount},
+		{name: "daily amount", amount: "50.01", usage: limitUsage{dailyAmount: utils.NewDecimal(100)}, rule: LimitDailyAmount},
+		{name: "daily amount with fee", amount: "49", fee: utils.MustParseDecimal("1.01"), usage: limitUsage{dailyAmount: utils.NewDecimal(100)}, rule: LimitDailyAmount},
+		{name: "maximum ignores fee", amount: "100", fee: utils.NewDecimal(5)},
+		{name: "monthly amount", amount: "10", usage: limitUsage{monthlyAmount: utils.NewDecimal(995)}, rule: LimitMonthlyAmount},
+		{name: "unlimited monthly count", amount: "10", usage: limitUsage{monthlyCount: 10_000}},
+		{name: "usage at the end of the range", amount: "10", usage: limitUsage{monthlyAmount: utils.MaxDecimal}, rule: LimitMonthlyAmount},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.check(utils.MustParseDecimal(tt.amount), tt.fee, tt.usage)
+			if tt.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var limitErr *LimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, tt.rule, limitErr.Rule)
+		})
+	}
+}
+
+func TestLimitsApply(t *testing.T) {
+	zero := utils.Decimal{}
+	count := 5
+	limits := DefaultLimits.Apply(LimitOverrides{MaxAmount: &zero, DailyCount: &count})
+
+	assert.Equal(t, DefaultLimits.MinAmount, limits.MinAmount)
+	assert.True(t, limits.MaxAmount.IsZero())
+	assert.Equal(t, 5, limits.DailyCount)
+	assert.NoError(t, limits.CheckAmount(utils.NewDecimal(5_000_000)))
+}
+
+func TestSubmitTransactionDailyLimitUsesLedgerHistory(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	repo.limits = Limits{DailyAmount: utils.NewDecimal(100)}
+	ctx := context.Background()
+
+	from := createTestAccount(t, repo, utils.NewDecimal(500))
+	to := createTestAccount(t, repo, utils.NewDecimal(0))
+
+	require.NoError(t, repo.SubmitTransaction(ctx, newTestTransfer(from, to, utils.NewDecimal(60))))
+
+	err := repo.SubmitTransaction(ctx, newTestTransfer(from, to, utils.NewDecimal(41)))
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, LimitDailyAmount, limitErr.Rule)
+
+	// an override lifts the default for this account only
+	raised := utils.NewDecimal(200)
+	require.NoError(t, repo.SetLimitOverrides(ctx, LimitOverrides{
+		AccountID:   from,
+		DailyAmount: &raised,
+		UpdatedBy:   "ops",
+		UpdatedAt:   time.Now(),
+	}))
+	require.NoError(t, repo.SubmitTransaction(ctx, newTestTransfer(from, to, utils.NewDecimal(41))))
+
+	overrides, err := repo.GetLimitOverrides(ctx, from)
+	require.NoError(t, err)
+	require.NotNil(t, overrides.DailyAmount)
+	assert.Equal(t, "200", overrides.DailyAmount.String())
+	assert.Nil(t, overrides.MaxAmount)
+}
+
+func TestSubmitTransactionConcurrentNeverExceedsDailyCount(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	repo.limits = Limits{DailyCount: 5}
+	ctx := context.Background()
+
+	from := createTestAccount(t, repo, utils.NewDecimal(1000))
+	to := createTestAccount(t, repo, utils.NewDecimal(0))
+
+	const workers = 20
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.SubmitTransaction(ctx, newTestTransfer(from, to, utils.NewDecimal(1)))
+
+			var limitErr *LimitError
+			if err != nil && !errors.As(err, &limitErr) {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, accepted)
+}
@@ -12,8 +12,10 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	repository "github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	utils "github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,6 +43,80 @@ func (m *MockWalletRepository) EXPECT() *MockWalletRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CancelScheduledTransfer mocks base method.
+func (m *MockWalletRepository) CancelScheduledTransfer(ctx context.Context, id string) (repository.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(repository.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduledTransfer indicates an expected call of CancelScheduledTransfer.
+func (mr *MockWalletRepositoryMockRecorder) CancelScheduledTransfer(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledTransfer", reflect.TypeOf((*MockWalletRepository)(nil).CancelScheduledTransfer), ctx, id)
+}
+
+// CaptureHold mocks base method.
+func (m *MockWalletRepository) CaptureHold(ctx context.Context, payload repository.CapturePayload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", ctx, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockWalletRepositoryMockRecorder) CaptureHold(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockWalletRepository)(nil).CaptureHold), ctx, payload)
+}
+
+// ChangeAccountStatus mocks base method.
+func (m *MockWalletRepository) ChangeAccountStatus(ctx context.Context, payload repository.StatusChangePayload) (repository.AccountStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeAccountStatus", ctx, payload)
+	ret0, _ := ret[0].(repository.AccountStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeAccountStatus indicates an expected call of ChangeAccountStatus.
+func (mr *MockWalletRepositoryMockRecorder) ChangeAccountStatus(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeAccountStatus", reflect.TypeOf((*MockWalletRepository)(nil).ChangeAccountStatus), ctx, payload)
+}
+
+// ClaimScheduledTransfers mocks base method.
+func (m *MockWalletRepository) ClaimScheduledTransfers(ctx context.Context, now time.Time, lockedUntil time.Time, limit int) ([]repository.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimScheduledTransfers", ctx, now, lockedUntil, limit)
+	ret0, _ := ret[0].([]repository.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimScheduledTransfers indicates an expected call of ClaimScheduledTransfers.
+func (mr *MockWalletRepositoryMockRecorder) ClaimScheduledTransfers(ctx, now, lockedUntil, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimScheduledTransfers", reflect.TypeOf((*MockWalletRepository)(nil).ClaimScheduledTransfers), ctx, now, lockedUntil, limit)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockWalletRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lockedUntil time.Time, limit int) ([]repository.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, now, lockedUntil, limit)
+	ret0, _ := ret[0].([]repository.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockWalletRepositoryMockRecorder) ClaimWebhookDeliveries(ctx, now, lockedUntil, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockWalletRepository)(nil).ClaimWebhookDeliveries), ctx, now, lockedUntil, limit)
+}
+
 // CreateAccount mocks base method.
 func (m *MockWalletRepository) CreateAccount(ctx context.Context, payload repository.DepositPayload) error {
 	m.ctrl.T.Helper()
@@ -55,6 +131,106 @@ func (mr *MockWalletRepositoryMockRecorder) CreateAccount(ctx, payload any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockWalletRepository)(nil).CreateAccount), ctx, payload)
 }
 
+// CreateCustomer mocks base method.
+func (m *MockWalletRepository) CreateCustomer(ctx context.Context, customer repository.Customer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCustomer", ctx, customer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCustomer indicates an expected call of CreateCustomer.
+func (mr *MockWalletRepositoryMockRecorder) CreateCustomer(ctx, customer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCustomer", reflect.TypeOf((*MockWalletRepository)(nil).CreateCustomer), ctx, customer)
+}
+
+// CreateHold mocks base method.
+func (m *MockWalletRepository) CreateHold(ctx context.Context, hold repository.Hold) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", ctx, hold)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockWalletRepositoryMockRecorder) CreateHold(ctx, hold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockWalletRepository)(nil).CreateHold), ctx, hold)
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockWalletRepository) CreateScheduledTransfer(ctx context.Context, st repository.ScheduledTransfer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", ctx, st)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockWalletRepositoryMockRecorder) CreateScheduledTransfer(ctx, st any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockWalletRepository)(nil).CreateScheduledTransfer), ctx, st)
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockWalletRepository) CreateWebhookSubscription(ctx context.Context, sub repository.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, sub)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) CreateWebhookSubscription(ctx, sub any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).CreateWebhookSubscription), ctx, sub)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockWalletRepository) DeleteWebhookSubscription(ctx context.Context, id string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) DeleteWebhookSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).DeleteWebhookSubscription), ctx, id)
+}
+
+// Deposit mocks base method.
+func (m *MockWalletRepository) Deposit(ctx context.Context, payload repository.TopUpPayload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deposit", ctx, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Deposit indicates an expected call of Deposit.
+func (mr *MockWalletRepositoryMockRecorder) Deposit(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deposit", reflect.TypeOf((*MockWalletRepository)(nil).Deposit), ctx, payload)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockWalletRepository) ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", ctx, now, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockWalletRepositoryMockRecorder) ExpireHolds(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockWalletRepository)(nil).ExpireHolds), ctx, now, limit)
+}
+
 // GetAccount mocks base method.
 func (m *MockWalletRepository) GetAccount(ctx context.Context, accountID int) (repository.Account, error) {
 	m.ctrl.T.Helper()
@@ -70,6 +246,378 @@ func (mr *MockWalletRepositoryMockRecorder) GetAccount(ctx, accountID any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockWalletRepository)(nil).GetAccount), ctx, accountID)
 }
 
+// GetCustomer mocks base method.
+func (m *MockWalletRepository) GetCustomer(ctx context.Context, id string) (repository.Customer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCustomer", ctx, id)
+	ret0, _ := ret[0].(repository.Customer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCustomer indicates an expected call of GetCustomer.
+func (mr *MockWalletRepositoryMockRecorder) GetCustomer(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCustomer", reflect.TypeOf((*MockWalletRepository)(nil).GetCustomer), ctx, id)
+}
+
+// GetHold mocks base method.
+func (m *MockWalletRepository) GetHold(ctx context.Context, id string) (repository.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, id)
+	ret0, _ := ret[0].(repository.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockWalletRepositoryMockRecorder) GetHold(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockWalletRepository)(nil).GetHold), ctx, id)
+}
+
+// GetLimitOverrides mocks base method.
+func (m *MockWalletRepository) GetLimitOverrides(ctx context.Context, accountID int) (repository.LimitOverrides, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLimitOverrides", ctx, accountID)
+	ret0, _ := ret[0].(repository.LimitOverrides)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLimitOverrides indicates an expected call of GetLimitOverrides.
+func (mr *MockWalletRepositoryMockRecorder) GetLimitOverrides(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLimitOverrides", reflect.TypeOf((*MockWalletRepository)(nil).GetLimitOverrides), ctx, accountID)
+}
+
+// GetReversedAmount mocks base method.
+func (m *MockWalletRepository) GetReversedAmount(ctx context.Context, transactionID string) (utils.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReversedAmount", ctx, transactionID)
+	ret0, _ := ret[0].(utils.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReversedAmount indicates an expected call of GetReversedAmount.
+func (mr *MockWalletRepositoryMockRecorder) GetReversedAmount(ctx, transactionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReversedAmount", reflect.TypeOf((*MockWalletRepository)(nil).GetReversedAmount), ctx, transactionID)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockWalletRepository) GetScheduledTransfer(ctx context.Context, id string) (repository.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(repository.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockWalletRepositoryMockRecorder) GetScheduledTransfer(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockWalletRepository)(nil).GetScheduledTransfer), ctx, id)
+}
+
+// GetTransaction mocks base method.
+func (m *MockWalletRepository) GetTransaction(ctx context.Context, id string) (repository.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransaction", ctx, id)
+	ret0, _ := ret[0].(repository.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransaction indicates an expected call of GetTransaction.
+func (mr *MockWalletRepositoryMockRecorder) GetTransaction(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransaction", reflect.TypeOf((*MockWalletRepository)(nil).GetTransaction), ctx, id)
+}
+
+// GetTransactionByReference mocks base method.
+func (m *MockWalletRepository) GetTransactionByReference(ctx context.Context, referenceNumber string) (repository.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionByReference", ctx, referenceNumber)
+	ret0, _ := ret[0].(repository.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionByReference indicates an expected call of GetTransactionByReference.
+func (mr *MockWalletRepositoryMockRecorder) GetTransactionByReference(ctx, referenceNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionByReference", reflect.TypeOf((*MockWalletRepository)(nil).GetTransactionByReference), ctx, referenceNumber)
+}
+
+// GetTransactionLedgerEntries mocks base method.
+func (m *MockWalletRepository) GetTransactionLedgerEntries(ctx context.Context, transactionID string) ([]repository.LedgerEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionLedgerEntries", ctx, transactionID)
+	ret0, _ := ret[0].([]repository.LedgerEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactionLedgerEntries indicates an expected call of GetTransactionLedgerEntries.
+func (mr *MockWalletRepositoryMockRecorder) GetTransactionLedgerEntries(ctx, transactionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionLedgerEntries", reflect.TypeOf((*MockWalletRepository)(nil).GetTransactionLedgerEntries), ctx, transactionID)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockWalletRepository) GetWebhookDelivery(ctx context.Context, id string) (repository.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(repository.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockWalletRepositoryMockRecorder) GetWebhookDelivery(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockWalletRepository)(nil).GetWebhookDelivery), ctx, id)
+}
+
+// GetWebhookSubscription mocks base method.
+func (m *MockWalletRepository) GetWebhookSubscription(ctx context.Context, id string) (repository.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(repository.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscription indicates an expected call of GetWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) GetWebhookSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).GetWebhookSubscription), ctx, id)
+}
+
+// ListAccountStatusChanges mocks base method.
+func (m *MockWalletRepository) ListAccountStatusChanges(ctx context.Context, accountID int) ([]repository.AccountStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountStatusChanges", ctx, accountID)
+	ret0, _ := ret[0].([]repository.AccountStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountStatusChanges indicates an expected call of ListAccountStatusChanges.
+func (mr *MockWalletRepositoryMockRecorder) ListAccountStatusChanges(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountStatusChanges", reflect.TypeOf((*MockWalletRepository)(nil).ListAccountStatusChanges), ctx, accountID)
+}
+
+// ListCustomerAccounts mocks base method.
+func (m *MockWalletRepository) ListCustomerAccounts(ctx context.Context, customerID string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCustomerAccounts", ctx, customerID)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCustomerAccounts indicates an expected call of ListCustomerAccounts.
+func (mr *MockWalletRepositoryMockRecorder) ListCustomerAccounts(ctx, customerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCustomerAccounts", reflect.TypeOf((*MockWalletRepository)(nil).ListCustomerAccounts), ctx, customerID)
+}
+
+// ListLedgerEntries mocks base method.
+func (m *MockWalletRepository) ListLedgerEntries(ctx context.Context, filter repository.LedgerFilter) ([]repository.HistoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLedgerEntries", ctx, filter)
+	ret0, _ := ret[0].([]repository.HistoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLedgerEntries indicates an expected call of ListLedgerEntries.
+func (mr *MockWalletRepositoryMockRecorder) ListLedgerEntries(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLedgerEntries", reflect.TypeOf((*MockWalletRepository)(nil).ListLedgerEntries), ctx, filter)
+}
+
+// ListScheduledTransfers mocks base method.
+func (m *MockWalletRepository) ListScheduledTransfers(ctx context.Context, filter repository.ScheduledTransferFilter) ([]repository.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", ctx, filter)
+	ret0, _ := ret[0].([]repository.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockWalletRepositoryMockRecorder) ListScheduledTransfers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockWalletRepository)(nil).ListScheduledTransfers), ctx, filter)
+}
+
+// ListWebhookAttempts mocks base method.
+func (m *MockWalletRepository) ListWebhookAttempts(ctx context.Context, deliveryID string) ([]repository.WebhookAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookAttempts", ctx, deliveryID)
+	ret0, _ := ret[0].([]repository.WebhookAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookAttempts indicates an expected call of ListWebhookAttempts.
+func (mr *MockWalletRepositoryMockRecorder) ListWebhookAttempts(ctx, deliveryID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookAttempts", reflect.TypeOf((*MockWalletRepository)(nil).ListWebhookAttempts), ctx, deliveryID)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockWalletRepository) ListWebhookDeliveries(ctx context.Context, filter repository.WebhookDeliveryFilter) ([]repository.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, filter)
+	ret0, _ := ret[0].([]repository.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockWalletRepositoryMockRecorder) ListWebhookDeliveries(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockWalletRepository)(nil).ListWebhookDeliveries), ctx, filter)
+}
+
+// ListWebhookSubscriptions mocks base method.
+func (m *MockWalletRepository) ListWebhookSubscriptions(ctx context.Context) ([]repository.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", ctx)
+	ret0, _ := ret[0].([]repository.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions.
+func (mr *MockWalletRepositoryMockRecorder) ListWebhookSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockWalletRepository)(nil).ListWebhookSubscriptions), ctx)
+}
+
+// PostJournal mocks base method.
+func (m *MockWalletRepository) PostJournal(ctx context.Context, payload repository.JournalPayload) ([]repository.LedgerEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostJournal", ctx, payload)
+	ret0, _ := ret[0].([]repository.LedgerEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostJournal indicates an expected call of PostJournal.
+func (mr *MockWalletRepositoryMockRecorder) PostJournal(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostJournal", reflect.TypeOf((*MockWalletRepository)(nil).PostJournal), ctx, payload)
+}
+
+// RecordScheduledRun mocks base method.
+func (m *MockWalletRepository) RecordScheduledRun(ctx context.Context, run repository.ScheduledRun) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordScheduledRun", ctx, run)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordScheduledRun indicates an expected call of RecordScheduledRun.
+func (mr *MockWalletRepositoryMockRecorder) RecordScheduledRun(ctx, run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordScheduledRun", reflect.TypeOf((*MockWalletRepository)(nil).RecordScheduledRun), ctx, run)
+}
+
+// RecordWebhookAttempt mocks base method.
+func (m *MockWalletRepository) RecordWebhookAttempt(ctx context.Context, result repository.WebhookAttemptResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookAttempt", ctx, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordWebhookAttempt indicates an expected call of RecordWebhookAttempt.
+func (mr *MockWalletRepositoryMockRecorder) RecordWebhookAttempt(ctx, result any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookAttempt", reflect.TypeOf((*MockWalletRepository)(nil).RecordWebhookAttempt), ctx, result)
+}
+
+// RedeliverWebhook mocks base method.
+func (m *MockWalletRepository) RedeliverWebhook(ctx context.Context, id string) (repository.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeliverWebhook", ctx, id)
+	ret0, _ := ret[0].(repository.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeliverWebhook indicates an expected call of RedeliverWebhook.
+func (mr *MockWalletRepositoryMockRecorder) RedeliverWebhook(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeliverWebhook", reflect.TypeOf((*MockWalletRepository)(nil).RedeliverWebhook), ctx, id)
+}
+
+// RelayOutbox mocks base method.
+func (m *MockWalletRepository) RelayOutbox(ctx context.Context, limit int, lease time.Duration, backoff repository.OutboxBackoff, publish repository.OutboxPublishFunc) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelayOutbox", ctx, limit, lease, backoff, publish)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RelayOutbox indicates an expected call of RelayOutbox.
+func (mr *MockWalletRepositoryMockRecorder) RelayOutbox(ctx, limit, lease, backoff, publish any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelayOutbox", reflect.TypeOf((*MockWalletRepository)(nil).RelayOutbox), ctx, limit, lease, backoff, publish)
+}
+
+// ReverseTransaction mocks base method.
+func (m *MockWalletRepository) ReverseTransaction(ctx context.Context, payload repository.ReversalPayload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransaction", ctx, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReverseTransaction indicates an expected call of ReverseTransaction.
+func (mr *MockWalletRepositoryMockRecorder) ReverseTransaction(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransaction", reflect.TypeOf((*MockWalletRepository)(nil).ReverseTransaction), ctx, payload)
+}
+
+// SetAccountCustomer mocks base method.
+func (m *MockWalletRepository) SetAccountCustomer(ctx context.Context, accountID int, customerID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountCustomer", ctx, accountID, customerID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetAccountCustomer indicates an expected call of SetAccountCustomer.
+func (mr *MockWalletRepositoryMockRecorder) SetAccountCustomer(ctx, accountID, customerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountCustomer", reflect.TypeOf((*MockWalletRepository)(nil).SetAccountCustomer), ctx, accountID, customerID)
+}
+
+// SetLimitOverrides mocks base method.
+func (m *MockWalletRepository) SetLimitOverrides(ctx context.Context, overrides repository.LimitOverrides) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLimitOverrides", ctx, overrides)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLimitOverrides indicates an expected call of SetLimitOverrides.
+func (mr *MockWalletRepositoryMockRecorder) SetLimitOverrides(ctx, overrides any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLimitOverrides", reflect.TypeOf((*MockWalletRepository)(nil).SetLimitOverrides), ctx, overrides)
+}
+
 // SubmitTransaction mocks base method.
 func (m *MockWalletRepository) SubmitTransaction(ctx context.Context, payload repository.TransactionPayload) error {
 	m.ctrl.T.Helper()
@@ -83,3 +631,75 @@ func (mr *MockWalletRepositoryMockRecorder) SubmitTransaction(ctx, payload any)
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitTransaction", reflect.TypeOf((*MockWalletRepository)(nil).SubmitTransaction), ctx, payload)
 }
+
+// SubmitTransactions mocks base method.
+func (m *MockWalletRepository) SubmitTransactions(ctx context.Context, payloads []repository.TransactionPayload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitTransactions", ctx, payloads)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SubmitTransactions indicates an expected call of SubmitTransactions.
+func (mr *MockWalletRepositoryMockRecorder) SubmitTransactions(ctx, payloads any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitTransactions", reflect.TypeOf((*MockWalletRepository)(nil).SubmitTransactions), ctx, payloads)
+}
+
+// UpdateScheduledTransfer mocks base method.
+func (m *MockWalletRepository) UpdateScheduledTransfer(ctx context.Context, update repository.ScheduledTransferUpdate) (repository.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransfer", ctx, update)
+	ret0, _ := ret[0].(repository.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduledTransfer indicates an expected call of UpdateScheduledTransfer.
+func (mr *MockWalletRepositoryMockRecorder) UpdateScheduledTransfer(ctx, update any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransfer", reflect.TypeOf((*MockWalletRepository)(nil).UpdateScheduledTransfer), ctx, update)
+}
+
+// UpdateWebhookSubscription mocks base method.
+func (m *MockWalletRepository) UpdateWebhookSubscription(ctx context.Context, sub repository.WebhookSubscription) (repository.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookSubscription", ctx, sub)
+	ret0, _ := ret[0].(repository.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookSubscription indicates an expected call of UpdateWebhookSubscription.
+func (mr *MockWalletRepositoryMockRecorder) UpdateWebhookSubscription(ctx, sub any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookSubscription", reflect.TypeOf((*MockWalletRepository)(nil).UpdateWebhookSubscription), ctx, sub)
+}
+
+// VoidHold mocks base method.
+func (m *MockWalletRepository) VoidHold(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidHold", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VoidHold indicates an expected call of VoidHold.
+func (mr *MockWalletRepositoryMockRecorder) VoidHold(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidHold", reflect.TypeOf((*MockWalletRepository)(nil).VoidHold), ctx, id)
+}
+
+// Withdraw mocks base method.
+func (m *MockWalletRepository) Withdraw(ctx context.Context, payload repository.WithdrawPayload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Withdraw", ctx, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Withdraw indicates an expected call of Withdraw.
+func (mr *MockWalletRepositoryMockRecorder) Withdraw(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdraw", reflect.TypeOf((*MockWalletRepository)(nil).Withdraw), ctx, payload)
+}
@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+)
+
+// outboxRelayLock is the advisory lock held by the relay claiming events
+// from the outbox, a second relay finds it taken and claims nothing.
+const outboxRelayLock int64 = 0x6f7574626f78
+
+type (
+	eventAccount struct {
+		AccountID int           `json:"account_id"`
+		Currency  string        `json:"currency"`
+		Status    string        `json:"status"`
+		Balance   utils.Decimal `json:"balance"`
+		CreatedAt time.Time     `json:"created_at"`
+	}
+
+	eventTransaction struct {
+		ID                    string                 `json:"id"`
+		ReferenceNumber       string                 `json:"reference_number"`
+		Type                  consts.TransactionType `json:"type"`
+		Description           string                 `json:"description,omitempty"`
+		FromAccountID         int64                  `json:"from_account_id,omitempty"`
+		ToAccountID           int64                  `json:"to_account_id,omitempty"`
+		Amount                utils.Decimal          `json:"amount"`
+		Metadata              json.RawMessage        `json:"metadata,omitempty"`
+		Status                string                 `json:"status"`
+		OriginalTransactionID string                 `json:"original_transaction_id,omitempty"`
+		CreatedAt             time.Time              `json:"created_at"`
+	}
+
+	// transactionEvent is the data of every event booking a transaction.
+	transactionEvent struct {
+		Account     *eventAccount    `json:"account,omitempty"`
+		Transaction eventTransaction `json:"transaction"`
+		Entries     []LedgerEntry    `json:"entries"`
+	}
+
+	statusChangeEvent struct {
+		AccountID          int       `json:"account_id"`
+		FromStatus         string    `json:"from_status"`
+		ToStatus           string    `json:"to_status"`
+		Actor              string    `json:"actor"`
+		Reason             string    `json:"reason"`
+		SweepTransactionID string    `json:"sweep_transaction_id,omitempty"`
+		CreatedAt          time.Time `json:"created_at"`
+	}
+)
+
+func toEventTransaction(trx Transaction) eventTransaction {
+	return eventTransaction{
+		ID:                    trx.ID,
+		ReferenceNumber:       trx.ReferenceNumber,
+		Type:                  trx.Type,
+		Description:           trx.Description,
+		FromAccountID:         trx.FromAccountID.Int64,
+		ToAccountID:           trx.ToAccountID.Int64,
+		Amount:                trx.Amount,
+		Metadata:              trx.Metadata,
+		Status:                trx.Status,
+		OriginalTransactionID: trx.OriginalTransactionID.String,
+		CreatedAt:             trx.CreatedAt,
+	}
+}
+
+// insertTransactionEvent writes an event for trx and its ledger entries,
+// concerning every customer account the entries post to.
+func insertTransactionEvent(ctx context.Context, repo *db.Repository, eventType string, trx Transaction, entries []LedgerEntry) error {
+	ids := make([]int, len(entries))
+	for i, e := range entries {
+		ids[i] = e.AccountID
+	}
+
+	return insertEvent(ctx, repo, eventType, transactionEvent{
+		Transaction: toEventTransaction(trx),
+		Entries:     entries,
+	}, ids...)
+}
+
+// insertEvent writes an event into the outbox within the caller's DB
+// transaction, so it exists exactly when the change it describes commits.
+// Callers insert it after locking accountIDs, which makes the outbox order of
+// an account's events its commit order. Every active webhook subscription to
+// the event gets its delivery in the same transaction.
+func insertEvent(ctx context.Context, repo *db.Repository, eventType string, data interface{}, accountIDs ...int) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	event := OutboxEvent{
+		EventID:    uuid.NewString(),
+		Type:       eventType,
+		AccountIDs: customerAccounts(accountIDs),
+		Data:       payload,
+		CreatedAt:  time.Now(),
+	}
+
+	_, err = repo.Exec(ctx, `INSERT INTO outbox (event_id, event_type, account_ids, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		event.EventID, event.Type, pq.Array(event.AccountIDs), payload, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox data: %w", err)
+	}
+
+	return insertWebhookDeliveries(ctx, repo, event)
+}
+
+// customerAccounts returns the distinct customer accounts among ids in
+// ascending order. System accounts carry negative ids and are left out, every
+// posting touches them.
+func customerAccounts(ids []int) []int64 {
+	seen := make(map[int]bool, len(ids))
+	result := []int64{}
+	for _, id := range ids {
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, int64(id))
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// RelayOutbox hands up to limit unpublished events to publish, oldest first,
+// and returns how many were published. The events are claimed for lease in a
+// short transaction and published after it committed, so a slow publisher
+// holds no transaction or lock open and a retried transaction never sends an
+// event twice; a second transaction then records the outcome. An event is
+// marked published only once publish returns nil, so it may be delivered more
+// than once but is never lost. A failed event is retried after
+// backoff(attempts) and holds back the later events of its accounts until
+// then, keeping every account's events in order. Events are only claimed by
+// one relay at a time, concurrent calls publish nothing.
+func (r *walletRepo) RelayOutbox(ctx context.Context, limit int, lease time.Duration, backoff OutboxBackoff, publish OutboxPublishFunc) (int, error) {
+	now := time.Now()
+	deadline := now.Add(lease)
+
+	events, err := r.claimOutboxEvents(ctx, now, deadline, limit)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	sent, failed := relayEvents(ctx, events, deadline, publish)
+
+	claimed := make([]int64, len(events))
+	for i, e := range events {
+		claimed[i] = e.ID
+	}
+
+	err = r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+		publishedAt := time.Now()
+		if len(sent) > 0 {
+			_, err := repo.Exec(ctx, "UPDATE outbox SET published_at = $1, last_error = NULL, next_attempt_at = NULL, locked_until = NULL WHERE id = ANY($2)",
+				publishedAt, pq.Array(sent))
+			if err != nil {
+				return fmt.Errorf("failed to update outbox data: %w", err)
+			}
+		}
+
+		for _, f := range failed {
+			_, err := repo.Exec(ctx, "UPDATE outbox SET attempts = $2, last_error = $3, next_attempt_at = $4, locked_until = NULL WHERE id = $1",
+				f.ID, f.Attempts, f.Err.Error(), publishedAt.Add(backoff(f.Attempts)))
+			if err != nil {
+				return fmt.Errorf("failed to update outbox data: %w", err)
+			}
+		}
+
+		// events held back behind a failure or left when the lease ran out
+		_, err := repo.Exec(ctx, "UPDATE outbox SET locked_until = NULL WHERE id = ANY($1) AND published_at IS NULL",
+			pq.Array(claimed))
+		if err != nil {
+			return fmt.Errorf("failed to update outbox data: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(sent), nil
+}
+
+// claimOutboxEvents locks up to limit deliverable events until lockedUntil
+// and returns them in id order. An event is deliverable when it is due, not
+// claimed by another relay, and no earlier unpublished event of its accounts
+// is waiting for a retry or claimed; those hold it back, so skipping them
+// before LIMIT keeps a stuck account from starving the others.
+func (r *walletRepo) claimOutboxEvents(ctx context.Context, now, lockedUntil time.Time, limit int) ([]OutboxEvent, error) {
+	var events []OutboxEvent
+	err := r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+		events = nil
+
+		// claims are taken one relay at a time, so none can see an event as
+		// deliverable while another relay's claim on an earlier event of
+		// its accounts is still uncommitted
+		var locked bool
+		if err := repo.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", outboxRelayLock).Scan(&locked); err != nil {
+			return fmt.Errorf("failed to lock outbox: %w", err)
+		}
+
+		if !locked {
+			return nil
+		}
+
+		rows, err := repo.Query(ctx, `UPDATE outbox SET locked_until = $2
+			WHERE id IN (
+				SELECT o.id FROM outbox o
+				WHERE o.published_at IS NULL
+					AND (o.next_attempt_at IS NULL OR o.next_attempt_at <= $1)
+					AND (o.locked_until IS NULL OR o.locked_until <= $1)
+					AND NOT EXISTS (
+						SELECT 1 FROM outbox b
+						WHERE b.published_at IS NULL AND b.id < o.id AND b.account_ids && o.account_ids
+							AND (b.next_attempt_at > $1 OR b.locked_until > $1)
+					)
+				ORDER BY o.id
+				LIMIT $3
+			)
+			RETURNING id, event_id, event_type, account_ids, payload, attempts, next_attempt_at, created_at`,
+			now, lockedUntil, limit)
+		if err != nil {
+			return fmt.Errorf("failed to claim outbox data: %w", err)
+		}
+
+		events, err = scanOutboxEvents(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the sub-select
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+type outboxFailure struct {
+	ID       int64
+	Attempts int
+	Err      error
+}
+
+// relayEvents publishes events in order until deadline, when the claim on
+// them runs out. An event failing to publish blocks its accounts, later
+// events of a blocked account are skipped for this round.
+func relayEvents(ctx context.Context, events []OutboxEvent, deadline time.Time, publish OutboxPublishFunc) ([]int64, []outboxFailure) {
+	var (
+		sent    []int64
+		failed  []outboxFailure
+		blocked = make(map[int64]bool)
+	)
+
+	for _, e := range events {
+		if !time.Now().Before(deadline) {
+			break
+		}
+
+		held := false
+		for _, id := range e.AccountIDs {
+			held = held || blocked[id]
+		}
+
+		if !held {
+			err := publish(ctx, e)
+			if err == nil {
+				sent = append(sent, e.ID)
+				continue
+			}
+			failed = append(failed, outboxFailure{ID: e.ID, Attempts: e.Attempts + 1, Err: err})
+		}
+
+		for _, id := range e.AccountIDs {
+			blocked[id] = true
+		}
+	}
+
+	return sent, failed
+}
+
+func scanOutboxEvents(rows *sql.Rows) ([]OutboxEvent, error) {
+	defer rows.Close()
+
+	var result []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		err := rows.Scan(
+			&e.ID,
+			&e.EventID,
+			&e.Type,
+			pq.Array(&e.AccountIDs),
+			&e.Data,
+			&e.Attempts,
+			&e.NextAttemptAt,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query outbox data: %w", err)
+		}
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox data: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+func TestCustomerAccounts(t *testing.T) {
+	assert.Equal(t, []int64{3, 7}, customerAccounts([]int{7, -1, 3, 7, 0}))
+	assert.Equal(t, []int64{}, customerAccounts([]int{-2}))
+}
+
+func TestRelayEvents(t *testing.T) {
+	event := func(id int64, accounts ...int64) OutboxEvent {
+		return OutboxEvent{ID: id, AccountIDs: accounts}
+	}
+
+	tests := []struct {
+		name       string
+		events     []OutboxEvent
+		fail       map[int64]bool
+		expired    bool
+		wantSent   []int64
+		wantFailed []int64
+	}{
+		{
+			name:     "publishes in order",
+			events:   []OutboxEvent{event(1, 10), event(2, 20), event(3, 10, 20)},
+			wantSent: []int64{1, 2, 3},
+		},
+		{
+			name:       "failure holds back the account's later events",
+			events:     []OutboxEvent{event(1, 10), event(2, 20), event(3, 10, 20), event(4, 20), event(5, 30)},
+			fail:       map[int64]bool{1: true},
+			wantSent:   []int64{2, 5},
+			wantFailed: []int64{1},
+		},
+		{
+			name:    "lease ran out",
+			events:  []OutboxEvent{event(1, 10), event(2, 20)},
+			expired: true,
+		},
+		{
+			name:       "event without accounts holds back nothing",
+			events:     []OutboxEvent{event(1), event(2)},
+			fail:       map[int64]bool{1: true},
+			wantSent:   []int64{2},
+			wantFailed: []int64{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadline := time.Now().Add(time.Minute)
+			if tt.expired {
+				deadline = time.Now()
+			}
+
+			sent, failed := relayEvents(context.Background(), tt.events, deadline, func(ctx context.Context, e OutboxEvent) error {
+				if tt.fail[e.ID] {
+					return errors.New("unavailable")
+				}
+				return nil
+			})
+
+			assert.Equal(t, tt.wantSent, sent)
+
+			var failedIDs []int64
+			for _, f := range failed {
+				assert.Equal(t, 1, f.Attempts)
+				failedIDs = append(failedIDs, f.ID)
+			}
+			assert.Equal(t, tt.wantFailed, failedIDs)
+		})
+	}
+}
+
+func TestRelayOutboxPublishesAccountEventsInOrder(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	from := createTestAccount(t, repo, utils.NewDecimal(100))
+	to := createTestAccount(t, repo, utils.NewDecimal(1))
+
+	var refs []string
+	for i := 0; i < 3; i++ {
+		payload := newTestTransfer(from, to, utils.NewDecimal(10))
+		require.NoError(t, repo.SubmitTransaction(ctx, payload))
+		refs = append(refs, payload.Transaction.ReferenceNumber)
+	}
+
+	concerns := func(e OutboxEvent) bool {
+		for _, id := range e.AccountIDs {
+			if id == int64(from) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// the first transfer fails once and must not be overtaken
+	var (
+		got    []OutboxEvent
+		failed bool
+	)
+	publish := func(ctx context.Context, e OutboxEvent) error {
+		if !concerns(e) {
+			return nil
+		}
+		if e.Type == consts.EventTransferCompleted && !failed {
+			failed = true
+			return errors.New("unavailable")
+		}
+		got = append(got, e)
+		return nil
+	}
+	noBackoff := func(int) time.Duration { return 0 }
+
+	for i := 0; i < 100 && len(got) < 4; i++ {
+		_, err := repo.RelayOutbox(ctx, 50, time.Minute, noBackoff, publish)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, got, 4)
+	assert.Equal(t, consts.EventAccountCreated, got[0].Type)
+	for i, e := range got[1:] {
+		assert.Equal(t, consts.EventTransferCompleted, e.Type)
+		assert.ElementsMatch(t, []int64{int64(from), int64(to)}, e.AccountIDs)
+
+		var data struct {
+			Transaction struct {
+				ReferenceNumber string `json:"reference_number"`
+			} `json:"transaction"`
+			Entries []LedgerEntry `json:"entries"`
+		}
+		require.NoError(t, json.Unmarshal(e.Data, &data))
+		assert.Equal(t, refs[i], data.Transaction.ReferenceNumber)
+		assert.Len(t, data.Entries, 2)
+	}
+}
+
+func TestRelayOutboxSkipsAccountsWaitingForRetry(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	// the events of stuck keep failing and are older than those of other
+	stuck := createTestAccount(t, repo, utils.NewDecimal(1))
+	other := createTestAccount(t, repo, utils.NewDecimal(1))
+
+	concerns := func(e OutboxEvent, accountID int) bool {
+		for _, id := range e.AccountIDs {
+			if id == int64(accountID) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var got []OutboxEvent
+	publish := func(ctx context.Context, e OutboxEvent) error {
+		if concerns(e, stuck) {
+			return errors.New("unavailable")
+		}
+		if concerns(e, other) {
+			got = append(got, e)
+		}
+		return nil
+	}
+	hour := func(int) time.Duration { return time.Hour }
+
+	// one event per round: the failed one waits for its retry and must not
+	// take the place of the others
+	for i := 0; i < 1000 && len(got) == 0; i++ {
+		_, err := repo.RelayOutbox(ctx, 1, time.Minute, hour, publish)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, got, 1)
+	assert.Equal(t, consts.EventAccountCreated, got[0].Type)
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+)
+
+const scheduleColumns = `id,
+					source_account_id,
+					destination_account_id,
+					amount,
+					COALESCE(description, ''),
+					frequency,
+					start_at,
+					end_at,
+					max_occurrences,
+					occurrences,
+					next_run_at,
+					status,
+					attempts,
+					COALESCE(last_error, ''),
+					last_transaction_id,
+					last_run_at,
+					COALESCE(created_by, ''),
+					COALESCE(created_by_role, ''),
+					created_at,
+					updated_at`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanScheduledTransfer(row rowScanner) (ScheduledTransfer, error) {
+	var result ScheduledTransfer
+	err := row.Scan(
+		&result.ID,
+		&result.SourceAccountID,
+		&result.DestinationAccountID,
+		&result.Amount,
+		&result.Description,
+		&result.Frequency,
+		&result.StartAt,
+		&result.EndAt,
+		&result.MaxOccurrences,
+		&result.Occurrences,
+		&result.NextRunAt,
+		&result.Status,
+		&result.Attempts,
+		&result.LastError,
+		&result.LastTransactionID,
+		&result.LastRunAt,
+		&result.CreatedBy,
+		&result.CreatedByRole,
+		&result.CreatedAt,
+		&result.UpdatedAt,
+	)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return ScheduledTransfer{}, nil
+	}
+
+	if err != nil {
+		return ScheduledTransfer{}, fmt.Errorf("failed to query scheduled_transfers data: %w", err)
+	}
+
+	return result, nil
+}
+
+func scanScheduledTransfers(rows *sql.Rows) ([]ScheduledTransfer, error) {
+	defer rows.Close()
+
+	var result []ScheduledTransfer
+	for rows.Next() {
+		st, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read scheduled_transfers data: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *walletRepo) CreateScheduledTransfer(ctx context.Context, st ScheduledTransfer) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO scheduled_transfers (id,
+							source_account_id,
+							destination_account_id,
+							amount,
+							description,
+							frequency,
+							start_at,
+							end_at,
+							max_occurrences,
+							next_run_at,
+							status,
+							created_by,
+							created_by_role,
+							created_at,
+							updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), NULLIF($13, ''), $14, $14)`,
+		st.ID,
+		st.SourceAccountID,
+		st.DestinationAccountID,
+		st.Amount,
+		st.Description,
+		st.Frequency,
+		st.StartAt,
+		st.EndAt,
+		st.MaxOccurrences,
+		st.NextRunAt,
+		st.Status,
+		st.CreatedBy,
+		st.CreatedByRole,
+		st.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert scheduled_transfers data: %w", err)
+	}
+
+	return nil
+}
+
+func (r *walletRepo) GetScheduledTransfer(ctx context.Context, id string) (ScheduledTransfer, error) {
+	return scanScheduledTransfer(r.db.QueryRow(ctx, `SELECT `+scheduleColumns+` FROM scheduled_transfers WHERE id = $1`, id))
+}
+
+func (r *walletRepo) ListScheduledTransfers(ctx context.Context, filter ScheduledTransferFilter) ([]ScheduledTransfer, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.AccountID != 0 {
+		where = append(where, "source_account_id = "+arg(filter.AccountID))
+	}
+
+	if filter.Status != "" {
+		where = append(where, "status = "+arg(filter.Status))
+	}
+
+	query := `SELECT ` + scheduleColumns + ` FROM scheduled_transfers`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ` + arg(filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled_transfers data: %w", err)
+	}
+
+	return scanScheduledTransfers(rows)
+}
+
+// UpdateScheduledTransfer applies update to an active schedule and returns
+// it. A schedule whose next occurrence falls outside the new end date or
+// count is completed right away.
+func (r *walletRepo) UpdateScheduledTransfer(ctx context.Context, update ScheduledTransferUpdate) (ScheduledTransfer, error) {
+	st, err := scanScheduledTransfer(r.db.QueryRow(ctx, `UPDATE scheduled_transfers SET
+			amount = $2,
+			description = $3,
+			end_at = $4,
+			max_occurrences = $5,
+			status = CASE
+				WHEN ($4::timestamp IS NOT NULL AND next_run_at > $4::timestamp)
+					OR ($5::int IS NOT NULL AND occurrences >= $5::int) THEN $6
+				ELSE status END,
+			updated_at = $7
+		WHERE id = $1 AND status = $8
+		RETURNING `+scheduleColumns,
+		update.ID,
+		update.Amount,
+		update.Description,
+		update.EndAt,
+		update.MaxOccurrences,
+		consts.ScheduleStatusCompleted,
+		time.Now(),
+		consts.ScheduleStatusActive,
+	))
+	if err != nil {
+		return ScheduledTransfer{}, err
+	}
+
+	if st.ID == "" {
+		return ScheduledTransfer{}, ErrScheduleNotActive
+	}
+
+	return st, nil
+}
+
+// CancelScheduledTransfer stops an active schedule and returns it. A run
+// already in progress still completes.
+func (r *walletRepo) CancelScheduledTransfer(ctx context.Context, id string) (ScheduledTransfer, error) {
+	st, err := scanScheduledTransfer(r.db.QueryRow(ctx, `UPDATE scheduled_transfers SET status = $2, updated_at = $3
+		WHERE id = $1 AND status = $4
+		RETURNING `+scheduleColumns,
+		id, consts.ScheduleStatusCancelled, time.Now(), consts.ScheduleStatusActive))
+	if err != nil {
+		return ScheduledTransfer{}, err
+	}
+
+	if st.ID == "" {
+		return ScheduledTransfer{}, ErrScheduleNotActive
+	}
+
+	return st, nil
+}
+
+// ClaimScheduledTransfers leases up to limit active schedules due at now to
+// the caller until lockedUntil and returns them, earliest first. Rows being
+// claimed by a concurrent worker are skipped, so any number of workers can
+// poll the same table. A lease that runs out before the run is recorded, e.g.
+// because the worker died, makes the schedule claimable again.
+func (r *walletRepo) ClaimScheduledTransfers(ctx context.Context, now, lockedUntil time.Time, limit int) ([]ScheduledTransfer, error) {
+	rows, err := r.db.Query(ctx, `UPDATE scheduled_transfers SET locked_until = $2, updated_at = $1
+		WHERE id IN (
+			SELECT id FROM scheduled_transfers
+			WHERE status = $3 AND next_run_at <= $1 AND (locked_until IS NULL OR locked_until <= $1)
+			ORDER BY next_run_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+scheduleColumns,
+		now, lockedUntil, consts.ScheduleStatusActive, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim scheduled_transfers data: %w", err)
+	}
+
+	claimed, err := scanScheduledTransfers(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the sub-select
+	sort.Slice(claimed, func(i, j int) bool {
+		return claimed[i].NextRunAt.Before(claimed[j].NextRunAt)
+	})
+
+	return claimed, nil
+}
+
+// RecordScheduledRun stores the outcome of a claimed run and releases the
+// claim. It reports false, leaving the schedule untouched, when the schedule
+// has moved past run.Occurrence since it was claimed.
+func (r *walletRepo) RecordScheduledRun(ctx context.Context, run ScheduledRun) (bool, error) {
+	res, err := r.db.Exec(ctx, `UPDATE scheduled_transfers SET
+			occurrences = occurrences + CASE WHEN $3 THEN 1 ELSE 0 END,
+			next_run_at = $4,
+			status = CASE WHEN status = $11 THEN $5 ELSE status END,
+			attempts = $6,
+			last_transaction_id = COALESCE(NULLIF($7, '')::uuid, last_transaction_id),
+			last_error = NULLIF($8, ''),
+			last_run_at = $9,
+			locked_until = NULL,
+			updated_at = $10
+		WHERE id = $1 AND occurrences = $2`,
+		run.ID,
+		run.Occurrence,
+		run.Advance,
+		run.NextRunAt,
+		run.Status,
+		run.Attempts,
+		run.TransactionID,
+		run.Error,
+		run.RunAt,
+		time.Now(),
+		consts.ScheduleStatusActive,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update scheduled_transfers data: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update scheduled_transfers data: %w", err)
+	}
+
+	return n > 0, nil
+}
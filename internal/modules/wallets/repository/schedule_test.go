@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+func newTestSchedule(from, to int, nextRunAt time.Time) ScheduledTransfer {
+	return ScheduledTransfer{
+		ID:                   uuid.NewString(),
+		SourceAccountID:      from,
+		DestinationAccountID: to,
+		Amount:               utils.NewDecimal(10),
+		Frequency:            consts.ScheduleFrequencyDaily,
+		StartAt:              nextRunAt,
+		NextRunAt:            nextRunAt,
+		Status:               consts.ScheduleStatusActive,
+		CreatedAt:            time.Now(),
+	}
+}
+
+func TestClaimScheduledTransfersOnce(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	from := createTestAccount(t, repo, utils.NewDecimal(100))
+	to := createTestAccount(t, repo, utils.NewDecimal(1))
+
+	ids := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		st := newTestSchedule(from, to, time.Now().Add(-time.Minute))
+		require.NoError(t, repo.CreateScheduledTransfer(ctx, st))
+		ids[st.ID] = true
+	}
+
+	// concurrent workers never claim the same schedule
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		claimed = make(map[string]int)
+	)
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				now := time.Now()
+				due, err := repo.ClaimScheduledTransfers(ctx, now, now.Add(time.Minute), 3)
+				if !assert.NoError(t, err) || len(due) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for _, st := range due {
+					claimed[st.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for id := range ids {
+		assert.Equal(t, 1, claimed[id], id)
+	}
+
+	// an expired lease makes the schedules claimable again
+	now := time.Now().Add(2 * time.Minute)
+	due, err := repo.ClaimScheduledTransfers(ctx, now, now.Add(time.Minute), 1000)
+	require.NoError(t, err)
+
+	reclaimed := 0
+	for _, st := range due {
+		if ids[st.ID] {
+			reclaimed++
+		}
+	}
+	assert.Equal(t, len(ids), reclaimed)
+}
+
+func TestScheduledTransferLifecycle(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	from := createTestAccount(t, repo, utils.NewDecimal(100))
+	to := createTestAccount(t, repo, utils.NewDecimal(1))
+
+	st := newTestSchedule(from, to, time.Now().Add(time.Hour))
+	st.CreatedBy, st.CreatedByRole = uuid.NewString(), consts.RoleCustomer
+	require.NoError(t, repo.CreateScheduledTransfer(ctx, st))
+
+	list, err := repo.ListScheduledTransfers(ctx, ScheduledTransferFilter{AccountID: from, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, st.ID, list[0].ID)
+	assert.Equal(t, st.CreatedBy, list[0].CreatedBy)
+	assert.Equal(t, consts.RoleCustomer, list[0].CreatedByRole)
+
+	// the first occurrence runs and the schedule moves on
+	ok, err := repo.RecordScheduledRun(ctx, ScheduledRun{
+		ID:         st.ID,
+		Occurrence: 0,
+		Advance:    true,
+		NextRunAt:  st.NextRunAt.AddDate(0, 0, 1),
+		Status:     consts.ScheduleStatusActive,
+		RunAt:      time.Now(),
+	})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// a stale worker recording the same occurrence again is ignored
+	ok, err = repo.RecordScheduledRun(ctx, ScheduledRun{ID: st.ID, Occurrence: 0, Advance: true, Status: consts.ScheduleStatusActive, RunAt: time.Now()})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// a count already reached completes the schedule
+	updated, err := repo.UpdateScheduledTransfer(ctx, ScheduledTransferUpdate{
+		ID:             st.ID,
+		Amount:         utils.NewDecimal(20),
+		MaxOccurrences: sql.NullInt64{Int64: 1, Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated.Occurrences)
+	assert.Equal(t, "20", updated.Amount.String())
+	assert.Equal(t, consts.ScheduleStatusCompleted, updated.Status)
+
+	_, err = repo.CancelScheduledTransfer(ctx, st.ID)
+	assert.ErrorIs(t, err, ErrScheduleNotActive)
+}
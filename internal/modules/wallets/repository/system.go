@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+)
+
+// systemAccount returns the account_id of the system account with the given
+// code in currency, creating it on first use. System accounts get negative
+// account_ids from system_account_id_seq.
+func systemAccount(ctx context.Context, repo *db.Repository, code, currency string) (int, error) {
+	var accountID int
+	err := repo.QueryRow(ctx, "SELECT account_id FROM accounts WHERE system_code = $1 AND currency = $2", code, currency).Scan(&accountID)
+	if err == nil {
+		return accountID, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to query accounts data: %w", err)
+	}
+
+	// a concurrent first use may insert the same account, the loser of the
+	// race reads the winner's row
+	err = repo.QueryRow(ctx, `INSERT INTO accounts (account_id, currency, balance, system_code)
+		VALUES (-nextval('system_account_id_seq'), $1, 0, $2)
+		ON CONFLICT (system_code, currency) WHERE system_code IS NOT NULL DO NOTHING
+		RETURNING account_id`, currency, code).Scan(&accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = repo.QueryRow(ctx, "SELECT account_id FROM accounts WHERE system_code = $1 AND currency = $2", code, currency).Scan(&accountID)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert accounts data: %w", err)
+	}
+
+	return accountID, nil
+}
+
+// resolveFXAccounts fills in the FX account of both currencies on the FX
+// ledger entries. The accounts are resolved in currency order so two first
+// uses of the same pair cannot deadlock on each other's inserts.
+func resolveFXAccounts(ctx context.Context, repo *db.Repository, fx *FXTransfer) error {
+	entries := map[string]*LedgerEntry{
+		fx.SourceCurrency:      &fx.LedgerEntrySource,
+		fx.DestinationCurrency: &fx.LedgerEntryDestination,
+	}
+
+	currencies := []string{fx.SourceCurrency, fx.DestinationCurrency}
+	if currencies[1] < currencies[0] {
+		currencies[0], currencies[1] = currencies[1], currencies[0]
+	}
+
+	for _, currency := range currencies {
+		accountID, err := systemAccount(ctx, repo, consts.SystemAccountFX, currency)
+		if err != nil {
+			return err
+		}
+		entries[currency].AccountID = accountID
+	}
+
+	return nil
+}
+
+// resolveFeeAccount fills in the fees account of the fee's currency on the
+// revenue ledger entry.
+func resolveFeeAccount(ctx context.Context, repo *db.Repository, fee *FeeTransfer) error {
+	accountID, err := systemAccount(ctx, repo, consts.SystemAccountFees, fee.Currency)
+	if err != nil {
+		return err
+	}
+
+	fee.LedgerEntryRevenue.AccountID = accountID
+	return nil
+}
+
+// resolveFundingAccount fills in the funding account of the currency of
+// accountID on entry, the other side of a deposit to or a withdrawal from
+// that account.
+func resolveFundingAccount(ctx context.Context, repo *db.Repository, accountID int, entry *LedgerEntry) error {
+	var currency string
+	err := repo.QueryRow(ctx, "SELECT currency FROM accounts WHERE account_id = $1", accountID).Scan(&currency)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return ErrDataNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to query accounts data: %w", err)
+	}
+
+	fundingID, err := systemAccount(ctx, repo, consts.SystemAccountFunding, currency)
+	if err != nil {
+		return err
+	}
+
+	entry.AccountID = fundingID
+	return nil
+}
+
+// resolveFeeRefundAccount fills in the fees account of the refund's currency
+// on the revenue ledger entry.
+func resolveFeeRefundAccount(ctx context.Context, repo *db.Repository, refund *FeeRefund) error {
+	accountID, err := systemAccount(ctx, repo, consts.SystemAccountFees, refund.Currency)
+	if err != nil {
+		return err
+	}
+
+	refund.LedgerEntryRevenue.AccountID = accountID
+	return nil
+}
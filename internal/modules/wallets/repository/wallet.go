@@ -5,121 +5,882 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
 	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
 )
 
 type walletRepo struct {
 	db *db.Repository
+
+	// limits applies to outgoing movements of accounts without overrides.
+	limits Limits
+}
+
+// Option customises the wallet repository.
+type Option func(*walletRepo)
+
+// WithLimits sets the limits outgoing movements are checked against unless
+// the account overrides them.
+func WithLimits(limits Limits) Option {
+	return func(r *walletRepo) {
+		r.limits = limits
+	}
 }
 
-func NewWalletRepository(db *db.Repository) WalletRepository {
-	return &walletRepo{
-		db: db,
+func NewWalletRepository(db *db.Repository, opts ...Option) WalletRepository {
+	r := &walletRepo{
+		db:     db,
+		limits: DefaultLimits,
 	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 func (r *walletRepo) CreateAccount(ctx context.Context, payload DepositPayload) error {
 	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
 		_, err := repo.Exec(ctx,
-			"INSERT INTO accounts (account_id, currency, balance) VALUES ($1, $2, $3)",
-			payload.Account.AccountID, "USD", payload.Account.Balance,
+			"INSERT INTO accounts (account_id, currency, balance, customer_id) VALUES ($1, $2, $3, NULLIF($4, '')::uuid)",
+			payload.Account.AccountID, payload.Account.Currency, payload.Account.Balance, payload.Account.CustomerID,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert accounts data: %w", err)
 		}
 
-		_, err = repo.Exec(ctx, `INSERT INTO transactions(id,
+		if err := insertTransaction(ctx, repo, payload.Transaction); err != nil {
+			return err
+		}
+
+		// an account opened empty has nothing to fund
+		if payload.Account.Balance.IsZero() {
+			return insertAccountEvent(ctx, repo, payload, payload.LedgerEntry)
+		}
+
+		if err := resolveFundingAccount(ctx, repo, payload.Account.AccountID, &payload.LedgerEntryFunding); err != nil {
+			return err
+		}
+
+		// the new row is not visible to anyone else yet, locking it with the
+		// funding account keeps the same order as Deposit
+		_, err = lockAccounts(ctx, repo, payload.Account.AccountID, payload.LedgerEntryFunding.AccountID)
+		if err != nil {
+			return err
+		}
+
+		payload.LedgerEntryFunding.BalanceBefore, payload.LedgerEntryFunding.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.LedgerEntryFunding.AccountID, payload.Account.Balance.Neg())
+		if err != nil {
+			return err
+		}
+
+		return insertAccountEvent(ctx, repo, payload, payload.LedgerEntry, payload.LedgerEntryFunding)
+	})
+}
+
+// insertAccountEvent writes the opening entries of a new account and its
+// account.created event.
+func insertAccountEvent(ctx context.Context, repo *db.Repository, payload DepositPayload, entries ...LedgerEntry) error {
+	if err := insertLedgerEntries(ctx, repo, entries...); err != nil {
+		return err
+	}
+
+	return insertEvent(ctx, repo, consts.EventAccountCreated, transactionEvent{
+		Account: &eventAccount{
+			AccountID: payload.Account.AccountID,
+			Currency:  payload.Account.Currency,
+			Status:    consts.AccountStatusActive,
+			Balance:   payload.Account.Balance,
+			CreatedAt: payload.Transaction.CreatedAt,
+		},
+		Transaction: toEventTransaction(payload.Transaction),
+		Entries:     entries,
+	}, payload.Account.AccountID)
+}
+
+func (r *walletRepo) GetAccount(ctx context.Context, accountID int) (Account, error) {
+	var result Account
+	err := r.db.QueryRow(ctx, "SELECT id, account_id, currency, status, balance, held_amount, COALESCE(customer_id::text, ''), created_at, updated_at FROM accounts WHERE account_id = $1", accountID).Scan(&result.ID, &result.AccountID, &result.Currency, &result.Status, &result.Balance, &result.HeldAmount, &result.CustomerID, &result.CreatedAt, &result.UpdatedAt)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return result, nil
+	}
+
+	if err != nil {
+		return result, fmt.Errorf("failed to query data: %w", err)
+	}
+
+	return result, nil
+}
+
+const transactionColumns = `id,
 							reference_number,
 							type,
+							COALESCE(description, ''),
 							from_account_id,
 							to_account_id,
 							amount,
-							description,
 							status,
-							created_at
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-			payload.Transaction.ID,
-			payload.Transaction.ReferenceNumber,
-			payload.Transaction.Type,
-			payload.Transaction.FromAccountID,
-			payload.Transaction.ToAccountID,
-			payload.Transaction.Amount,
-			payload.Transaction.Description,
-			payload.Transaction.Status,
-			payload.Transaction.CreatedAt,
+							created_at,
+							COALESCE(request_fingerprint, ''),
+							original_transaction_id,
+							metadata`
+
+func scanTransaction(row *sql.Row) (Transaction, error) {
+	var result Transaction
+	err := row.Scan(
+		&result.ID,
+		&result.ReferenceNumber,
+		&result.Type,
+		&result.Description,
+		&result.FromAccountID,
+		&result.ToAccountID,
+		&result.Amount,
+		&result.Status,
+		&result.CreatedAt,
+		&result.RequestFingerprint,
+		&result.OriginalTransactionID,
+		(*[]byte)(&result.Metadata),
+	)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return Transaction{}, nil
+	}
+
+	if err != nil {
+		return Transaction{}, fmt.Errorf("failed to query data: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *walletRepo) GetTransaction(ctx context.Context, id string) (Transaction, error) {
+	return scanTransaction(r.db.QueryRow(ctx, `SELECT `+transactionColumns+`
+		FROM transactions WHERE id = $1`, id))
+}
+
+func (r *walletRepo) GetTransactionByReference(ctx context.Context, referenceNumber string) (Transaction, error) {
+	return scanTransaction(r.db.QueryRow(ctx, `SELECT `+transactionColumns+`
+		FROM transactions WHERE reference_number = $1`, referenceNumber))
+}
+
+func (r *walletRepo) GetTransactionLedgerEntries(ctx context.Context, transactionID string) ([]LedgerEntry, error) {
+	rows, err := r.db.Query(ctx, `SELECT id,
+				transaction_id,
+				account_id,
+				entry_type,
+				amount,
+				balance_before,
+				balance_after,
+				COALESCE(description, ''),
+				created_at
+		FROM ledger_entries WHERE transaction_id = $1
+		ORDER BY entry_type DESC, account_id`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger_entries data: %w", err)
+	}
+	defer rows.Close()
+
+	var result []LedgerEntry
+	for rows.Next() {
+		var e LedgerEntry
+		err := rows.Scan(
+			&e.ID,
+			&e.TransactionID,
+			&e.AccountID,
+			&e.EntryType,
+			&e.Amount,
+			&e.BalanceBefore,
+			&e.BalanceAfter,
+			&e.Description,
+			&e.CreatedAt,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert transactions data: %w", err)
+			return nil, fmt.Errorf("failed to scan ledger_entries data: %w", err)
 		}
+		result = append(result, e)
+	}
 
-		_, err = repo.Exec(
-			ctx, ` INSERT INTO ledger_entries 
-				(
-					id,
-					transaction_id,
-					account_id,
-					entry_type,
-					amount,
-					balance_before,
-					balance_after,
-					description,
-					created_at
-				)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-			payload.LedgerEntry.ID,
-			payload.LedgerEntry.TransactionID,
-			payload.LedgerEntry.AccountID,
-			payload.LedgerEntry.EntryType,
-			payload.LedgerEntry.Amount,
-			payload.LedgerEntry.BalanceBefore,
-			payload.LedgerEntry.BalanceAfter,
-			payload.LedgerEntry.Description,
-			payload.LedgerEntry.CreatedAt,
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger_entries data: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *walletRepo) ListLedgerEntries(ctx context.Context, filter LedgerFilter) ([]HistoryEntry, error) {
+	where := []string{"le.account_id = $1"}
+	args := []interface{}{filter.AccountID}
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(filter.Types) > 0 {
+		types := make([]string, 0, len(filter.Types))
+		for _, t := range filter.Types {
+			types = append(types, string(t))
+		}
+		where = append(where, "t.type = ANY("+arg(pq.Array(types))+")")
+	}
+
+	if !filter.From.IsZero() {
+		where = append(where, "le.created_at >= "+arg(filter.From))
+	}
+
+	if !filter.To.IsZero() {
+		where = append(where, "le.created_at < "+arg(filter.To))
+	}
+
+	if filter.After != nil {
+		where = append(where, fmt.Sprintf("(le.created_at, le.id) < (%s, %s)", arg(filter.After.CreatedAt), arg(filter.After.ID)))
+	}
+
+	query := `SELECT le.id,
+				le.transaction_id,
+				le.account_id,
+				le.entry_type,
+				le.amount,
+				le.balance_before,
+				le.balance_after,
+				COALESCE(le.description, ''),
+				le.created_at,
+				t.type,
+				t.reference_number,
+				CASE WHEN le.entry_type = 'debit' THEN t.to_account_id ELSE t.from_account_id END
+		FROM ledger_entries le
+		JOIN transactions t ON t.id = le.transaction_id
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY le.created_at DESC, le.id DESC
+		LIMIT ` + arg(filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger_entries data: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]HistoryEntry, 0, filter.Limit)
+	for rows.Next() {
+		var e HistoryEntry
+		err := rows.Scan(
+			&e.ID,
+			&e.TransactionID,
+			&e.AccountID,
+			&e.EntryType,
+			&e.Amount,
+			&e.BalanceBefore,
+			&e.BalanceAfter,
+			&e.Description,
+			&e.CreatedAt,
+			&e.TransactionType,
+			&e.ReferenceNumber,
+			&e.CounterpartyAccountID,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert ledger_entries data: %w", err)
+			return nil, fmt.Errorf("failed to scan ledger_entries data: %w", err)
+		}
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger_entries data: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *walletRepo) SubmitTransaction(ctx context.Context, payload TransactionPayload) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+		return r.transfer(ctx, repo, payload)
+	})
+}
+
+// SubmitTransactions books all payloads in one DB transaction or none of
+// them. Every account of the batch, system accounts included, is locked in
+// ascending account_id order up front, so batches sharing accounts queue
+// instead of deadlocking. A failing payload is reported as a
+// *BatchItemError.
+func (r *walletRepo) SubmitTransactions(ctx context.Context, payloads []TransactionPayload) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+		ids := make([]int, 0, 2*len(payloads))
+		for i := range payloads {
+			accountIDs, err := transferAccounts(ctx, repo, &payloads[i])
+			if err != nil {
+				return &BatchItemError{Index: i, Err: err}
+			}
+			ids = append(ids, accountIDs...)
+		}
+
+		if _, err := lockAccounts(ctx, repo, ids...); err != nil {
+			return err
+		}
+
+		for i, payload := range payloads {
+			if err := r.transfer(ctx, repo, payload); err != nil {
+				return &BatchItemError{Index: i, Err: err}
+			}
 		}
 
 		return nil
 	})
 }
 
-func (r *walletRepo) GetAccount(ctx context.Context, accountID int) (Account, error) {
-	var result Account
-	err := r.db.QueryRow(ctx, "SELECT id, account_id, status, balance, created_at, updated_at FROM accounts WHERE account_id = $1", accountID).Scan(&result.ID, &result.AccountID, &result.Status, &result.Balance, &result.CreatedAt, &result.UpdatedAt)
-	if err != nil && errors.Is(err, sql.ErrNoRows) {
-		return result, nil
+// transferAccounts resolves the system accounts payload books against and
+// returns every account it touches.
+func transferAccounts(ctx context.Context, repo *db.Repository, payload *TransactionPayload) ([]int, error) {
+	ids := []int{payload.From.AccountID, payload.To.AccountID}
+	if payload.FX != nil {
+		if err := resolveFXAccounts(ctx, repo, payload.FX); err != nil {
+			return nil, err
+		}
+		ids = append(ids, payload.FX.LedgerEntrySource.AccountID, payload.FX.LedgerEntryDestination.AccountID)
+	}
+
+	if payload.Fee != nil {
+		if err := resolveFeeAccount(ctx, repo, payload.Fee); err != nil {
+			return nil, err
+		}
+		ids = append(ids, payload.Fee.LedgerEntryRevenue.AccountID)
 	}
 
+	return ids, nil
+}
+
+// transfer books payload inside the caller's DB transaction: all accounts
+// are locked, their currencies, the sender's available balance and limits
+// checked, the balances moved and the ledger entries written.
+func (r *walletRepo) transfer(ctx context.Context, repo *db.Repository, payload TransactionPayload) error {
+	ids, err := transferAccounts(ctx, repo, &payload)
 	if err != nil {
-		return result, fmt.Errorf("failed to query data: %w", err)
+		return err
 	}
 
-	return result, nil
+	accounts, err := lockAccounts(ctx, repo, ids...)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTransferCurrencies(accounts, payload); err != nil {
+		return err
+	}
+
+	fee := feeAmount(payload.Fee)
+	debited, err := payload.Amount.CheckedAdd(fee)
+	if err != nil {
+		return err
+	}
+
+	if availableBalance(accounts[payload.From.AccountID]).Cmp(debited) < 0 {
+		return ErrInsufficientBalance
+	}
+
+	if err := r.checkOutgoingLimits(ctx, repo, payload.From.AccountID, payload.Amount, fee); err != nil {
+		return err
+	}
+
+	return bookTransfer(ctx, repo, payload)
 }
 
-func (r *walletRepo) SubmitTransaction(ctx context.Context, payload TransactionPayload) error {
+// checkTransferCurrencies requires both sides of a plain transfer to share a
+// currency and both sides of an FX transfer to hold the currencies it was
+// priced for.
+func checkTransferCurrencies(accounts map[int]Account, payload TransactionPayload) error {
+	if err := checkFeeCurrency(accounts[payload.From.AccountID], payload.Fee); err != nil {
+		return err
+	}
+
+	if payload.FX == nil {
+		return checkSameCurrency(accounts)
+	}
+
+	if accounts[payload.From.AccountID].Currency != payload.FX.SourceCurrency ||
+		accounts[payload.To.AccountID].Currency != payload.FX.DestinationCurrency {
+		return ErrCurrencyMismatch
+	}
+
+	return nil
+}
+
+// checkFeeCurrency requires a fee to be charged in the payer's currency.
+func checkFeeCurrency(payer Account, fee *FeeTransfer) error {
+	if fee != nil && payer.Currency != fee.Currency {
+		return ErrCurrencyMismatch
+	}
+
+	return nil
+}
+
+func feeAmount(fee *FeeTransfer) utils.Decimal {
+	if fee == nil {
+		return utils.Decimal{}
+	}
+
+	return fee.Amount
+}
+
+// bookFee moves the fee from the payer to the fees account and returns its
+// two ledger entries. Callers must hold both account locks.
+func bookFee(ctx context.Context, repo *db.Repository, payerID int, fee *FeeTransfer) ([]LedgerEntry, error) {
+	var err error
+	fee.LedgerEntryPayer.BalanceBefore, fee.LedgerEntryPayer.BalanceAfter, err = adjustAccountBalance(ctx, repo, payerID, fee.Amount.Neg())
+	if err != nil {
+		return nil, err
+	}
+
+	fee.LedgerEntryRevenue.BalanceBefore, fee.LedgerEntryRevenue.BalanceAfter, err = adjustAccountBalance(ctx, repo, fee.LedgerEntryRevenue.AccountID, fee.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return []LedgerEntry{fee.LedgerEntryPayer, fee.LedgerEntryRevenue}, nil
+}
+
+// bookTransfer writes the transaction, moves the balances and writes the
+// ledger entries. Callers must hold all account locks and have done their
+// own checks.
+func bookTransfer(ctx context.Context, repo *db.Repository, payload TransactionPayload) error {
+	if err := insertTransaction(ctx, repo, payload.Transaction); err != nil {
+		return err
+	}
+
+	var err error
+	payload.LedgerEntryFrom.BalanceBefore, payload.LedgerEntryFrom.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.From.AccountID, payload.Amount.Neg())
+	if err != nil {
+		return err
+	}
+
+	received := payload.Amount
+	entries := []LedgerEntry{}
+	if fx := payload.FX; fx != nil {
+		received = fx.DestinationAmount
+
+		fx.LedgerEntrySource.BalanceBefore, fx.LedgerEntrySource.BalanceAfter, err = adjustAccountBalance(ctx, repo, fx.LedgerEntrySource.AccountID, payload.Amount)
+		if err != nil {
+			return err
+		}
+
+		fx.LedgerEntryDestination.BalanceBefore, fx.LedgerEntryDestination.BalanceAfter, err = adjustAccountBalance(ctx, repo, fx.LedgerEntryDestination.AccountID, received.Neg())
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, fx.LedgerEntrySource, fx.LedgerEntryDestination)
+	}
+
+	payload.LedgerEntryTo.BalanceBefore, payload.LedgerEntryTo.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.To.AccountID, received)
+	if err != nil {
+		return err
+	}
+
+	if payload.Fee != nil {
+		feeEntries, err := bookFee(ctx, repo, payload.From.AccountID, payload.Fee)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, feeEntries...)
+	}
+
+	entries = append([]LedgerEntry{payload.LedgerEntryFrom, payload.LedgerEntryTo}, entries...)
+	if err := insertLedgerEntries(ctx, repo, entries...); err != nil {
+		return err
+	}
+
+	return insertTransactionEvent(ctx, repo, consts.EventTransferCompleted, payload.Transaction, entries)
+}
+
+func (r *walletRepo) Withdraw(ctx context.Context, payload WithdrawPayload) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+
+		if err := resolveFundingAccount(ctx, repo, payload.AccountID, &payload.LedgerEntryFunding); err != nil {
+			return err
+		}
+
+		ids := []int{payload.AccountID, payload.LedgerEntryFunding.AccountID}
+		if payload.Fee != nil {
+			if err := resolveFeeAccount(ctx, repo, payload.Fee); err != nil {
+				return err
+			}
+			ids = append(ids, payload.Fee.LedgerEntryRevenue.AccountID)
+		}
+
+		accounts, err := lockAccounts(ctx, repo, ids...)
+		if err != nil {
+			return err
+		}
+
+		payer := accounts[payload.AccountID]
+		if err := checkFeeCurrency(payer, payload.Fee); err != nil {
+			return err
+		}
+
+		fee := feeAmount(payload.Fee)
+		debited, err := payload.Amount.CheckedAdd(fee)
+		if err != nil {
+			return err
+		}
+
+		if availableBalance(payer).Cmp(debited) < 0 {
+			return ErrInsufficientBalance
+		}
+
+		if err := r.checkOutgoingLimits(ctx, repo, payload.AccountID, payload.Amount, fee); err != nil {
+			return err
+		}
+
+		if err := insertTransaction(ctx, repo, payload.Transaction); err != nil {
+			return err
+		}
+
+		payload.LedgerEntry.BalanceBefore, payload.LedgerEntry.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.AccountID, payload.Amount.Neg())
+		if err != nil {
+			return err
+		}
+
+		payload.LedgerEntryFunding.BalanceBefore, payload.LedgerEntryFunding.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.LedgerEntryFunding.AccountID, payload.Amount)
+		if err != nil {
+			return err
+		}
+
+		entries := []LedgerEntry{payload.LedgerEntry, payload.LedgerEntryFunding}
+		if payload.Fee != nil {
+			feeEntries, err := bookFee(ctx, repo, payload.AccountID, payload.Fee)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, feeEntries...)
+		}
+
+		if err := insertLedgerEntries(ctx, repo, entries...); err != nil {
+			return err
+		}
+
+		return insertTransactionEvent(ctx, repo, consts.EventWithdrawalCompleted, payload.Transaction, entries)
+	})
+}
+
+func (r *walletRepo) Deposit(ctx context.Context, payload TopUpPayload) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+
+		if err := resolveFundingAccount(ctx, repo, payload.AccountID, &payload.LedgerEntryFunding); err != nil {
+			return err
+		}
+
+		_, err := lockAccounts(ctx, repo, payload.AccountID, payload.LedgerEntryFunding.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if err := insertTransaction(ctx, repo, payload.Transaction); err != nil {
+			return err
+		}
+
+		payload.LedgerEntryFunding.BalanceBefore, payload.LedgerEntryFunding.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.LedgerEntryFunding.AccountID, payload.Amount.Neg())
+		if err != nil {
+			return err
+		}
+
+		payload.LedgerEntry.BalanceBefore, payload.LedgerEntry.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.AccountID, payload.Amount)
+		if err != nil {
+			return err
+		}
+
+		entries := []LedgerEntry{payload.LedgerEntry, payload.LedgerEntryFunding}
+		if err := insertLedgerEntries(ctx, repo, entries...); err != nil {
+			return err
+		}
+
+		return insertTransactionEvent(ctx, repo, consts.EventDepositCompleted, payload.Transaction, entries)
+	})
+}
+
+func (r *walletRepo) GetReversedAmount(ctx context.Context, transactionID string) (utils.Decimal, error) {
+	return reversedAmount(ctx, r.db, transactionID)
+}
+
+func (r *walletRepo) ReverseTransaction(ctx context.Context, payload ReversalPayload) error {
 	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
 
-		var idFrom int
-		err := repo.QueryRow(ctx, "SELECT id from accounts WHERE account_id = $1 FOR UPDATE", payload.From.AccountID).Scan(&idFrom)
+		originalID := payload.Transaction.OriginalTransactionID.String
+
+		// concurrent reversals of the same transfer queue on the original row
+		var (
+			originalType   consts.TransactionType
+			originalAmount utils.Decimal
+			converted      bool
+		)
+		err := repo.QueryRow(ctx, "SELECT type, amount, COALESCE(metadata ? 'fx', false) FROM transactions WHERE id = $1 FOR UPDATE", originalID).Scan(&originalType, &originalAmount, &converted)
+		if err != nil && errors.Is(err, sql.ErrNoRows) {
+			return ErrNotReversible
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to query transactions data: %w", err)
+		}
+
+		// a cross-currency transfer cannot be mirrored with a single amount
+		if originalType != consts.TransactionTypeTransfer || converted {
+			return ErrNotReversible
+		}
+
+		reversed, err := reversedAmount(ctx, repo, originalID)
 		if err != nil {
-			return fmt.Errorf("failed to query  from data: %w", err)
+			return err
+		}
+
+		remaining := originalAmount.Sub(reversed).Sub(payload.Amount)
+		if remaining.IsNegative() {
+			return ErrReversalExceeded
+		}
+
+		ids := []int{payload.From.AccountID, payload.To.AccountID}
+		if refund := payload.FeeRefund; refund != nil {
+			// the share is taken on the running total so the refunds of
+			// several partial reversals add up to the whole fee
+			refund.Amount, err = feeShare(refund, reversed, payload.Amount, originalAmount)
+			if err != nil {
+				return err
+			}
+
+			if refund.Amount.IsPositive() {
+				if err := resolveFeeRefundAccount(ctx, repo, refund); err != nil {
+					return err
+				}
+				ids = append(ids, refund.LedgerEntryRevenue.AccountID)
+			} else {
+				payload.FeeRefund = nil
+			}
 		}
 
-		var idTo int
-		err = repo.QueryRow(ctx, "SELECT id from accounts WHERE account_id = $1 FOR UPDATE", payload.To.AccountID).Scan(&idTo)
+		accounts, err := lockAccounts(ctx, repo, ids...)
 		if err != nil {
-			return fmt.Errorf("failed to query to data: %w", err)
+			return err
+		}
+
+		if !payload.AllowNegativeBalance && availableBalance(accounts[payload.From.AccountID]).Cmp(payload.Amount) < 0 {
+			return ErrInsufficientBalance
+		}
+
+		if err := checkFeeRefundCurrency(accounts[payload.To.AccountID], payload.FeeRefund); err != nil {
+			return err
+		}
+
+		if err := insertTransaction(ctx, repo, payload.Transaction); err != nil {
+			return err
+		}
+
+		payload.LedgerEntryFrom.BalanceBefore, payload.LedgerEntryFrom.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.From.AccountID, payload.Amount.Neg())
+		if err != nil {
+			return err
+		}
+
+		payload.LedgerEntryTo.BalanceBefore, payload.LedgerEntryTo.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.To.AccountID, payload.Amount)
+		if err != nil {
+			return err
+		}
+
+		entries := []LedgerEntry{payload.LedgerEntryFrom, payload.LedgerEntryTo}
+		if refund := payload.FeeRefund; refund != nil {
+			refund.LedgerEntryRevenue.Amount, refund.LedgerEntryPayer.Amount = refund.Amount, refund.Amount
+
+			refund.LedgerEntryRevenue.BalanceBefore, refund.LedgerEntryRevenue.BalanceAfter, err = adjustAccountBalance(ctx, repo, refund.LedgerEntryRevenue.AccountID, refund.Amount.Neg())
+			if err != nil {
+				return err
+			}
+
+			refund.LedgerEntryPayer.BalanceBefore, refund.LedgerEntryPayer.BalanceAfter, err = adjustAccountBalance(ctx, repo, payload.To.AccountID, refund.Amount)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, refund.LedgerEntryRevenue, refund.LedgerEntryPayer)
+		}
+
+		if err := insertLedgerEntries(ctx, repo, entries...); err != nil {
+			return err
+		}
+
+		if err := insertTransactionEvent(ctx, repo, consts.EventTransactionReversed, payload.Transaction, entries); err != nil {
+			return err
+		}
+
+		status := consts.TransactionStatusPartiallyReversed
+		if remaining.IsZero() {
+			status = consts.TransactionStatusReversed
+		}
+
+		_, err = repo.Exec(ctx, "UPDATE transactions SET status = $1 WHERE id = $2", status, originalID)
+		if err != nil {
+			return fmt.Errorf("failed to update transactions data: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// feeShare returns the part of the refunded fee owed for reversing amount
+// of a transfer of total once reversed has already been reversed.
+func feeShare(refund *FeeRefund, reversed, amount, total utils.Decimal) (utils.Decimal, error) {
+	before, err := refund.Fee.MulDiv(reversed, total, refund.Scale)
+	if err != nil {
+		return utils.Decimal{}, err
+	}
+
+	after, err := refund.Fee.MulDiv(reversed.Add(amount), total, refund.Scale)
+	if err != nil {
+		return utils.Decimal{}, err
+	}
+
+	return after.Sub(before), nil
+}
+
+// checkFeeRefundCurrency requires a fee to be refunded in the payer's
+// currency.
+func checkFeeRefundCurrency(payer Account, refund *FeeRefund) error {
+	if refund != nil && payer.Currency != refund.Currency {
+		return ErrCurrencyMismatch
+	}
+
+	return nil
+}
+
+// reversedAmount sums the reversals already booked against a transfer.
+func reversedAmount(ctx context.Context, repo *db.Repository, transactionID string) (utils.Decimal, error) {
+	var total utils.Decimal
+	err := repo.QueryRow(ctx, `SELECT COALESCE(SUM(amount), 0) FROM transactions
+		WHERE original_transaction_id = $1 AND type = $2`, transactionID, consts.TransactionTypeReversal).Scan(&total)
+	if err != nil {
+		return total, fmt.Errorf("failed to query transactions data: %w", err)
+	}
+
+	return total, nil
+}
+
+// lockAccount takes a row lock on the account for the rest of the
+// surrounding transaction and returns the locked row.
+func lockAccount(ctx context.Context, repo *db.Repository, accountID int) (Account, error) {
+	var result Account
+	err := repo.QueryRow(ctx, "SELECT id, account_id, currency, status, balance, held_amount FROM accounts WHERE account_id = $1 FOR UPDATE", accountID).Scan(&result.ID, &result.AccountID, &result.Currency, &result.Status, &result.Balance, &result.HeldAmount)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return result, ErrDataNotFound
+	}
+
+	if err != nil {
+		return result, fmt.Errorf("failed to query account data: %w", err)
+	}
+
+	return result, nil
+}
+
+// lockAvailableBalance locks the account and returns the balance available
+// for debits. Frozen and closed accounts cannot move money and yield
+// ErrAccountFrozen or ErrAccountClosed.
+func lockAvailableBalance(ctx context.Context, repo *db.Repository, accountID int) (utils.Decimal, error) {
+	acc, err := lockActiveAccount(ctx, repo, accountID)
+	if err != nil {
+		return utils.Decimal{}, err
+	}
+
+	return availableBalance(acc), nil
+}
+
+// lockActiveAccount is lockAccount for accounts that are about to move money;
+// frozen and closed accounts yield ErrAccountFrozen or ErrAccountClosed.
+func lockActiveAccount(ctx context.Context, repo *db.Repository, accountID int) (Account, error) {
+	acc, err := lockAccount(ctx, repo, accountID)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if err := checkAccountActive(acc); err != nil {
+		return Account{}, err
+	}
+
+	return acc, nil
+}
+
+func checkAccountActive(acc Account) error {
+	switch acc.Status {
+	case consts.AccountStatusFrozen:
+		return ErrAccountFrozen
+	case consts.AccountStatusClosed:
+		return ErrAccountClosed
+	}
+
+	return nil
+}
+
+// availableBalance is the balance less the amount reserved by active holds.
+func availableBalance(acc Account) utils.Decimal {
+	return acc.Balance.Sub(acc.HeldAmount)
+}
+
+// checkSameCurrency refuses to move money between accounts held in different
+// currencies.
+func checkSameCurrency(accounts map[int]Account) error {
+	var currency string
+	for _, acc := range accounts {
+		if currency != "" && acc.Currency != currency {
+			return ErrCurrencyMismatch
+		}
+		currency = acc.Currency
+	}
+
+	return nil
+}
+
+// lockAccounts locks every account in ascending account_id order, whatever
+// the order of the arguments, so two transactions touching the same accounts
+// always queue instead of deadlocking. It returns the locked rows by
+// account_id; frozen and closed accounts are refused as by
+// lockActiveAccount.
+func lockAccounts(ctx context.Context, repo *db.Repository, accountIDs ...int) (map[int]Account, error) {
+	ids := append([]int(nil), accountIDs...)
+	sort.Ints(ids)
+
+	accounts := make(map[int]Account, len(ids))
+	for _, id := range ids {
+		if _, ok := accounts[id]; ok {
+			continue
 		}
 
-		if idFrom == 0 || idTo == 0 {
-			return errors.New("data not found")
+		acc, err := lockActiveAccount(ctx, repo, id)
+		if err != nil {
+			return nil, err
 		}
+		accounts[id] = acc
+	}
+
+	return accounts, nil
+}
+
+// adjustAccountBalance adds delta to the stored balance in SQL rather than
+// writing back a value computed from an earlier read, and returns the balance
+// before and after the change. Callers must hold the row lock.
+func adjustAccountBalance(ctx context.Context, repo *db.Repository, accountID int, delta utils.Decimal) (utils.Decimal, utils.Decimal, error) {
+	var after utils.Decimal
+	err := repo.QueryRow(ctx, `UPDATE accounts SET balance = balance + $1, updated_at = $2
+		WHERE account_id = $3 RETURNING balance`, delta, time.Now(), accountID).Scan(&after)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return utils.Decimal{}, utils.Decimal{}, ErrDataNotFound
+	}
+
+	if err != nil {
+		return utils.Decimal{}, utils.Decimal{}, fmt.Errorf("failed to update accounts data: %w", err)
+	}
+
+	return after.Sub(delta), after, nil
+}
 
-		_, err = repo.Exec(ctx, `INSERT INTO transactions (id,
+func insertTransaction(ctx context.Context, repo *db.Repository, trx Transaction) error {
+	_, err := repo.Exec(ctx, `INSERT INTO transactions (id,
 							reference_number,
 							type,
 							from_account_id,
@@ -127,24 +888,68 @@ func (r *walletRepo) SubmitTransaction(ctx context.Context, payload TransactionP
 							amount,
 							description,
 							status,
-							created_at
-	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-			payload.Transaction.ID,
-			payload.Transaction.ReferenceNumber,
-			payload.Transaction.Type,
-			payload.Transaction.FromAccountID,
-			payload.Transaction.ToAccountID,
-			payload.Transaction.Amount,
-			payload.Transaction.Description,
-			payload.Transaction.Status,
-			payload.Transaction.CreatedAt,
+							created_at,
+							request_fingerprint,
+							original_transaction_id,
+							metadata
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, NULLIF($12, '')::jsonb)`,
+		trx.ID,
+		trx.ReferenceNumber,
+		trx.Type,
+		trx.FromAccountID,
+		trx.ToAccountID,
+		trx.Amount,
+		trx.Description,
+		trx.Status,
+		trx.CreatedAt,
+		trx.RequestFingerprint,
+		trx.OriginalTransactionID,
+		string(trx.Metadata),
+	)
+	if db.IsUniqueViolation(err, "transactions_reference_number_key") {
+		return ErrDuplicateReference
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to insert transactions data: %w", err)
+	}
+
+	return nil
+}
+
+// insertLedgerEntries writes all entries with a single multi-row INSERT.
+// Entries of a transaction must balance, see checkBalanced.
+func insertLedgerEntries(ctx context.Context, repo *db.Repository, entries ...LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := checkBalanced(entries); err != nil {
+		return err
+	}
+
+	const columns = 9
+	values := make([]string, 0, len(entries))
+	args := make([]interface{}, 0, len(entries)*columns)
+	for i, e := range entries {
+		n := i * columns
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9))
+		args = append(args,
+			e.ID,
+			e.TransactionID,
+			e.AccountID,
+			e.EntryType,
+			e.Amount,
+			e.BalanceBefore,
+			e.BalanceAfter,
+			e.Description,
+			e.CreatedAt,
 		)
-		if err != nil {
-			return fmt.Errorf("failed to insert transactions data: %w", err)
-		}
+	}
 
-		_, err = repo.Exec(
-			ctx, ` INSERT INTO ledger_entries 
+	_, err := repo.Exec(
+		ctx, ` INSERT INTO ledger_entries 
 				(
 					id,
 					transaction_id,
@@ -156,43 +961,39 @@ func (r *walletRepo) SubmitTransaction(ctx context.Context, payload TransactionP
 					description,
 					created_at
 				)
-					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9), 
-					($10, $11, $12, $13, $14, $15, $16, $17, $18)`,
-			payload.LedgerEntryFrom.ID,
-			payload.LedgerEntryFrom.TransactionID,
-			payload.LedgerEntryFrom.AccountID,
-			payload.LedgerEntryFrom.EntryType,
-			payload.LedgerEntryFrom.Amount,
-			payload.LedgerEntryFrom.BalanceBefore,
-			payload.LedgerEntryFrom.BalanceAfter,
-			payload.LedgerEntryFrom.Description,
-			payload.LedgerEntryFrom.CreatedAt,
-			payload.LedgerEntryTo.ID,
-			payload.LedgerEntryTo.TransactionID,
-			payload.LedgerEntryTo.AccountID,
-			payload.LedgerEntryTo.EntryType,
-			payload.LedgerEntryTo.Amount,
-			payload.LedgerEntryTo.BalanceBefore,
-			payload.LedgerEntryTo.BalanceAfter,
-			payload.LedgerEntryTo.Description,
-			payload.LedgerEntryTo.CreatedAt,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert ledger_entries data: %w", err)
-		}
+				VALUES `+strings.Join(values, ", "),
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert ledger_entries data: %w", err)
+	}
 
-		_, err = repo.Exec(ctx, `UPDATE accounts SET balance = $1, updated_at = $2
-		WHERE account_id = $3`, payload.From.Balance, time.Now(), payload.From.AccountID)
+	return nil
+}
+
+// checkBalanced requires the debits of every transaction among entries to
+// equal its credits. Every booking writes all entries of its transaction in
+// one call, so this catches an unbalanced posting before it reaches the
+// database, which enforces the same per currency at commit.
+func checkBalanced(entries []LedgerEntry) error {
+	net := make(map[string]utils.Decimal)
+	for _, e := range entries {
+		amount := e.Amount
+		if e.EntryType == consts.EntryTypeCredit {
+			amount = amount.Neg()
+		}
+		sum, err := net[e.TransactionID].CheckedAdd(amount)
 		if err != nil {
-			return fmt.Errorf("failed to update accounts data from: %w", err)
+			return err
 		}
+		net[e.TransactionID] = sum
+	}
 
-		_, err = repo.Exec(ctx, `UPDATE accounts SET balance = $1, updated_at = $2
-		WHERE account_id = $3`, payload.To.Balance, time.Now(), payload.To.AccountID)
-		if err != nil {
-			return fmt.Errorf("failed to update accounts data to: %w", err)
+	for _, n := range net {
+		if !n.IsZero() {
+			return ErrUnbalancedEntries
 		}
+	}
 
-		return nil
-	})
+	return nil
 }
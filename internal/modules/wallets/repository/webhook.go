@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+)
+
+const webhookSubscriptionColumns = `id, COALESCE(customer_id::text, ''), url, secret, event_types, active, created_at, updated_at`
+
+const webhookDeliveryColumns = `id,
+					subscription_id,
+					event_id,
+					event_type,
+					payload,
+					status,
+					attempts,
+					next_attempt_at,
+					last_status_code,
+					COALESCE(last_error, ''),
+					delivered_at,
+					created_at,
+					updated_at`
+
+func scanWebhookSubscription(row rowScanner) (WebhookSubscription, error) {
+	var result WebhookSubscription
+	err := row.Scan(
+		&result.ID,
+		&result.CustomerID,
+		&result.URL,
+		&result.Secret,
+		pq.Array(&result.EventTypes),
+		&result.Active,
+		&result.CreatedAt,
+		&result.UpdatedAt,
+	)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return WebhookSubscription{}, nil
+	}
+
+	if err != nil {
+		return WebhookSubscription{}, fmt.Errorf("failed to query webhook_subscriptions data: %w", err)
+	}
+
+	return result, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (WebhookDelivery, error) {
+	var result WebhookDelivery
+	err := row.Scan(
+		&result.ID,
+		&result.SubscriptionID,
+		&result.EventID,
+		&result.EventType,
+		&result.Payload,
+		&result.Status,
+		&result.Attempts,
+		&result.NextAttemptAt,
+		&result.LastStatusCode,
+		&result.LastError,
+		&result.DeliveredAt,
+		&result.CreatedAt,
+		&result.UpdatedAt,
+	)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return WebhookDelivery{}, nil
+	}
+
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("failed to query webhook_deliveries data: %w", err)
+	}
+
+	return result, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]WebhookDelivery, error) {
+	defer rows.Close()
+
+	var result []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook_deliveries data: %w", err)
+	}
+
+	return result, nil
+}
+
+// insertWebhookDeliveries queues event for every active subscription to its
+// type whose customer owns one of the event's accounts. The request body is
+// the event as the outbox relay publishes it.
+func insertWebhookDeliveries(ctx context.Context, repo *db.Repository, event OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+
+	_, err = repo.Exec(ctx, `INSERT INTO webhook_deliveries (id,
+							subscription_id,
+							event_id,
+							event_type,
+							payload,
+							status,
+							next_attempt_at,
+							created_at,
+							updated_at
+		)
+		SELECT gen_random_uuid(), s.id, $1, $2, $3, $4, $5, $5, $5 FROM webhook_subscriptions s
+		WHERE s.active AND ($2 = ANY(s.event_types) OR $6 = ANY(s.event_types))
+			AND EXISTS (SELECT 1 FROM accounts a WHERE a.account_id = ANY($7) AND a.customer_id = s.customer_id)`,
+		event.EventID, event.Type, body, consts.WebhookDeliveryPending, event.CreatedAt, consts.WebhookEventAll, pq.Array(event.AccountIDs))
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook_deliveries data: %w", err)
+	}
+
+	return nil
+}
+
+func (r *walletRepo) CreateWebhookSubscription(ctx context.Context, sub WebhookSubscription) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO webhook_subscriptions (id, customer_id, url, secret, event_types, active, created_at, updated_at)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7, $7)`,
+		sub.ID, sub.CustomerID, sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.Active, sub.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook_subscriptions data: %w", err)
+	}
+
+	return nil
+}
+
+func (r *walletRepo) GetWebhookSubscription(ctx context.Context, id string) (WebhookSubscription, error) {
+	return scanWebhookSubscription(r.db.QueryRow(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+}
+
+func (r *walletRepo) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook_subscriptions data: %w", err)
+	}
+	defer rows.Close()
+
+	var result []WebhookSubscription
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook_subscriptions data: %w", err)
+	}
+
+	return result, nil
+}
+
+// UpdateWebhookSubscription replaces the URL, event types and active flag of
+// subscription sub.ID and returns it, or the zero value when it does not
+// exist. Deliveries already queued keep going to the new URL.
+func (r *walletRepo) UpdateWebhookSubscription(ctx context.Context, sub WebhookSubscription) (WebhookSubscription, error) {
+	return scanWebhookSubscription(r.db.QueryRow(ctx, `UPDATE webhook_subscriptions SET url = $2, event_types = $3, active = $4, updated_at = $5
+		WHERE id = $1
+		RETURNING `+webhookSubscriptionColumns,
+		sub.ID, sub.URL, pq.Array(sub.EventTypes), sub.Active, time.Now()))
+}
+
+// DeleteWebhookSubscription removes a subscription with its deliveries and
+// their log and reports whether it existed.
+func (r *walletRepo) DeleteWebhookSubscription(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook_subscriptions data: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook_subscriptions data: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+func (r *walletRepo) GetWebhookDelivery(ctx context.Context, id string) (WebhookDelivery, error) {
+	return scanWebhookDelivery(r.db.QueryRow(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+}
+
+func (r *walletRepo) ListWebhookDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where = append(where, "subscription_id = "+arg(filter.SubscriptionID))
+	if filter.Status != "" {
+		where = append(where, "status = "+arg(filter.Status))
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY created_at DESC, id DESC LIMIT ` + arg(filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook_deliveries data: %w", err)
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// ListWebhookAttempts returns the attempt log of a delivery, oldest first.
+func (r *walletRepo) ListWebhookAttempts(ctx context.Context, deliveryID string) ([]WebhookAttempt, error) {
+	rows, err := r.db.Query(ctx, `SELECT id, delivery_id, COALESCE(status_code, 0), COALESCE(error, ''), duration_ms, created_at
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY id`, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook_delivery_attempts data: %w", err)
+	}
+	defer rows.Close()
+
+	var result []WebhookAttempt
+	for rows.Next() {
+		var a WebhookAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.StatusCode, &a.Error, &a.Duration, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to query webhook_delivery_attempts data: %w", err)
+		}
+		result = append(result, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook_delivery_attempts data: %w", err)
+	}
+
+	return result, nil
+}
+
+// ClaimWebhookDeliveries leases up to limit pending deliveries of active
+// subscriptions due at now to the caller until lockedUntil and returns them,
+// earliest first. Like ClaimScheduledTransfers, concurrent workers skip each
+// other's rows and a lapsed lease makes a delivery claimable again.
+func (r *walletRepo) ClaimWebhookDeliveries(ctx context.Context, now, lockedUntil time.Time, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, `UPDATE webhook_deliveries SET locked_until = $2, updated_at = $1
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = $3 AND d.next_attempt_at <= $1 AND (d.locked_until IS NULL OR d.locked_until <= $1) AND s.active
+			ORDER BY d.next_attempt_at
+			LIMIT $4
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns,
+		now, lockedUntil, consts.WebhookDeliveryPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook_deliveries data: %w", err)
+	}
+
+	claimed, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the sub-select
+	sort.Slice(claimed, func(i, j int) bool {
+		return claimed[i].NextAttemptAt.Before(claimed[j].NextAttemptAt)
+	})
+
+	return claimed, nil
+}
+
+// RecordWebhookAttempt logs an attempt and stores its outcome on the
+// delivery in one transaction. The outcome is dropped when the delivery is no
+// longer pending, e.g. a worker whose lease ran out reporting late.
+func (r *walletRepo) RecordWebhookAttempt(ctx context.Context, result WebhookAttemptResult) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context, repo *db.Repository) error {
+		a := result.Attempt
+		_, err := repo.Exec(ctx, `INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms, created_at)
+			VALUES ($1, NULLIF($2, 0), NULLIF($3, ''), $4, $5)`,
+			a.DeliveryID, a.StatusCode, a.Error, a.Duration, a.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert webhook_delivery_attempts data: %w", err)
+		}
+
+		_, err = repo.Exec(ctx, `UPDATE webhook_deliveries SET
+				status = $2,
+				attempts = $3,
+				next_attempt_at = $4,
+				last_status_code = NULLIF($5, 0),
+				last_error = NULLIF($6, ''),
+				delivered_at = CASE WHEN $2 = $7 THEN $8 ELSE delivered_at END,
+				locked_until = NULL,
+				updated_at = $8
+			WHERE id = $1 AND status = $9`,
+			a.DeliveryID,
+			result.Status,
+			result.Attempts,
+			result.NextAttemptAt,
+			a.StatusCode,
+			a.Error,
+			consts.WebhookDeliverySucceeded,
+			a.CreatedAt,
+			consts.WebhookDeliveryPending,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update webhook_deliveries data: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RedeliverWebhook queues delivery id again right away with a fresh set of
+// attempts, whatever its status, and returns it. The zero value means it
+// does not exist.
+func (r *walletRepo) RedeliverWebhook(ctx context.Context, id string) (WebhookDelivery, error) {
+	now := time.Now()
+	return scanWebhookDelivery(r.db.QueryRow(ctx, `UPDATE webhook_deliveries SET status = $2, attempts = 0, next_attempt_at = $3, locked_until = NULL, updated_at = $3
+		WHERE id = $1
+		RETURNING `+webhookDeliveryColumns,
+		id, consts.WebhookDeliveryPending, now))
+}
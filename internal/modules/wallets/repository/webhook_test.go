@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+func createTestCustomer(t *testing.T, repo *walletRepo, accountIDs ...int) string {
+	t.Helper()
+
+	customer := Customer{ID: uuid.NewString(), Name: "test customer", CreatedAt: time.Now()}
+	require.NoError(t, repo.CreateCustomer(context.Background(), customer))
+
+	for _, id := range accountIDs {
+		ok, err := repo.SetAccountCustomer(context.Background(), id, customer.ID)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	return customer.ID
+}
+
+func createTestWebhook(t *testing.T, repo *walletRepo, customerID string, eventTypes ...string) WebhookSubscription {
+	t.Helper()
+
+	now := time.Now()
+	sub := WebhookSubscription{
+		ID:         uuid.NewString(),
+		CustomerID: customerID,
+		URL:        "https://partner.example.com/hook",
+		Secret:     "whsec_0123456789abcdef",
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, repo.CreateWebhookSubscription(context.Background(), sub))
+	t.Cleanup(func() { repo.DeleteWebhookSubscription(context.Background(), sub.ID) })
+
+	return sub
+}
+
+func TestWebhookDeliveryLifecycle(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	from := createTestAccount(t, repo, utils.NewDecimal(100))
+	to := createTestAccount(t, repo, utils.NewDecimal(1))
+	sub := createTestWebhook(t, repo, createTestCustomer(t, repo, from), consts.EventTransferCompleted)
+	require.NoError(t, repo.SubmitTransaction(ctx, newTestTransfer(from, to, utils.NewDecimal(10))))
+
+	// only the transfer matches, the account.created events do not
+	list, err := repo.ListWebhookDeliveries(ctx, WebhookDeliveryFilter{SubscriptionID: sub.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	d := list[0]
+	assert.Equal(t, consts.EventTransferCompleted, d.EventType)
+	assert.Equal(t, consts.WebhookDeliveryPending, d.Status)
+
+	var envelope OutboxEvent
+	require.NoError(t, json.Unmarshal(d.Payload, &envelope))
+	assert.Equal(t, d.EventID, envelope.EventID)
+
+	claim := func() bool {
+		now := time.Now()
+		claimed, err := repo.ClaimWebhookDeliveries(ctx, now, now.Add(time.Minute), 100)
+		require.NoError(t, err)
+		for _, c := range claimed {
+			if c.ID == d.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	require.True(t, claim())
+	assert.False(t, claim(), "a leased delivery must not be claimed twice")
+
+	require.NoError(t, repo.RecordWebhookAttempt(ctx, WebhookAttemptResult{
+		Attempt:       WebhookAttempt{DeliveryID: d.ID, StatusCode: 500, Error: "receiver responded 500", Duration: 12, CreatedAt: time.Now()},
+		Status:        consts.WebhookDeliveryFailed,
+		Attempts:      1,
+		NextAttemptAt: time.Now(),
+	}))
+
+	// a late result of a delivery that is no longer pending is only logged
+	require.NoError(t, repo.RecordWebhookAttempt(ctx, WebhookAttemptResult{
+		Attempt:  WebhookAttempt{DeliveryID: d.ID, StatusCode: 200, CreatedAt: time.Now()},
+		Status:   consts.WebhookDeliverySucceeded,
+		Attempts: 1,
+	}))
+
+	got, err := repo.GetWebhookDelivery(ctx, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, consts.WebhookDeliveryFailed, got.Status)
+	assert.Equal(t, int64(500), got.LastStatusCode.Int64)
+	assert.False(t, got.DeliveredAt.Valid)
+
+	attempts, err := repo.ListWebhookAttempts(ctx, d.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 500, attempts[0].StatusCode)
+	assert.Equal(t, int64(12), attempts[0].Duration)
+
+	got, err = repo.RedeliverWebhook(ctx, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, consts.WebhookDeliveryPending, got.Status)
+	assert.Zero(t, got.Attempts)
+	require.True(t, claim())
+
+	require.NoError(t, repo.RecordWebhookAttempt(ctx, WebhookAttemptResult{
+		Attempt:       WebhookAttempt{DeliveryID: d.ID, StatusCode: 204, CreatedAt: time.Now()},
+		Status:        consts.WebhookDeliverySucceeded,
+		NextAttemptAt: time.Now(),
+	}))
+
+	got, err = repo.GetWebhookDelivery(ctx, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, consts.WebhookDeliverySucceeded, got.Status)
+	assert.True(t, got.DeliveredAt.Valid)
+}
+
+func TestWebhookDeliveriesScopedToCustomer(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	a := createTestAccount(t, repo, utils.NewDecimal(100))
+	b1 := createTestAccount(t, repo, utils.NewDecimal(100))
+	b2 := createTestAccount(t, repo, utils.NewDecimal(100))
+
+	subA := createTestWebhook(t, repo, createTestCustomer(t, repo, a), consts.WebhookEventAll)
+	subB := createTestWebhook(t, repo, createTestCustomer(t, repo, b1, b2), consts.WebhookEventAll)
+
+	deliveries := func(sub WebhookSubscription) int {
+		list, err := repo.ListWebhookDeliveries(ctx, WebhookDeliveryFilter{SubscriptionID: sub.ID, Limit: 10})
+		require.NoError(t, err)
+		return len(list)
+	}
+
+	// customer B's own transfer is not sent to customer A
+	require.NoError(t, repo.SubmitTransaction(ctx, newTestTransfer(b1, b2, utils.NewDecimal(10))))
+	assert.Zero(t, deliveries(subA))
+	assert.Equal(t, 1, deliveries(subB))
+
+	// a transfer between them is sent to both
+	require.NoError(t, repo.SubmitTransaction(ctx, newTestTransfer(a, b1, utils.NewDecimal(10))))
+	assert.Equal(t, 1, deliveries(subA))
+	assert.Equal(t, 2, deliveries(subB))
+
+	// a subscription without a customer receives nothing
+	orphan := createTestWebhook(t, repo, "", consts.WebhookEventAll)
+	require.NoError(t, repo.SubmitTransaction(ctx, newTestTransfer(a, b1, utils.NewDecimal(10))))
+	assert.Zero(t, deliveries(orphan))
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+)
+
+// FreezeAccount stops every money movement in or out of the account until it
+// is unfrozen.
+func (s *wallet) FreezeAccount(ctx context.Context, accountID int, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error) {
+	return s.changeAccountStatus(ctx, "[FreezeAccount]", accountID, consts.AccountStatusFrozen, req)
+}
+
+func (s *wallet) UnfreezeAccount(ctx context.Context, accountID int, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error) {
+	return s.changeAccountStatus(ctx, "[UnfreezeAccount]", accountID, consts.AccountStatusActive, req)
+}
+
+// CloseAccount closes the account for good. The balance must be zero unless
+// req.SweepToAccountID names an active account to receive it.
+func (s *wallet) CloseAccount(ctx context.Context, accountID int, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error) {
+	return s.changeAccountStatus(ctx, "[CloseAccount]", accountID, consts.AccountStatusClosed, req)
+}
+
+func (s *wallet) changeAccountStatus(ctx context.Context, logPrefix string, accountID int, status string, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error) {
+	actor, err := principalActor(ctx)
+	if err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.Any("err", err))
+		return presentations.AccountStatusChange{}, err
+	}
+
+	if err := validateStatusChange(accountID, status, req); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.Any("err", err))
+		return presentations.AccountStatusChange{}, err
+	}
+
+	payload := repository.StatusChangePayload{
+		AccountID: accountID,
+		Status:    status,
+		Actor:     actor,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedAt: time.Now(),
+	}
+
+	if req.SweepToAccountID != 0 {
+		// the amount is only known once the account is locked, the
+		// repository fills it in
+		sweep := prepareTrxPayload(
+			repository.Account{AccountID: accountID},
+			repository.Account{AccountID: req.SweepToAccountID},
+			utils.Decimal{},
+			nil,
+		)
+		sweep.Transaction.Description = fmt.Sprintf("sweep on closure of account %d to %d", accountID, req.SweepToAccountID)
+		payload.Sweep = &sweep
+	}
+
+	change, err := s.repo.ChangeAccountStatus(ctx, payload)
+	if err != nil {
+		slog.Warn(logPrefix+" failed change status", slog.Any("accountID", accountID), slog.Any("err", err))
+		return presentations.AccountStatusChange{}, wrapError(err)
+	}
+
+	slog.Info(logPrefix+" success", slog.Any("accountID", accountID), slog.String("from", change.FromStatus), slog.String("to", change.ToStatus), slog.String("actor", change.Actor))
+	return toAccountStatusChange(change), nil
+}
+
+func (s *wallet) ListAccountStatusChanges(ctx context.Context, accountID int) ([]presentations.AccountStatusChange, error) {
+	if err := validateAccountID(accountID); err != nil {
+		slog.Warn("[ListAccountStatusChanges] failed validation", slog.Any("err", err))
+		return nil, err
+	}
+
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		slog.Warn("[ListAccountStatusChanges] failed GetAccount", slog.Any("err", err))
+		return nil, wrapError(err)
+	}
+
+	if account.ID == 0 {
+		slog.Warn("[ListAccountStatusChanges] failed data not found", slog.Any("accountID", accountID))
+		return nil, ErrAccountNotFound
+	}
+
+	changes, err := s.repo.ListAccountStatusChanges(ctx, accountID)
+	if err != nil {
+		slog.Warn("[ListAccountStatusChanges] failed ListAccountStatusChanges", slog.Any("err", err))
+		return nil, wrapError(err)
+	}
+
+	resp := make([]presentations.AccountStatusChange, 0, len(changes))
+	for _, c := range changes {
+		resp = append(resp, toAccountStatusChange(c))
+	}
+
+	return resp, nil
+}
+
+// principalActor returns the authenticated caller recorded as the actor of
+// an audited change, so the audit trail cannot name anyone else.
+func principalActor(ctx context.Context) (string, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok || p.Subject == "" {
+		return "", ErrActorRequired
+	}
+
+	return p.Subject, nil
+}
+
+func validateStatusChange(accountID int, status string, req presentations.ChangeAccountStatus) error {
+	if err := validateAccountID(accountID); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		return ErrReasonRequired
+	}
+
+	if req.SweepToAccountID == 0 {
+		return nil
+	}
+
+	if status != consts.AccountStatusClosed {
+		return ErrSweepNotAllowed
+	}
+
+	if err := validateAccountID(req.SweepToAccountID); err != nil {
+		return err
+	}
+
+	if req.SweepToAccountID == accountID {
+		return ErrSameAccount
+	}
+
+	return nil
+}
+
+func toAccountStatusChange(c repository.AccountStatusChange) presentations.AccountStatusChange {
+	return presentations.AccountStatusChange{
+		ID:                 c.ID,
+		AccountID:          c.AccountID,
+		FromStatus:         c.FromStatus,
+		ToStatus:           c.ToStatus,
+		Actor:              c.Actor,
+		Reason:             c.Reason,
+		SweepTransactionID: c.SweepTransactionID.String,
+		CreatedAt:          c.CreatedAt,
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestChangeAccountStatus(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	errTest := errors.New("test")
+	ctx := auth.NewContext(context.TODO(), auth.Principal{Subject: "ops@example.com", Role: consts.RoleOperator})
+	svc := NewWalletService(mRepo)
+
+	req := presentations.ChangeAccountStatus{Reason: "chargeback investigation"}
+
+	testTables := []struct {
+		name   string
+		mock   func()
+		err    error
+		ctx    context.Context
+		change func(ctx context.Context, accountID int, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error)
+		req    presentations.ChangeAccountStatus
+	}{
+		{
+			name:   "FAILED without principal",
+			err:    ErrActorRequired,
+			ctx:    context.TODO(),
+			change: svc.FreezeAccount,
+			req:    presentations.ChangeAccountStatus{Reason: "fraud"},
+			mock:   func() {},
+		},
+		{
+			name:   "FAILED validation reason",
+			err:    ErrReasonRequired,
+			change: svc.FreezeAccount,
+			req:    presentations.ChangeAccountStatus{Reason: "  "},
+			mock:   func() {},
+		},
+		{
+			name:   "FAILED sweep when freezing",
+			err:    ErrSweepNotAllowed,
+			change: svc.FreezeAccount,
+			req:    presentations.ChangeAccountStatus{Reason: "fraud", SweepToAccountID: 3},
+			mock:   func() {},
+		},
+		{
+			name:   "FAILED sweep to itself",
+			err:    ErrSameAccount,
+			change: svc.CloseAccount,
+			req:    presentations.ChangeAccountStatus{Reason: "customer request", SweepToAccountID: 2},
+			mock:   func() {},
+		},
+		{
+			name:   "FAILED transition not allowed",
+			err:    ErrStatusTransition,
+			change: svc.UnfreezeAccount,
+			req:    req,
+			mock: func() {
+				mRepo.EXPECT().ChangeAccountStatus(ctx, gomock.Any()).Return(repository.AccountStatusChange{}, repository.ErrStatusTransition)
+			},
+		},
+		{
+			name:   "FAILED close with balance",
+			err:    ErrBalanceNotZero,
+			change: svc.CloseAccount,
+			req:    req,
+			mock: func() {
+				mRepo.EXPECT().ChangeAccountStatus(ctx, gomock.Any()).Return(repository.AccountStatusChange{}, repository.ErrBalanceNotZero)
+			},
+		},
+		{
+			name:   "FAILED db error",
+			err:    errTest,
+			change: svc.FreezeAccount,
+			req:    req,
+			mock: func() {
+				mRepo.EXPECT().ChangeAccountStatus(ctx, gomock.Any()).Return(repository.AccountStatusChange{}, errTest)
+			},
+		},
+		{
+			name:   "SUCCESS freeze",
+			change: svc.FreezeAccount,
+			req:    req,
+			mock: func() {
+				mRepo.EXPECT().ChangeAccountStatus(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, p repository.StatusChangePayload) (repository.AccountStatusChange, error) {
+					assert.Equal(t, 2, p.AccountID)
+					assert.Equal(t, consts.AccountStatusFrozen, p.Status)
+					assert.Equal(t, "ops@example.com", p.Actor)
+					assert.Nil(t, p.Sweep)
+					return repository.AccountStatusChange{ID: 1, AccountID: 2, FromStatus: "active", ToStatus: "frozen"}, nil
+				})
+			},
+		},
+		{
+			name:   "SUCCESS close with sweep",
+			change: svc.CloseAccount,
+			req:    presentations.ChangeAccountStatus{Reason: "customer request", SweepToAccountID: 9},
+			mock: func() {
+				mRepo.EXPECT().ChangeAccountStatus(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, p repository.StatusChangePayload) (repository.AccountStatusChange, error) {
+					assert.Equal(t, consts.AccountStatusClosed, p.Status)
+					if assert.NotNil(t, p.Sweep) {
+						assert.Equal(t, 2, p.Sweep.From.AccountID)
+						assert.Equal(t, 9, p.Sweep.To.AccountID)
+					}
+					return repository.AccountStatusChange{
+						ID:                 2,
+						AccountID:          2,
+						FromStatus:         "active",
+						ToStatus:           "closed",
+						SweepTransactionID: sql.NullString{String: "trx-1", Valid: true},
+					}, nil
+				})
+			},
+		},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			callCtx := ctx
+			if tt.ctx != nil {
+				callCtx = tt.ctx
+			}
+
+			resp, err := tt.change(callCtx, 2, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.NotZero(t, resp.ID)
+			}
+		})
+	}
+}
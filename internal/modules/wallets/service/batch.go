@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+)
+
+// SubmitTransactionBatch books req.Transfers. Every transfer is validated
+// like SubmitTransaction and keeps its own reference_number, so a batch
+// retried with the same keys replays the transfers already booked.
+func (s *wallet) SubmitTransactionBatch(ctx context.Context, req presentations.CreateTransactionBatch) (presentations.TransactionBatch, error) {
+	if err := validateBatch(req); err != nil {
+		slog.Warn("[SubmitTransactionBatch] failed validation", slog.Any("err", err))
+		return presentations.TransactionBatch{}, err
+	}
+
+	if req.Mode == consts.BatchModeBestEffort {
+		return s.submitBestEffort(ctx, req.Transfers), nil
+	}
+
+	return s.submitAtomic(ctx, req.Transfers)
+}
+
+// submitBestEffort books every transfer in its own DB transaction and
+// reports each outcome.
+func (s *wallet) submitBestEffort(ctx context.Context, transfers []presentations.CreateTransaction) presentations.TransactionBatch {
+	batch := presentations.TransactionBatch{
+		Mode:  consts.BatchModeBestEffort,
+		Items: make([]presentations.TransactionBatchItem, 0, len(transfers)),
+	}
+
+	for i, req := range transfers {
+		trx, err := s.SubmitTransaction(ctx, req)
+		addBatchItem(&batch, i, trx, err)
+	}
+
+	slog.Info("[SubmitTransactionBatch] done", slog.String("mode", batch.Mode), slog.Int("succeeded", batch.Succeeded), slog.Int("failed", batch.Failed))
+	return batch
+}
+
+// submitAtomic validates every transfer first and then books them all in one
+// DB transaction. The first failing transfer fails the whole batch; its
+// index is reported in the error details.
+func (s *wallet) submitAtomic(ctx context.Context, transfers []presentations.CreateTransaction) (presentations.TransactionBatch, error) {
+	refs := make(map[string]bool)
+	for i, req := range transfers {
+		if req.ReferenceNumber == "" {
+			continue
+		}
+
+		if refs[req.ReferenceNumber] {
+			slog.Warn("[SubmitTransactionBatch] failed duplicate reference_number", slog.Int("index", i))
+			return presentations.TransactionBatch{}, batchItemFailure(i, validationError(ErrInvalidBatch, fmt.Sprintf("reference_number %q is used more than once", req.ReferenceNumber)))
+		}
+		refs[req.ReferenceNumber] = true
+	}
+
+	var (
+		results  = make([]presentations.Transaction, len(transfers))
+		payloads = make([]repository.TransactionPayload, 0, len(transfers))
+		// indexes maps each payload back to its transfer
+		indexes = make([]int, 0, len(transfers))
+	)
+	for i, req := range transfers {
+		payload, replayed, err := s.prepareTransfer(ctx, "[SubmitTransactionBatch]", req)
+		if err != nil {
+			return presentations.TransactionBatch{}, batchItemFailure(i, err)
+		}
+
+		if replayed.ID != "" {
+			results[i] = toTransaction(replayed, nil)
+			continue
+		}
+
+		payloads = append(payloads, payload)
+		indexes = append(indexes, i)
+	}
+
+	if len(payloads) > 0 {
+		err := s.repo.SubmitTransactions(ctx, payloads)
+
+		var itemErr *repository.BatchItemError
+		if errors.As(err, &itemErr) {
+			slog.Warn("[SubmitTransactionBatch] failed submit transactions", slog.Int("index", indexes[itemErr.Index]), slog.Any("err", err))
+
+			// a concurrent request claimed one of the keys; sent again the
+			// batch replays or conflicts item by item
+			if errors.Is(itemErr.Err, repository.ErrDuplicateReference) {
+				return presentations.TransactionBatch{}, batchItemFailure(indexes[itemErr.Index], ErrRetryable.withCause(err))
+			}
+
+			return presentations.TransactionBatch{}, batchItemFailure(indexes[itemErr.Index], itemErr.Err)
+		}
+		if err != nil {
+			slog.Warn("[SubmitTransactionBatch] failed submit transactions", slog.Any("err", err))
+			return presentations.TransactionBatch{}, wrapError(err)
+		}
+
+		for j, payload := range payloads {
+			results[indexes[j]] = toTransaction(payload.Transaction, nil)
+		}
+	}
+
+	batch := presentations.TransactionBatch{
+		Mode:  consts.BatchModeAtomic,
+		Items: make([]presentations.TransactionBatchItem, 0, len(transfers)),
+	}
+	for i, trx := range results {
+		addBatchItem(&batch, i, trx, nil)
+	}
+
+	slog.Info("[SubmitTransactionBatch] done", slog.String("mode", batch.Mode), slog.Int("succeeded", batch.Succeeded), slog.Int("replayed", len(transfers)-len(payloads)))
+	return batch, nil
+}
+
+func addBatchItem(batch *presentations.TransactionBatch, index int, trx presentations.Transaction, err error) {
+	item := presentations.TransactionBatchItem{
+		Index:  index,
+		Status: consts.BatchItemCompleted,
+	}
+
+	if err != nil {
+		var e *Error
+		if !errors.As(wrapError(err), &e) {
+			e = ErrInternal
+		}
+
+		item.Status = consts.BatchItemFailed
+		item.Error = &presentations.BatchItemError{
+			ErrorCode: e.Code,
+			Message:   e.Message,
+			Data:      e.Details,
+		}
+		batch.Failed++
+	} else {
+		item.Transaction = &trx
+		batch.Succeeded++
+	}
+
+	batch.Items = append(batch.Items, item)
+}
+
+// batchItemFailure is err with the index of the failing transfer added to
+// its details.
+func batchItemFailure(index int, err error) error {
+	var e *Error
+	if !errors.As(wrapError(err), &e) {
+		e = ErrInternal
+	}
+
+	c := *e
+	c.Details = map[string]string{"index": strconv.Itoa(index)}
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+
+	return &c
+}
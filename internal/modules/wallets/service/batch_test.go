@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSubmitTransactionBatchValidation(t *testing.T) {
+	svc := NewWalletService(nil)
+	transfer := presentations.CreateTransaction{SourceAccountID: 1, DestinationAccountID: 2, Amount: "10"}
+
+	testTables := []struct {
+		name string
+		req  presentations.CreateTransactionBatch
+	}{
+		{name: "missing mode", req: presentations.CreateTransactionBatch{Transfers: []presentations.CreateTransaction{transfer}}},
+		{name: "unknown mode", req: presentations.CreateTransactionBatch{Mode: "eventual", Transfers: []presentations.CreateTransaction{transfer}}},
+		{name: "empty", req: presentations.CreateTransactionBatch{Mode: consts.BatchModeAtomic}},
+		{name: "too large", req: presentations.CreateTransactionBatch{Mode: consts.BatchModeAtomic, Transfers: make([]presentations.CreateTransaction, maxBatchTransfers+1)}},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SubmitTransactionBatch(staffCtx(), tt.req)
+			assert.ErrorIs(t, err, ErrInvalidBatch)
+		})
+	}
+}
+
+func newBatchMocks(t *testing.T) (*mocks.MockWalletRepository, Wallet) {
+	mockCtl := gomock.NewController(t)
+	t.Cleanup(mockCtl.Finish)
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	mRepo.EXPECT().GetAccount(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id int) (repository.Account, error) {
+		return repository.Account{ID: id, AccountID: id, Currency: consts.DefaultCurrency, Balance: utils.NewDecimal(100)}, nil
+	}).AnyTimes()
+
+	return mRepo, NewWalletService(mRepo)
+}
+
+func TestSubmitTransactionBatchBestEffort(t *testing.T) {
+	mRepo, svc := newBatchMocks(t)
+	ctx := staffCtx()
+
+	mRepo.EXPECT().SubmitTransaction(ctx, gomock.Any()).Return(nil)
+	mRepo.EXPECT().SubmitTransaction(ctx, gomock.Any()).Return(repository.ErrInsufficientBalance)
+
+	result, err := svc.SubmitTransactionBatch(ctx, presentations.CreateTransactionBatch{
+		Mode: consts.BatchModeBestEffort,
+		Transfers: []presentations.CreateTransaction{
+			{SourceAccountID: 1, DestinationAccountID: 2, Amount: "10"},
+			{SourceAccountID: 1, DestinationAccountID: 1, Amount: "10"},
+			{SourceAccountID: 1, DestinationAccountID: 3, Amount: "90"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 2, result.Failed)
+	require.Len(t, result.Items, 3)
+
+	assert.Equal(t, consts.BatchItemCompleted, result.Items[0].Status)
+	assert.Equal(t, "10", result.Items[0].Transaction.Amount.String())
+	assert.Equal(t, consts.BatchItemFailed, result.Items[1].Status)
+	assert.Equal(t, ErrSameAccount.Code, result.Items[1].Error.ErrorCode)
+	assert.Equal(t, 2, result.Items[2].Index)
+	assert.Equal(t, ErrInsufficientFunds.Code, result.Items[2].Error.ErrorCode)
+}
+
+func TestSubmitTransactionBatchAtomic(t *testing.T) {
+	ctx := staffCtx()
+	replayed := repository.Transaction{
+		ID:                 "5f0c2f5e-6a3b-4d8e-9c1a-2b7d4e6f8a90",
+		ReferenceNumber:    "payroll-0",
+		Type:               consts.TransactionTypeTransfer,
+		Amount:             utils.NewDecimal(10),
+		RequestFingerprint: requestFingerprint(consts.TransactionTypeTransfer, 1, 2, utils.NewDecimal(10)),
+	}
+	transfers := []presentations.CreateTransaction{
+		{SourceAccountID: 1, DestinationAccountID: 2, Amount: "10", ReferenceNumber: "payroll-0"},
+		{SourceAccountID: 1, DestinationAccountID: 3, Amount: "20", ReferenceNumber: "payroll-1"},
+		{SourceAccountID: 1, DestinationAccountID: 4, Amount: "30", ReferenceNumber: "payroll-2"},
+	}
+
+	replays := func(mRepo *mocks.MockWalletRepository) {
+		mRepo.EXPECT().GetTransactionByReference(ctx, "payroll-0").Return(replayed, nil)
+		mRepo.EXPECT().GetTransactionByReference(ctx, "payroll-1").Return(repository.Transaction{}, nil)
+		mRepo.EXPECT().GetTransactionByReference(ctx, "payroll-2").Return(repository.Transaction{}, nil)
+	}
+
+	t.Run("SUCCESS", func(t *testing.T) {
+		mRepo, svc := newBatchMocks(t)
+		replays(mRepo)
+		mRepo.EXPECT().SubmitTransactions(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, payloads []repository.TransactionPayload) error {
+			require.Len(t, payloads, 2)
+			assert.Equal(t, "payroll-1", payloads[0].Transaction.ReferenceNumber)
+			assert.Equal(t, 4, payloads[1].To.AccountID)
+			return nil
+		})
+
+		result, err := svc.SubmitTransactionBatch(ctx, presentations.CreateTransactionBatch{Mode: consts.BatchModeAtomic, Transfers: transfers})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Succeeded)
+		assert.Equal(t, replayed.ID, result.Items[0].Transaction.ID)
+		assert.Equal(t, "payroll-2", result.Items[2].Transaction.ReferenceNumber)
+	})
+
+	t.Run("FAILED item booked", func(t *testing.T) {
+		mRepo, svc := newBatchMocks(t)
+		replays(mRepo)
+		mRepo.EXPECT().SubmitTransactions(ctx, gomock.Any()).Return(&repository.BatchItemError{Index: 1, Err: repository.ErrInsufficientBalance})
+
+		_, err := svc.SubmitTransactionBatch(ctx, presentations.CreateTransactionBatch{Mode: consts.BatchModeAtomic, Transfers: transfers})
+		assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+		var e *Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, "2", e.Details["index"])
+	})
+
+	t.Run("FAILED item validation", func(t *testing.T) {
+		_, svc := newBatchMocks(t)
+
+		_, err := svc.SubmitTransactionBatch(ctx, presentations.CreateTransactionBatch{
+			Mode: consts.BatchModeAtomic,
+			Transfers: []presentations.CreateTransaction{
+				{SourceAccountID: 1, DestinationAccountID: 2, Amount: "10"},
+				{SourceAccountID: 1, DestinationAccountID: 2, Amount: "abc"},
+			},
+		})
+		assert.ErrorIs(t, err, ErrInvalidAmount)
+
+		var e *Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, "1", e.Details["index"])
+	})
+
+	t.Run("FAILED duplicate reference", func(t *testing.T) {
+		_, svc := newBatchMocks(t)
+
+		_, err := svc.SubmitTransactionBatch(ctx, presentations.CreateTransactionBatch{
+			Mode:      consts.BatchModeAtomic,
+			Transfers: append(transfers, transfers[1]),
+		})
+		assert.ErrorIs(t, err, ErrInvalidBatch)
+	})
+
+	t.Run("FAILED concurrent reference", func(t *testing.T) {
+		mRepo, svc := newBatchMocks(t)
+		replays(mRepo)
+		mRepo.EXPECT().SubmitTransactions(ctx, gomock.Any()).Return(&repository.BatchItemError{Index: 0, Err: repository.ErrDuplicateReference})
+
+		_, err := svc.SubmitTransactionBatch(ctx, presentations.CreateTransactionBatch{Mode: consts.BatchModeAtomic, Transfers: transfers})
+		assert.ErrorIs(t, err, ErrRetryable)
+	})
+}
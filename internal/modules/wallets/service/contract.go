@@ -4,10 +4,54 @@ import (
 	"context"
 
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
 )
 
 type Wallet interface {
 	CreateAccount(ctx context.Context, req presentations.CreateAccount) error
 	GetAccount(ctx context.Context, accountID int) (presentations.Account, error)
-	SubmitTransaction(ctx context.Context, req presentations.CreateTransaction) error
+	SubmitTransaction(ctx context.Context, req presentations.CreateTransaction) (presentations.Transaction, error)
+	SubmitTransactionBatch(ctx context.Context, req presentations.CreateTransactionBatch) (presentations.TransactionBatch, error)
+	GetTransaction(ctx context.Context, id string) (presentations.Transaction, error)
+	GetTransactionByReference(ctx context.Context, referenceNumber string) (presentations.Transaction, error)
+	ReverseTransaction(ctx context.Context, id string, req presentations.CreateReversal) (presentations.Transaction, error)
+	CreateHold(ctx context.Context, req presentations.CreateHold) (presentations.Hold, error)
+	GetHold(ctx context.Context, id string) (presentations.Hold, error)
+	CaptureHold(ctx context.Context, id string, req presentations.CaptureHold) (presentations.Transaction, error)
+	VoidHold(ctx context.Context, id string) (presentations.Hold, error)
+	ExpireHolds(ctx context.Context) (int, error)
+	FreezeAccount(ctx context.Context, accountID int, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error)
+	UnfreezeAccount(ctx context.Context, accountID int, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error)
+	CloseAccount(ctx context.Context, accountID int, req presentations.ChangeAccountStatus) (presentations.AccountStatusChange, error)
+	ListAccountStatusChanges(ctx context.Context, accountID int) ([]presentations.AccountStatusChange, error)
+	GetAccountLimits(ctx context.Context, accountID int) (presentations.AccountLimits, error)
+	SetAccountLimits(ctx context.Context, accountID int, req presentations.SetAccountLimits) (presentations.AccountLimits, error)
+	Withdraw(ctx context.Context, req presentations.CreateWithdrawal) error
+	Deposit(ctx context.Context, accountID int, req presentations.CreateDeposit) error
+	ListAccountTransactions(ctx context.Context, accountID int, req presentations.ListAccountTransactions) (presentations.AccountTransactions, error)
+	PostJournal(ctx context.Context, req presentations.CreateJournal) (presentations.Transaction, error)
+	CreateScheduledTransfer(ctx context.Context, req presentations.CreateScheduledTransfer) (presentations.ScheduledTransfer, error)
+	GetScheduledTransfer(ctx context.Context, id string) (presentations.ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, req presentations.ListScheduledTransfers) ([]presentations.ScheduledTransfer, error)
+	UpdateScheduledTransfer(ctx context.Context, id string, req presentations.UpdateScheduledTransfer) (presentations.ScheduledTransfer, error)
+	CancelScheduledTransfer(ctx context.Context, id string) (presentations.ScheduledTransfer, error)
+	RunScheduledTransfers(ctx context.Context) (int, error)
+	CreateWebhook(ctx context.Context, req presentations.CreateWebhook) (presentations.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (presentations.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]presentations.Webhook, error)
+	UpdateWebhook(ctx context.Context, id string, req presentations.UpdateWebhook) (presentations.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhookDeliveries(ctx context.Context, id string, req presentations.ListWebhookDeliveries) ([]presentations.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, id, deliveryID string) (presentations.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, id, deliveryID string) (presentations.WebhookDelivery, error)
+	RunWebhookDeliveries(ctx context.Context) (int, error)
+	CreateCustomer(ctx context.Context, req presentations.CreateCustomer) (presentations.Customer, error)
+	GetCustomer(ctx context.Context, id string) (presentations.Customer, error)
+	SetAccountOwner(ctx context.Context, accountID int, req presentations.SetAccountOwner) (presentations.Account, error)
+}
+
+// FXRateProvider quotes exchange rates for cross-currency transfers. Rate
+// returns how many units of quote one unit of base buys.
+type FXRateProvider interface {
+	Rate(ctx context.Context, base, quote string) (utils.Decimal, error)
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+// defaultCurrencies is the currency table used when none is configured.
+var defaultCurrencies = map[string]int{
+	consts.DefaultCurrency: 2,
+}
+
+// normalizeCurrency upper-cases an ISO 4217 code and falls back to
+// consts.DefaultCurrency when it is empty.
+func normalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return consts.DefaultCurrency
+	}
+
+	return code
+}
+
+// validateCurrencyAmount checks that currency is in the currency table and
+// that amount is a whole number of its minor units.
+func (s *wallet) validateCurrencyAmount(currency string, amount utils.Decimal) error {
+	scale, ok := s.currencies[currency]
+	if !ok {
+		return ErrUnsupportedCurrency
+	}
+
+	if !amount.FitsScale(scale) {
+		return ErrAmountScale
+	}
+
+	return nil
+}
+
+// validateAccountAmount looks the account up, authorizes the caller on it,
+// checks amount against its currency and returns the account. Authorizing
+// first keeps a customer from probing the currency of another's account.
+func (s *wallet) validateAccountAmount(ctx context.Context, logPrefix string, accountID int, amount utils.Decimal) (repository.Account, error) {
+	acc, err := s.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetAccount", slog.Any("err", err))
+		return repository.Account{}, wrapError(err)
+	}
+
+	if acc.ID == 0 {
+		slog.Warn(logPrefix+" failed data not found", slog.Any("accountID", accountID))
+		return repository.Account{}, ErrAccountNotFound
+	}
+
+	if err := authorizeAccount(ctx, logPrefix, acc); err != nil {
+		return repository.Account{}, err
+	}
+
+	if err := s.validateCurrencyAmount(acc.Currency, amount); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.String("currency", acc.Currency), slog.Any("err", err))
+		return repository.Account{}, err
+	}
+
+	return acc, nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+)
+
+// maxCustomerName bounds the name of a customer.
+const maxCustomerName = 255
+
+func (s *wallet) CreateCustomer(ctx context.Context, req presentations.CreateCustomer) (presentations.Customer, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxCustomerName {
+		slog.Warn("[CreateCustomer] failed validation", slog.String("name", req.Name))
+		return presentations.Customer{}, validationError(ErrInvalidCustomer, "name is required and at most 255 characters")
+	}
+
+	customer := repository.Customer{
+		ID:        uuid.NewString(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.CreateCustomer(ctx, customer); err != nil {
+		slog.Warn("[CreateCustomer] failed CreateCustomer", slog.Any("err", err))
+		return presentations.Customer{}, wrapError(err)
+	}
+
+	slog.Info("[CreateCustomer] success", slog.String("id", customer.ID))
+	return presentations.Customer{
+		ID:         customer.ID,
+		Name:       customer.Name,
+		AccountIDs: []int{},
+		CreatedAt:  customer.CreatedAt,
+	}, nil
+}
+
+// GetCustomer returns customer id with the accounts it owns.
+func (s *wallet) GetCustomer(ctx context.Context, id string) (presentations.Customer, error) {
+	customer, err := s.getCustomer(ctx, "[GetCustomer]", id)
+	if err != nil {
+		return presentations.Customer{}, err
+	}
+
+	accountIDs, err := s.repo.ListCustomerAccounts(ctx, customer.ID)
+	if err != nil {
+		slog.Warn("[GetCustomer] failed ListCustomerAccounts", slog.Any("err", err))
+		return presentations.Customer{}, wrapError(err)
+	}
+
+	if accountIDs == nil {
+		accountIDs = []int{}
+	}
+
+	return presentations.Customer{
+		ID:         customer.ID,
+		Name:       customer.Name,
+		AccountIDs: accountIDs,
+		CreatedAt:  customer.CreatedAt,
+	}, nil
+}
+
+// SetAccountOwner hands an account to another customer, e.g. one opened
+// before accounts had owners.
+func (s *wallet) SetAccountOwner(ctx context.Context, accountID int, req presentations.SetAccountOwner) (presentations.Account, error) {
+	if err := validateAccountID(accountID); err != nil {
+		slog.Warn("[SetAccountOwner] failed validation", slog.Any("err", err))
+		return presentations.Account{}, wrapError(err)
+	}
+
+	if req.CustomerID != "" {
+		if _, err := s.getCustomer(ctx, "[SetAccountOwner]", req.CustomerID); err != nil {
+			return presentations.Account{}, err
+		}
+	}
+
+	ok, err := s.repo.SetAccountCustomer(ctx, accountID, req.CustomerID)
+	if err != nil {
+		slog.Warn("[SetAccountOwner] failed SetAccountCustomer", slog.Any("err", err))
+		return presentations.Account{}, wrapError(err)
+	}
+
+	if !ok {
+		slog.Warn("[SetAccountOwner] failed data not found", slog.Any("accountID", accountID))
+		return presentations.Account{}, ErrAccountNotFound
+	}
+
+	slog.Info("[SetAccountOwner] success", slog.Any("accountID", accountID), slog.String("customer_id", req.CustomerID))
+	return s.GetAccount(ctx, accountID)
+}
+
+func (s *wallet) getCustomer(ctx context.Context, logPrefix, id string) (repository.Customer, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.String("customer_id", id))
+		return repository.Customer{}, ErrInvalidCustomerID
+	}
+
+	customer, err := s.repo.GetCustomer(ctx, id)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetCustomer", slog.Any("err", err))
+		return repository.Customer{}, wrapError(err)
+	}
+
+	if customer.ID == "" {
+		slog.Warn(logPrefix+" failed data not found", slog.String("customer_id", id))
+		return repository.Customer{}, ErrCustomerNotFound
+	}
+
+	return customer, nil
+}
+
+// accountOwner resolves the owner of a new account. Customers open empty
+// accounts for themselves, as an opening balance is funded from the system
+// funding account; other callers name the owner, if any.
+func (s *wallet) accountOwner(ctx context.Context, logPrefix, customerID string, initialBalance utils.Decimal) (string, error) {
+	if p, ok := customerPrincipal(ctx); ok {
+		if customerID == "" {
+			customerID = p.Subject
+		}
+
+		if !ownedBy(p, customerID) {
+			slog.Warn(logPrefix+" failed authorization", slog.String("customer_id", customerID), slog.String("subject", p.Subject))
+			return "", validationError(ErrAccountForbidden, "customers can only open accounts for themselves")
+		}
+
+		if !initialBalance.IsZero() {
+			slog.Warn(logPrefix+" failed authorization", slog.String("initial_balance", initialBalance.String()), slog.String("subject", p.Subject))
+			return "", validationError(ErrAccountForbidden, "customers can only open accounts with a zero initial_balance")
+		}
+	}
+
+	if customerID == "" {
+		return "", nil
+	}
+
+	if _, err := s.getCustomer(ctx, logPrefix, customerID); err != nil {
+		return "", err
+	}
+
+	return customerID, nil
+}
+
+// customerPrincipal returns the principal of ctx when it is restricted to
+// its own accounts. Operators and admins act on any account for support
+// tooling, and so do the workers with the system principal. A context
+// without a principal is restricted like a customer owning nothing.
+func customerPrincipal(ctx context.Context) (auth.Principal, bool) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return auth.Principal{Role: consts.RoleCustomer}, true
+	}
+
+	switch p.Role {
+	case consts.RoleOperator, consts.RoleAdmin, consts.RoleSystem:
+		return auth.Principal{}, false
+	}
+
+	return p, true
+}
+
+// ownedBy reports whether customerID is p's own id.
+func ownedBy(p auth.Principal, customerID string) bool {
+	return p.Subject != "" && customerID == p.Subject
+}
+
+// SystemContext returns ctx carrying the principal of the background
+// workers, which act on any account.
+func SystemContext(ctx context.Context) context.Context {
+	return auth.NewContext(ctx, auth.Principal{Subject: consts.RoleSystem, Role: consts.RoleSystem})
+}
+
+// authorizeAccount refuses a customer acting on account unless it owns it.
+func authorizeAccount(ctx context.Context, logPrefix string, account repository.Account) error {
+	p, ok := customerPrincipal(ctx)
+	if !ok || ownedBy(p, account.CustomerID) {
+		return nil
+	}
+
+	slog.Warn(logPrefix+" failed authorization", slog.Any("accountID", account.AccountID), slog.String("subject", p.Subject))
+	return ErrAccountForbidden
+}
+
+// authorizeAccountID is authorizeAccount for an account not loaded yet. It
+// only reads the account for customers.
+func (s *wallet) authorizeAccountID(ctx context.Context, logPrefix string, accountID int) error {
+	if _, ok := customerPrincipal(ctx); !ok {
+		return nil
+	}
+
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetAccount", slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	if account.ID == 0 {
+		slog.Warn(logPrefix+" failed data not found", slog.Any("accountID", accountID))
+		return ErrAccountNotFound
+	}
+
+	return authorizeAccount(ctx, logPrefix, account)
+}
+
+// authorizeTransaction lets a customer see a transaction when it owns its
+// source or its destination account.
+func (s *wallet) authorizeTransaction(ctx context.Context, logPrefix string, trx repository.Transaction) error {
+	p, ok := customerPrincipal(ctx)
+	if !ok {
+		return nil
+	}
+
+	for _, id := range []sql.NullInt64{trx.FromAccountID, trx.ToAccountID} {
+		if !id.Valid || id.Int64 < 1 {
+			continue
+		}
+
+		account, err := s.repo.GetAccount(ctx, int(id.Int64))
+		if err != nil {
+			slog.Warn(logPrefix+" failed GetAccount", slog.Any("err", err))
+			return wrapError(err)
+		}
+
+		if account.ID != 0 && ownedBy(p, account.CustomerID) {
+			return nil
+		}
+	}
+
+	slog.Warn(logPrefix+" failed authorization", slog.String("id", trx.ID), slog.String("subject", p.Subject))
+	return ErrAccountForbidden
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testCustomerID  = "5b0f8a8e-2f1c-4c7e-9a3e-8d1f6b2a4c01"
+	otherCustomerID = "7c2e9b1d-4a3f-4e8b-b5d6-1f0a2c3e4d02"
+)
+
+func customerCtx(subject string) context.Context {
+	return auth.NewContext(context.TODO(), auth.Principal{Subject: subject, Role: consts.RoleCustomer})
+}
+
+// staffCtx is the caller of tests not about ownership.
+func staffCtx() context.Context {
+	return auth.NewContext(context.TODO(), auth.Principal{Subject: "support", Role: consts.RoleOperator})
+}
+
+func TestGetAccountOwnership(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	operatorCtx := auth.NewContext(context.TODO(), auth.Principal{Subject: "support", Role: consts.RoleOperator})
+
+	testTables := []struct {
+		name    string
+		ctx     context.Context
+		account repository.Account
+		err     error
+	}{
+		{
+			name:    "SUCCESS owner",
+			ctx:     customerCtx(testCustomerID),
+			account: repository.Account{ID: 1, AccountID: 2, CustomerID: testCustomerID},
+		},
+		{
+			name:    "FAILED account of another customer",
+			ctx:     customerCtx(otherCustomerID),
+			account: repository.Account{ID: 1, AccountID: 2, CustomerID: testCustomerID},
+			err:     ErrAccountForbidden,
+		},
+		{
+			name:    "FAILED account without owner",
+			ctx:     customerCtx(testCustomerID),
+			account: repository.Account{ID: 1, AccountID: 2},
+			err:     ErrAccountForbidden,
+		},
+		{
+			name:    "SUCCESS operator bypasses ownership",
+			ctx:     operatorCtx,
+			account: repository.Account{ID: 1, AccountID: 2, CustomerID: testCustomerID},
+		},
+	}
+
+	svc := NewWalletService(mRepo)
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo.EXPECT().GetAccount(tt.ctx, 2).Return(tt.account, nil)
+
+			resp, err := svc.GetAccount(tt.ctx, 2)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, tt.account.CustomerID, resp.CustomerID)
+			}
+		})
+	}
+}
+
+func TestSubmitTransactionOwnership(t *testing.T) {
+	source := repository.Account{ID: 1, AccountID: 3, CustomerID: testCustomerID, Currency: consts.DefaultCurrency, Balance: utils.NewDecimal(50)}
+	destination := repository.Account{ID: 2, AccountID: 2, CustomerID: otherCustomerID, Currency: consts.DefaultCurrency, Balance: utils.NewDecimal(50)}
+	req := presentations.CreateTransaction{
+		SourceAccountID:      3,
+		DestinationAccountID: 2,
+		Amount:               "10",
+		ReferenceNumber:      "key-1",
+	}
+
+	t.Run("FAILED source of another customer", func(t *testing.T) {
+		mockCtl := gomock.NewController(t)
+		defer mockCtl.Finish()
+		mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+		// refused before the reference_number is looked up
+		ctx := customerCtx(otherCustomerID)
+		mRepo.EXPECT().GetAccount(ctx, 3).Return(source, nil)
+
+		_, err := NewWalletService(mRepo).SubmitTransaction(ctx, req)
+		assert.ErrorIs(t, err, ErrAccountForbidden)
+	})
+
+	t.Run("SUCCESS owner of the source", func(t *testing.T) {
+		mockCtl := gomock.NewController(t)
+		defer mockCtl.Finish()
+		mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+		ctx := customerCtx(testCustomerID)
+		mRepo.EXPECT().GetAccount(ctx, 3).Return(source, nil).Times(2)
+		mRepo.EXPECT().GetTransactionByReference(ctx, "key-1").Return(repository.Transaction{}, nil)
+		mRepo.EXPECT().GetAccount(ctx, 2).Return(destination, nil)
+		mRepo.EXPECT().SubmitTransaction(ctx, gomock.AssignableToTypeOf(repository.TransactionPayload{})).Return(nil)
+
+		resp, err := NewWalletService(mRepo).SubmitTransaction(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, 3, *resp.SourceAccountID)
+	})
+}
+
+func TestWithdrawOwnership(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	// a JPY account takes no fractional amounts; a stranger must not learn
+	// that from the error
+	ctx := customerCtx(otherCustomerID)
+	mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{ID: 1, AccountID: 3, CustomerID: testCustomerID, Currency: "JPY"}, nil)
+
+	svc := NewWalletService(mRepo, WithCurrencies(map[string]int{"USD": 2, "JPY": 0}))
+	err := svc.Withdraw(ctx, presentations.CreateWithdrawal{AccountID: 3, Amount: "10.5"})
+	assert.ErrorIs(t, err, ErrAccountForbidden)
+}
+
+func TestGetTransactionOwnership(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	trx := repository.Transaction{
+		ID:            "f5a1b2c3-d4e5-4f60-8a7b-9c0d1e2f3a04",
+		Type:          consts.TransactionTypeTransfer,
+		FromAccountID: sql.NullInt64{Int64: 3, Valid: true},
+		ToAccountID:   sql.NullInt64{Int64: 2, Valid: true},
+	}
+
+	svc := NewWalletService(mRepo)
+
+	t.Run("SUCCESS owner of the destination", func(t *testing.T) {
+		ctx := customerCtx(testCustomerID)
+		mRepo.EXPECT().GetTransaction(ctx, trx.ID).Return(trx, nil)
+		mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{ID: 1, AccountID: 3, CustomerID: otherCustomerID}, nil)
+		mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{ID: 2, AccountID: 2, CustomerID: testCustomerID}, nil)
+		mRepo.EXPECT().GetTransactionLedgerEntries(ctx, trx.ID).Return(nil, nil)
+
+		resp, err := svc.GetTransaction(ctx, trx.ID)
+		require.NoError(t, err)
+		assert.Equal(t, trx.ID, resp.ID)
+	})
+
+	t.Run("FAILED stranger", func(t *testing.T) {
+		ctx := customerCtx("0d9e8f7a-6b5c-4d3e-a2f1-0e9d8c7b6a05")
+		mRepo.EXPECT().GetTransaction(ctx, trx.ID).Return(trx, nil)
+		mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{ID: 1, AccountID: 3, CustomerID: otherCustomerID}, nil)
+		mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{ID: 2, AccountID: 2, CustomerID: testCustomerID}, nil)
+
+		_, err := svc.GetTransaction(ctx, trx.ID)
+		assert.ErrorIs(t, err, ErrAccountForbidden)
+	})
+}
+
+func TestCreateAccountOwner(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	svc := NewWalletService(mRepo)
+
+	t.Run("FAILED customer opening for another", func(t *testing.T) {
+		err := svc.CreateAccount(customerCtx(testCustomerID), presentations.CreateAccount{
+			AccountID:      7,
+			InitialBalance: "0",
+			CustomerID:     otherCustomerID,
+		})
+		assert.ErrorIs(t, err, ErrAccountForbidden)
+	})
+
+	t.Run("FAILED customer funding its new account", func(t *testing.T) {
+		err := svc.CreateAccount(customerCtx(testCustomerID), presentations.CreateAccount{
+			AccountID:      7,
+			InitialBalance: "10",
+		})
+		assert.ErrorIs(t, err, ErrAccountForbidden)
+	})
+
+	t.Run("FAILED unknown customer", func(t *testing.T) {
+		ctx := staffCtx()
+		mRepo.EXPECT().GetCustomer(ctx, otherCustomerID).Return(repository.Customer{}, nil)
+
+		err := svc.CreateAccount(ctx, presentations.CreateAccount{
+			AccountID:      7,
+			InitialBalance: "10",
+			CustomerID:     otherCustomerID,
+		})
+		assert.ErrorIs(t, err, ErrCustomerNotFound)
+	})
+
+	t.Run("SUCCESS customer owns its new account", func(t *testing.T) {
+		ctx := customerCtx(testCustomerID)
+		mRepo.EXPECT().GetCustomer(ctx, testCustomerID).Return(repository.Customer{ID: testCustomerID, Name: "Ada"}, nil)
+		mRepo.EXPECT().GetAccount(ctx, 7).Return(repository.Account{}, nil)
+		mRepo.EXPECT().CreateAccount(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, payload repository.DepositPayload) error {
+			assert.Equal(t, testCustomerID, payload.Account.CustomerID)
+			return nil
+		})
+
+		err := svc.CreateAccount(ctx, presentations.CreateAccount{AccountID: 7, InitialBalance: "0"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestCreateCustomer(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	svc := NewWalletService(mRepo)
+
+	_, err := svc.CreateCustomer(ctx, presentations.CreateCustomer{Name: "  "})
+	assert.ErrorIs(t, err, ErrInvalidCustomer)
+
+	mRepo.EXPECT().CreateCustomer(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, c repository.Customer) error {
+		assert.Equal(t, "Ada Lovelace", c.Name)
+		return nil
+	})
+
+	got, err := svc.CreateCustomer(ctx, presentations.CreateCustomer{Name: " Ada Lovelace "})
+	require.NoError(t, err)
+	assert.NotEmpty(t, got.ID)
+	assert.Empty(t, got.AccountIDs)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+)
+
+// ErrorKind groups domain errors by how a caller should react to them. The
+// delivery layer maps each kind to a transport status.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindInsufficientFunds
+	KindLimitExceeded
+	KindRetryable
+	KindForbidden
+)
+
+// Error is returned by every Wallet method. Code is a stable machine readable
+// identifier and Message is safe to show to clients; the underlying cause is
+// kept for logging through errors.Unwrap and never rendered by Error.
+// Details, when set, carries client-safe specifics such as the breached
+// limit rule.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Details map[string]string
+	cause   error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches any *Error with the same Code, so errors.Is(err, ErrAccountNotFound)
+// holds whatever cause is attached.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func (e *Error) withCause(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+var (
+	ErrInternal = newError(KindInternal, "INTERNAL_ERROR", "internal server error")
+
+	ErrInvalidAccountID       = newError(KindValidation, "INVALID_ACCOUNT_ID", "invalid account_id format")
+	ErrInvalidAmount          = newError(KindValidation, "INVALID_AMOUNT", "invalid amount format")
+	ErrAmountTooSmall         = newError(KindValidation, "AMOUNT_TOO_SMALL", "amount is below the minimum allowed")
+	ErrSameAccount            = newError(KindValidation, "SAME_ACCOUNT", "source and destination account must differ")
+	ErrInvalidReferenceNumber = newError(KindValidation, "INVALID_REFERENCE_NUMBER", "invalid reference_number format")
+	ErrReferenceRequired      = newError(KindValidation, "REFERENCE_NUMBER_REQUIRED", "reference_number is required")
+	ErrInvalidTransactionID   = newError(KindValidation, "INVALID_TRANSACTION_ID", "invalid transaction id format")
+	ErrInvalidHoldID          = newError(KindValidation, "INVALID_HOLD_ID", "invalid hold id format")
+	ErrInvalidExpiry          = newError(KindValidation, "INVALID_EXPIRES_AT", "expires_at must be an RFC 3339 timestamp in the future")
+	ErrActorRequired          = newError(KindValidation, "ACTOR_REQUIRED", "an authenticated actor is required")
+	ErrReasonRequired         = newError(KindValidation, "REASON_REQUIRED", "reason is required")
+	ErrSweepNotAllowed        = newError(KindValidation, "SWEEP_NOT_ALLOWED", "sweep_to_account_id is only accepted when closing an account")
+	ErrUnsupportedCurrency    = newError(KindValidation, "UNSUPPORTED_CURRENCY", "currency is not supported")
+	ErrCurrencyMismatch       = newError(KindValidation, "CURRENCY_MISMATCH", "source and destination accounts have different currencies")
+	ErrAmountScale            = newError(KindValidation, "INVALID_AMOUNT_SCALE", "amount has more decimal places than the currency allows")
+	ErrFXRateUnavailable      = newError(KindValidation, "FX_RATE_UNAVAILABLE", "no exchange rate for the currency pair")
+	ErrInvalidAccountLimits   = newError(KindValidation, "INVALID_ACCOUNT_LIMITS", "account limits must not be negative")
+	ErrInvalidLimit           = newError(KindValidation, "INVALID_LIMIT", "limit must be between 1 and 100")
+	ErrInvalidFilter          = newError(KindValidation, "INVALID_FILTER", "invalid filter")
+	ErrInvalidCursor          = newError(KindValidation, "INVALID_CURSOR", "invalid cursor")
+	ErrInvalidJournal         = newError(KindValidation, "INVALID_JOURNAL", "invalid journal legs")
+	ErrUnbalancedJournal      = newError(KindValidation, "JOURNAL_NOT_BALANCED", "journal debits must equal credits")
+	ErrInvalidBatch           = newError(KindValidation, "INVALID_BATCH", "invalid transfer batch")
+	ErrInvalidSchedule        = newError(KindValidation, "INVALID_SCHEDULE", "invalid scheduled transfer")
+	ErrInvalidScheduleID      = newError(KindValidation, "INVALID_SCHEDULE_ID", "invalid scheduled transfer id format")
+	ErrInvalidWebhook         = newError(KindValidation, "INVALID_WEBHOOK", "invalid webhook")
+	ErrInvalidWebhookID       = newError(KindValidation, "INVALID_WEBHOOK_ID", "invalid webhook id format")
+	ErrInvalidDeliveryID      = newError(KindValidation, "INVALID_DELIVERY_ID", "invalid webhook delivery id format")
+	ErrInvalidCustomer        = newError(KindValidation, "INVALID_CUSTOMER", "invalid customer")
+	ErrInvalidCustomerID      = newError(KindValidation, "INVALID_CUSTOMER_ID", "invalid customer id format")
+
+	ErrAccountNotFound     = newError(KindNotFound, "ACCOUNT_NOT_FOUND", "account not found")
+	ErrTransactionNotFound = newError(KindNotFound, "TRANSACTION_NOT_FOUND", "transaction not found")
+	ErrHoldNotFound        = newError(KindNotFound, "HOLD_NOT_FOUND", "hold not found")
+	ErrScheduleNotFound    = newError(KindNotFound, "SCHEDULE_NOT_FOUND", "scheduled transfer not found")
+	ErrWebhookNotFound     = newError(KindNotFound, "WEBHOOK_NOT_FOUND", "webhook not found")
+	ErrDeliveryNotFound    = newError(KindNotFound, "WEBHOOK_DELIVERY_NOT_FOUND", "webhook delivery not found")
+	ErrCustomerNotFound    = newError(KindNotFound, "CUSTOMER_NOT_FOUND", "customer not found")
+
+	ErrAccountExists       = newError(KindConflict, "ACCOUNT_ALREADY_EXISTS", "account already exists")
+	ErrIdempotencyConflict = newError(KindConflict, "IDEMPOTENCY_KEY_REUSED", "idempotency key already used with a different request")
+	ErrNotReversible       = newError(KindConflict, "TRANSACTION_NOT_REVERSIBLE", "transaction cannot be reversed")
+	ErrReversalExceeded    = newError(KindConflict, "REVERSAL_AMOUNT_EXCEEDED", "reversal amount exceeds remaining amount")
+	ErrHoldNotActive       = newError(KindConflict, "HOLD_NOT_ACTIVE", "hold is not active")
+	ErrCaptureExceeded     = newError(KindConflict, "CAPTURE_AMOUNT_EXCEEDED", "capture amount exceeds held amount")
+	ErrAccountFrozen       = newError(KindConflict, "ACCOUNT_FROZEN", "account is frozen")
+	ErrAccountClosed       = newError(KindConflict, "ACCOUNT_CLOSED", "account is closed")
+	ErrStatusTransition    = newError(KindConflict, "INVALID_STATUS_TRANSITION", "account status change not allowed")
+	ErrBalanceNotZero      = newError(KindConflict, "ACCOUNT_BALANCE_NOT_ZERO", "account balance must be zero to close it")
+	ErrActiveHolds         = newError(KindConflict, "ACCOUNT_HAS_ACTIVE_HOLDS", "account has active holds")
+	ErrScheduleNotActive   = newError(KindConflict, "SCHEDULE_NOT_ACTIVE", "scheduled transfer is not active")
+
+	ErrInsufficientFunds = newError(KindInsufficientFunds, "INSUFFICIENT_FUNDS", "sender balance less than amount")
+
+	ErrLimitExceeded = newError(KindLimitExceeded, "LIMIT_EXCEEDED", "amount exceeds the account limits")
+
+	// ErrAccountForbidden refuses a customer acting on an account owned by
+	// someone else, or by no one.
+	ErrAccountForbidden = newError(KindForbidden, "ACCOUNT_FORBIDDEN", "account belongs to another customer")
+
+	// ErrRetryable means the request lost a race with a concurrent transaction
+	// (deadlock or serialization failure) and can be sent again unchanged.
+	ErrRetryable = newError(KindRetryable, "CONCURRENT_UPDATE", "request conflicted with a concurrent update, please retry")
+)
+
+// validationError returns a validation error sharing base's code with a more
+// specific message.
+func validationError(base *Error, message string) *Error {
+	e := *base
+	e.Message = message
+	return &e
+}
+
+// limitError reports the limit rule a movement breached. Falling short of
+// the minimum amount is reported as ErrAmountTooSmall, every other rule as
+// ErrLimitExceeded.
+func limitError(err *repository.LimitError) *Error {
+	base := ErrLimitExceeded
+	if err.Rule == repository.LimitMinAmount {
+		base = ErrAmountTooSmall
+	}
+
+	e := validationError(base, err.Error()).withCause(err)
+	e.Details = map[string]string{"rule": err.Rule, "limit": err.Limit}
+	return e
+}
+
+// wrapError turns any error reaching a Wallet method boundary into an *Error.
+// Errors that already are *Error pass through, known repository and driver
+// errors are mapped to their domain counterpart and everything else becomes
+// ErrInternal with the original error kept as the cause.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	var limitErr *repository.LimitError
+	if errors.As(err, &limitErr) {
+		return limitError(limitErr)
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrDataNotFound):
+		return ErrAccountNotFound.withCause(err)
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return ErrInsufficientFunds.withCause(err)
+	case errors.Is(err, repository.ErrNotReversible):
+		return ErrNotReversible.withCause(err)
+	case errors.Is(err, repository.ErrReversalExceeded):
+		return ErrReversalExceeded.withCause(err)
+	case errors.Is(err, repository.ErrHoldNotActive):
+		return ErrHoldNotActive.withCause(err)
+	case errors.Is(err, repository.ErrCaptureExceeded):
+		return ErrCaptureExceeded.withCause(err)
+	case errors.Is(err, repository.ErrCurrencyMismatch):
+		return ErrCurrencyMismatch.withCause(err)
+	case errors.Is(err, repository.ErrAccountFrozen):
+		return ErrAccountFrozen.withCause(err)
+	case errors.Is(err, repository.ErrAccountClosed):
+		return ErrAccountClosed.withCause(err)
+	case errors.Is(err, repository.ErrStatusTransition):
+		return ErrStatusTransition.withCause(err)
+	case errors.Is(err, repository.ErrBalanceNotZero):
+		return ErrBalanceNotZero.withCause(err)
+	case errors.Is(err, repository.ErrActiveHolds):
+		return ErrActiveHolds.withCause(err)
+	case errors.Is(err, repository.ErrUnbalancedEntries):
+		return ErrUnbalancedJournal.withCause(err)
+	case errors.Is(err, repository.ErrScheduleNotActive):
+		return ErrScheduleNotActive.withCause(err)
+	case errors.Is(err, db.ErrRetryable):
+		return ErrRetryable.withCause(err)
+	case errors.Is(err, utils.ErrDecimalScale):
+		return validationError(ErrInvalidAmount, err.Error()).withCause(err)
+	case errors.Is(err, utils.ErrInvalidDecimal), errors.Is(err, utils.ErrDecimalOverflow):
+		return ErrInvalidAmount.withCause(err)
+	}
+
+	return ErrInternal.withCause(err)
+}
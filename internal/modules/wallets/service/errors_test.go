@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapError(t *testing.T) {
+	sqlErr := errors.New(`pq: relation "accounts" does not exist`)
+
+	testTables := []struct {
+		name    string
+		err     error
+		want    *Error
+		message string
+	}{
+		{name: "domain error passes through", err: ErrAccountExists, want: ErrAccountExists, message: "account already exists"},
+		{name: "repository not found", err: fmt.Errorf("lock: %w", repository.ErrDataNotFound), want: ErrAccountNotFound, message: "account not found"},
+		{name: "repository insufficient balance", err: repository.ErrInsufficientBalance, want: ErrInsufficientFunds, message: "sender balance less than amount"},
+		{name: "repository currency mismatch", err: repository.ErrCurrencyMismatch, want: ErrCurrencyMismatch},
+		{name: "repository unbalanced entries", err: repository.ErrUnbalancedEntries, want: ErrUnbalancedJournal},
+		{name: "repository schedule not active", err: repository.ErrScheduleNotActive, want: ErrScheduleNotActive},
+		{name: "repository limit", err: &repository.LimitError{Rule: repository.LimitDailyAmount, Limit: "5000"}, want: ErrLimitExceeded, message: "daily_amount limit of 5000 exceeded"},
+		{name: "repository minimum", err: &repository.LimitError{Rule: repository.LimitMinAmount, Limit: "1"}, want: ErrAmountTooSmall, message: "min_amount limit of 1 not met"},
+		{name: "retryable", err: &db.RetryableError{Err: errors.New("deadlock detected")}, want: ErrRetryable},
+		{name: "decimal scale", err: utils.ErrDecimalScale, want: ErrInvalidAmount, message: utils.ErrDecimalScale.Error()},
+		{name: "sql error is masked", err: sqlErr, want: ErrInternal, message: "internal server error"},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapError(tt.err)
+
+			var e *Error
+			assert.ErrorAs(t, err, &e)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Equal(t, tt.want.Kind, e.Kind)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.message != "" {
+				assert.Equal(t, tt.message, err.Error())
+			}
+		})
+	}
+
+	assert.NoError(t, wrapError(nil))
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+// FeeSchedule holds the fee charged on each transaction type. Types without
+// a rule are free.
+type FeeSchedule map[consts.TransactionType]FeeRule
+
+// FeeRule prices one transaction type as a flat part plus a percentage of
+// the amount, or by Tiers when set. The result is rounded to the payer's
+// currency and then held between Min and Max; zero Min or Max means no cap.
+type FeeRule struct {
+	Flat    utils.Decimal
+	Percent utils.Decimal
+	Min     utils.Decimal
+	Max     utils.Decimal
+	Tiers   []FeeTier
+}
+
+// FeeTier prices amounts up to and including UpTo. Tiers are tried in
+// order and the first one covering the whole amount applies; a zero UpTo
+// covers any amount.
+type FeeTier struct {
+	UpTo    utils.Decimal
+	Flat    utils.Decimal
+	Percent utils.Decimal
+}
+
+// percentScale is the number of fractional digits a percentage may have so
+// that it converts to an exact Decimal rate.
+const percentScale = utils.DecimalScale - 2
+
+// ValidPercent reports whether p can be used as a fee percentage.
+func ValidPercent(p utils.Decimal) bool {
+	return !p.IsNegative() && p.FitsScale(percentScale)
+}
+
+// price returns the flat part and percentage applying to amount. ok is false
+// when no tier covers it.
+func (r FeeRule) price(amount utils.Decimal) (flat, percent utils.Decimal, ok bool) {
+	if len(r.Tiers) == 0 {
+		return r.Flat, r.Percent, true
+	}
+
+	for _, tier := range r.Tiers {
+		if tier.UpTo.IsZero() || amount.Cmp(tier.UpTo) <= 0 {
+			return tier.Flat, tier.Percent, true
+		}
+	}
+
+	return utils.Decimal{}, utils.Decimal{}, false
+}
+
+// quoteFee prices a movement of amount in currency for trxType. It returns
+// nil when the movement is free.
+func (s *wallet) quoteFee(trxType consts.TransactionType, currency string, amount utils.Decimal) (*presentations.Fee, error) {
+	rule, ok := s.fees[trxType]
+	if !ok {
+		return nil, nil
+	}
+
+	scale, ok := s.currencies[currency]
+	if !ok {
+		return nil, ErrUnsupportedCurrency
+	}
+
+	flat, percent, ok := rule.price(amount)
+	if !ok {
+		return nil, nil
+	}
+
+	one := utils.NewDecimal(1)
+	flat, err := flat.Mul(one, scale)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	// the percentage holds at most percentScale digits, so dropping two
+	// units of scale is exact
+	rate := utils.NewDecimalFromUnits(percent.Units() / 100)
+	variable, err := amount.Mul(rate, scale)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	total, err := flat.CheckedAdd(variable)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	fee := &presentations.Fee{
+		Currency: currency,
+		Flat:     flat,
+		Percent:  percent,
+		Variable: variable,
+		Amount:   total,
+	}
+
+	if rule.Min.IsPositive() && fee.Amount.Cmp(rule.Min) < 0 {
+		fee.Amount, fee.Capped = rule.Min, feeCappedMin
+	}
+
+	if rule.Max.IsPositive() && fee.Amount.Cmp(rule.Max) > 0 {
+		fee.Amount, fee.Capped = rule.Max, feeCappedMax
+	}
+
+	// caps are configured without regard to currency
+	fee.Amount, err = fee.Amount.Mul(one, scale)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	if !fee.Amount.IsPositive() {
+		return nil, nil
+	}
+
+	return fee, nil
+}
+
+const (
+	feeCappedMin = "min"
+	feeCappedMax = "max"
+)
+
+// newFeeTransfer books fee as two extra legs of trx: a debit on the payer
+// and a credit on the fees account, which the repository resolves. The
+// breakdown is kept in the transaction metadata.
+func newFeeTransfer(trx *repository.Transaction, payerID int, fee *presentations.Fee) *repository.FeeTransfer {
+	if fee == nil {
+		return nil
+	}
+
+	// trx is freshly prepared, there is no metadata to keep
+	trx.Metadata, _ = json.Marshal(transactionMetadata{Fee: fee})
+
+	return &repository.FeeTransfer{
+		Currency: fee.Currency,
+		Amount:   fee.Amount,
+		LedgerEntryPayer: repository.LedgerEntry{
+			ID:            uuid.NewString(),
+			TransactionID: trx.ID,
+			AccountID:     payerID,
+			EntryType:     consts.EntryTypeDebit,
+			Amount:        fee.Amount,
+			Description:   "fee",
+			CreatedAt:     trx.CreatedAt,
+		},
+		LedgerEntryRevenue: repository.LedgerEntry{
+			ID:            uuid.NewString(),
+			TransactionID: trx.ID,
+			EntryType:     consts.EntryTypeCredit,
+			Amount:        fee.Amount,
+			Description:   "fee",
+			CreatedAt:     trx.CreatedAt,
+		},
+	}
+}
+
+// newFeeRefund gives back the fee of a transfer being reversed as two extra
+// legs of the reversal trx: a debit on the fees account and a credit on the
+// payer. The repository works out the share matching the amount reversed.
+func (s *wallet) newFeeRefund(trx repository.Transaction, payerID int, fee *presentations.Fee) (*repository.FeeRefund, error) {
+	if fee == nil {
+		return nil, nil
+	}
+
+	scale, ok := s.currencies[fee.Currency]
+	if !ok {
+		return nil, ErrUnsupportedCurrency
+	}
+
+	return &repository.FeeRefund{
+		Currency: fee.Currency,
+		Fee:      fee.Amount,
+		Scale:    scale,
+		LedgerEntryRevenue: repository.LedgerEntry{
+			ID:            uuid.NewString(),
+			TransactionID: trx.ID,
+			EntryType:     consts.EntryTypeDebit,
+			Description:   "fee refund",
+			CreatedAt:     trx.CreatedAt,
+		},
+		LedgerEntryPayer: repository.LedgerEntry{
+			ID:            uuid.NewString(),
+			TransactionID: trx.ID,
+			AccountID:     payerID,
+			EntryType:     consts.EntryTypeCredit,
+			Description:   "fee refund",
+			CreatedAt:     trx.CreatedAt,
+		},
+	}, nil
+}
+
+func feeAmount(fee *presentations.Fee) utils.Decimal {
+	if fee == nil {
+		return utils.Decimal{}
+	}
+
+	return fee.Amount
+}
+
+// transactionFee returns the fee stored in the transaction metadata, if any.
+func transactionFee(trx repository.Transaction) *presentations.Fee {
+	meta, err := readMetadata(trx)
+	if err != nil {
+		return nil
+	}
+
+	return meta.Fee
+}
+
+func readMetadata(trx repository.Transaction) (transactionMetadata, error) {
+	var meta transactionMetadata
+	if len(trx.Metadata) == 0 {
+		return meta, nil
+	}
+
+	err := json.Unmarshal(trx.Metadata, &meta)
+	return meta, err
+}
+
+// updateMetadata applies update to the transaction metadata, keeping what
+// is already there.
+func updateMetadata(trx *repository.Transaction, update func(*transactionMetadata)) error {
+	meta, err := readMetadata(*trx)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	update(&meta)
+
+	trx.Metadata, err = json.Marshal(meta)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	return nil
+}
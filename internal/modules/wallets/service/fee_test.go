@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuoteFee(t *testing.T) {
+	d := utils.MustParseDecimal
+	svc := NewWalletService(nil,
+		WithCurrencies(map[string]int{"USD": 2, "JPY": 0}),
+		WithFees(FeeSchedule{
+			consts.TransactionTypeTransfer: {Flat: d("0.3"), Percent: d("1.5"), Min: d("0.5"), Max: d("20")},
+			consts.TransactionTypeWithdraw: {Tiers: []FeeTier{
+				{UpTo: d("100"), Flat: d("1")},
+				{UpTo: d("1000"), Flat: d("2.5"), Percent: d("0.1")},
+				{Percent: d("0.25")},
+			}},
+		}),
+	).(*wallet)
+
+	testTables := []struct {
+		name     string
+		trxType  consts.TransactionType
+		currency string
+		amount   string
+		fee      string
+		variable string
+		capped   string
+	}{
+		{name: "flat plus percent", trxType: consts.TransactionTypeTransfer, currency: "USD", amount: "100", fee: "1.8", variable: "1.5"},
+		{name: "percent rounded half up", trxType: consts.TransactionTypeTransfer, currency: "USD", amount: "33.3", fee: "0.8", variable: "0.5"},
+		{name: "minimum", trxType: consts.TransactionTypeTransfer, currency: "USD", amount: "1", fee: "0.5", variable: "0.02", capped: feeCappedMin},
+		{name: "maximum", trxType: consts.TransactionTypeTransfer, currency: "USD", amount: "5000", fee: "20", variable: "75", capped: feeCappedMax},
+		{name: "currency without minor units", trxType: consts.TransactionTypeTransfer, currency: "JPY", amount: "1000", fee: "15", variable: "15"},
+		{name: "first tier", trxType: consts.TransactionTypeWithdraw, currency: "USD", amount: "100", fee: "1", variable: "0"},
+		{name: "second tier", trxType: consts.TransactionTypeWithdraw, currency: "USD", amount: "500", fee: "3", variable: "0.5"},
+		{name: "open tier", trxType: consts.TransactionTypeWithdraw, currency: "USD", amount: "4000", fee: "10", variable: "10"},
+		{name: "free type", trxType: consts.TransactionTypeDeposit, currency: "USD", amount: "100"},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fee, err := svc.quoteFee(tt.trxType, tt.currency, d(tt.amount))
+			require.NoError(t, err)
+			if tt.fee == "" {
+				assert.Nil(t, fee)
+				return
+			}
+
+			require.NotNil(t, fee)
+			assert.Equal(t, tt.currency, fee.Currency)
+			assert.Equal(t, tt.fee, fee.Amount.String())
+			assert.Equal(t, tt.variable, fee.Variable.String())
+			assert.Equal(t, tt.capped, fee.Capped)
+		})
+	}
+
+	_, err := svc.quoteFee(consts.TransactionTypeTransfer, "XXX", d("10"))
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestSubmitTransactionWithFee(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	svc := NewWalletService(mRepo, WithFees(FeeSchedule{
+		consts.TransactionTypeTransfer: {Flat: utils.NewDecimal(1)},
+	}))
+
+	from := repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency, Balance: utils.NewDecimal(50)}
+	to := repository.Account{ID: 2, AccountID: 2, Currency: consts.DefaultCurrency}
+
+	mRepo.EXPECT().GetAccount(ctx, 3).Return(from, nil)
+	mRepo.EXPECT().GetAccount(ctx, 2).Return(to, nil)
+	_, err := svc.SubmitTransaction(ctx, presentations.CreateTransaction{SourceAccountID: 3, DestinationAccountID: 2, Amount: "49.5"})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	mRepo.EXPECT().GetAccount(ctx, 3).Return(from, nil)
+	mRepo.EXPECT().GetAccount(ctx, 2).Return(to, nil)
+	mRepo.EXPECT().SubmitTransaction(ctx, gomock.AssignableToTypeOf(repository.TransactionPayload{})).DoAndReturn(
+		func(_ context.Context, p repository.TransactionPayload) error {
+			assert.Equal(t, "49", p.Amount.String())
+			if assert.NotNil(t, p.Fee) {
+				assert.Equal(t, consts.DefaultCurrency, p.Fee.Currency)
+				assert.Equal(t, "1", p.Fee.Amount.String())
+				assert.Equal(t, 3, p.Fee.LedgerEntryPayer.AccountID)
+				assert.Equal(t, consts.EntryTypeDebit, p.Fee.LedgerEntryPayer.EntryType)
+				assert.Equal(t, consts.EntryTypeCredit, p.Fee.LedgerEntryRevenue.EntryType)
+				assert.Equal(t, p.Transaction.ID, p.Fee.LedgerEntryRevenue.TransactionID)
+			}
+
+			var meta transactionMetadata
+			require.NoError(t, json.Unmarshal(p.Transaction.Metadata, &meta))
+			assert.Nil(t, meta.FX)
+			return nil
+		})
+
+	resp, err := svc.SubmitTransaction(ctx, presentations.CreateTransaction{SourceAccountID: 3, DestinationAccountID: 2, Amount: "49"})
+	require.NoError(t, err)
+	if assert.NotNil(t, resp.Fee) {
+		assert.Equal(t, "1", resp.Fee.Amount.String())
+		assert.Equal(t, "1", resp.Fee.Flat.String())
+	}
+}
+
+func TestWithdrawWithFee(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	svc := NewWalletService(mRepo, WithFees(FeeSchedule{
+		consts.TransactionTypeWithdraw: {Percent: utils.NewDecimal(1)},
+	}))
+
+	mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{ID: 1, AccountID: 2, Currency: consts.DefaultCurrency}, nil)
+	mRepo.EXPECT().Withdraw(ctx, gomock.AssignableToTypeOf(repository.WithdrawPayload{})).DoAndReturn(
+		func(_ context.Context, p repository.WithdrawPayload) error {
+			if assert.NotNil(t, p.Fee) {
+				assert.Equal(t, "0.25", p.Fee.Amount.String())
+				assert.Equal(t, 2, p.Fee.LedgerEntryPayer.AccountID)
+			}
+			assert.Equal(t, consts.EntryTypeCredit, p.LedgerEntryFunding.EntryType)
+			assert.Equal(t, "25", p.LedgerEntryFunding.Amount.String())
+			return nil
+		})
+
+	err := svc.Withdraw(ctx, presentations.CreateWithdrawal{AccountID: 2, Amount: "25"})
+	assert.NoError(t, err)
+}
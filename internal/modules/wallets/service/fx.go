@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+// staticRates is an FXRateProvider serving a fixed rate table, keyed by
+// "BASE/QUOTE".
+type staticRates map[string]utils.Decimal
+
+// NewStaticRateProvider returns an FXRateProvider for a fixed rate table such
+// as {"USD/EUR": "0.92"}, where the value is how many units of the quote
+// currency one unit of the base currency buys. Pairs are not inverted: a
+// transfer in each direction needs its own entry. It is meant for local use
+// and tests.
+func NewStaticRateProvider(rates map[string]string) (FXRateProvider, error) {
+	table := make(staticRates, len(rates))
+	for pair, value := range rates {
+		base, quote, ok := strings.Cut(pair, "/")
+		if !ok || base == "" || quote == "" {
+			return nil, fmt.Errorf("invalid fx pair %q, expected BASE/QUOTE", pair)
+		}
+
+		rate, err := utils.ParseDecimal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fx rate for %s: %w", pair, err)
+		}
+
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("invalid fx rate for %s: must be positive", pair)
+		}
+
+		table[fxPair(base, quote)] = rate
+	}
+
+	return table, nil
+}
+
+func (r staticRates) Rate(_ context.Context, base, quote string) (utils.Decimal, error) {
+	if base == quote {
+		return utils.NewDecimal(1), nil
+	}
+
+	rate, ok := r[fxPair(base, quote)]
+	if !ok {
+		return utils.Decimal{}, ErrFXRateUnavailable
+	}
+
+	return rate, nil
+}
+
+func fxPair(base, quote string) string {
+	return normalizeCurrency(base) + "/" + normalizeCurrency(quote)
+}
+
+// fxQuote is the conversion applied to a cross-currency transfer, stored
+// under "fx" in the transaction metadata.
+type fxQuote struct {
+	Rate                utils.Decimal `json:"rate"`
+	SourceCurrency      string        `json:"source_currency"`
+	SourceAmount        utils.Decimal `json:"source_amount"`
+	DestinationCurrency string        `json:"destination_currency"`
+	DestinationAmount   utils.Decimal `json:"destination_amount"`
+}
+
+// transactionMetadata is stored as JSON on the transaction.
+type transactionMetadata struct {
+	FX  *fxQuote           `json:"fx,omitempty"`
+	Fee *presentations.Fee `json:"fee,omitempty"`
+}
+
+// isFXTransfer reports whether trx converted between currencies.
+func isFXTransfer(trx repository.Transaction) bool {
+	meta, err := readMetadata(trx)
+	return err == nil && meta.FX != nil
+}
+
+// applyFX turns payload into a transfer from a from-currency account to a
+// to-currency account. The amount received is the amount paid at the
+// provider's rate, rounded to the destination currency's minor units.
+func (s *wallet) applyFX(ctx context.Context, payload *repository.TransactionPayload, from, to string) error {
+	rate, err := s.fxRates.Rate(ctx, from, to)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	scale, ok := s.currencies[to]
+	if !ok {
+		return ErrUnsupportedCurrency
+	}
+
+	received, err := payload.Amount.Mul(rate, scale)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if !received.IsPositive() {
+		return ErrAmountTooSmall
+	}
+
+	quote := fxQuote{
+		Rate:                rate,
+		SourceCurrency:      from,
+		SourceAmount:        payload.Amount,
+		DestinationCurrency: to,
+		DestinationAmount:   received,
+	}
+
+	trx := &payload.Transaction
+	if err := updateMetadata(trx, func(meta *transactionMetadata) { meta.FX = &quote }); err != nil {
+		return err
+	}
+	trx.Description = fmt.Sprintf("transfer %s %s from %d to %d as %s %s", payload.Amount, from, payload.From.AccountID, payload.To.AccountID, received, to)
+
+	payload.LedgerEntryTo.Amount = received
+	payload.FX = &repository.FXTransfer{
+		SourceCurrency:      from,
+		DestinationCurrency: to,
+		DestinationAmount:   received,
+		LedgerEntrySource: repository.LedgerEntry{
+			ID:            uuid.NewString(),
+			TransactionID: trx.ID,
+			EntryType:     consts.EntryTypeCredit,
+			Amount:        payload.Amount,
+			Description:   "fx conversion",
+			CreatedAt:     trx.CreatedAt,
+		},
+		LedgerEntryDestination: repository.LedgerEntry{
+			ID:            uuid.NewString(),
+			TransactionID: trx.ID,
+			EntryType:     consts.EntryTypeDebit,
+			Amount:        received,
+			Description:   "fx conversion",
+			CreatedAt:     trx.CreatedAt,
+		},
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStaticRateProvider(t *testing.T) {
+	ctx := staffCtx()
+
+	_, err := NewStaticRateProvider(map[string]string{"USDEUR": "0.92"})
+	assert.Error(t, err)
+
+	_, err = NewStaticRateProvider(map[string]string{"USD/EUR": "-1"})
+	assert.Error(t, err)
+
+	rates, err := NewStaticRateProvider(map[string]string{"usd/eur": "0.92"})
+	require.NoError(t, err)
+
+	rate, err := rates.Rate(ctx, "USD", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.92", rate.String())
+
+	rate, err = rates.Rate(ctx, "EUR", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", rate.String())
+
+	_, err = rates.Rate(ctx, "EUR", "USD")
+	assert.ErrorIs(t, err, ErrFXRateUnavailable)
+}
+
+func TestSubmitTransactionCrossCurrency(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+
+	rates, err := NewStaticRateProvider(map[string]string{"USD/JPY": "157.345", "USD/EUR": "0.92"})
+	require.NoError(t, err)
+
+	svc := NewWalletService(mRepo,
+		WithCurrencies(map[string]int{"USD": 2, "EUR": 2, "JPY": 0, "GBP": 2}),
+		WithFXRateProvider(rates),
+	)
+
+	usd := repository.Account{ID: 1, AccountID: 3, Currency: "USD", Balance: utils.NewDecimal(500)}
+	jpy := repository.Account{ID: 2, AccountID: 2, Currency: "JPY"}
+	gbp := repository.Account{ID: 4, AccountID: 4, Currency: "GBP"}
+
+	testTables := []struct {
+		name string
+		mock func()
+		err  error
+		req  presentations.CreateTransaction
+	}{
+		{
+			name: "FAILED rate unavailable",
+			err:  ErrFXRateUnavailable,
+			req:  presentations.CreateTransaction{SourceAccountID: 3, DestinationAccountID: 4, Amount: "10"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(usd, nil)
+				mRepo.EXPECT().GetAccount(ctx, 4).Return(gbp, nil)
+			},
+		},
+		{
+			name: "FAILED amount beyond source currency scale",
+			err:  ErrAmountScale,
+			req:  presentations.CreateTransaction{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10.005"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(usd, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(jpy, nil)
+			},
+		},
+		{
+			name: "SUCCESS",
+			req:  presentations.CreateTransaction{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10.25"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(usd, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(jpy, nil)
+				mRepo.EXPECT().SubmitTransaction(ctx, gomock.AssignableToTypeOf(repository.TransactionPayload{})).DoAndReturn(
+					func(_ context.Context, p repository.TransactionPayload) error {
+						assert.Equal(t, "10.25", p.Amount.String())
+						assert.Equal(t, "10.25", p.LedgerEntryFrom.Amount.String())
+
+						// 10.25 * 157.345 = 1612.78625, rounded to whole yen
+						assert.Equal(t, "1613", p.LedgerEntryTo.Amount.String())
+						if assert.NotNil(t, p.FX) {
+							assert.Equal(t, "USD", p.FX.SourceCurrency)
+							assert.Equal(t, "JPY", p.FX.DestinationCurrency)
+							assert.Equal(t, "1613", p.FX.DestinationAmount.String())
+							assert.Equal(t, consts.EntryTypeCredit, p.FX.LedgerEntrySource.EntryType)
+							assert.Equal(t, "10.25", p.FX.LedgerEntrySource.Amount.String())
+							assert.Equal(t, consts.EntryTypeDebit, p.FX.LedgerEntryDestination.EntryType)
+							assert.Equal(t, "1613", p.FX.LedgerEntryDestination.Amount.String())
+							assert.Equal(t, p.Transaction.ID, p.FX.LedgerEntryDestination.TransactionID)
+						}
+
+						var meta transactionMetadata
+						require.NoError(t, json.Unmarshal(p.Transaction.Metadata, &meta))
+						if assert.NotNil(t, meta.FX) {
+							assert.Equal(t, "157.345", meta.FX.Rate.String())
+							assert.Equal(t, "1613", meta.FX.DestinationAmount.String())
+						}
+						return nil
+					})
+			},
+		},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			resp, err := svc.SubmitTransaction(ctx, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.NotEmpty(t, resp.Metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+
+	dateLayout = "2006-01-02"
+)
+
+func (s *wallet) ListAccountTransactions(ctx context.Context, accountID int, req presentations.ListAccountTransactions) (presentations.AccountTransactions, error) {
+	if err := validateAccountID(accountID); err != nil {
+		slog.Warn("[ListAccountTransactions] failed validation", slog.Any("err", err))
+		return presentations.AccountTransactions{}, wrapError(err)
+	}
+
+	filter, err := buildLedgerFilter(accountID, req)
+	if err != nil {
+		slog.Warn("[ListAccountTransactions] failed validation", slog.Any("err", err))
+		return presentations.AccountTransactions{}, wrapError(err)
+	}
+
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		slog.Warn("[ListAccountTransactions] failed GetAccount", slog.Any("err", err))
+		return presentations.AccountTransactions{}, wrapError(err)
+	}
+
+	if account.ID == 0 {
+		slog.Warn("[ListAccountTransactions] failed data not found", slog.Any("accountID", accountID))
+		return presentations.AccountTransactions{}, ErrAccountNotFound
+	}
+
+	if err := authorizeAccount(ctx, "[ListAccountTransactions]", account); err != nil {
+		return presentations.AccountTransactions{}, err
+	}
+
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	entries, err := s.repo.ListLedgerEntries(ctx, filter)
+	if err != nil {
+		slog.Warn("[ListAccountTransactions] failed ListLedgerEntries", slog.Any("err", err))
+		return presentations.AccountTransactions{}, wrapError(err)
+	}
+
+	resp := presentations.AccountTransactions{
+		AccountID: accountID,
+		Items:     make([]presentations.AccountTransaction, 0, len(entries)),
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[len(entries)-1]
+		resp.NextCursor = encodeHistoryCursor(repository.LedgerCursor{
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
+		})
+	}
+
+	for _, e := range entries {
+		resp.Items = append(resp.Items, toAccountTransaction(e))
+	}
+
+	slog.Info("[ListAccountTransactions] success", slog.Any("accountID", accountID), slog.Int("count", len(resp.Items)))
+	return resp, nil
+}
+
+func toAccountTransaction(e repository.HistoryEntry) presentations.AccountTransaction {
+	item := presentations.AccountTransaction{
+		TransactionID:   e.TransactionID,
+		ReferenceNumber: e.ReferenceNumber,
+		Type:            string(e.TransactionType),
+		EntryType:       string(e.EntryType),
+		Amount:          e.Amount,
+		BalanceBefore:   e.BalanceBefore,
+		BalanceAfter:    e.BalanceAfter,
+		Description:     e.Description,
+		CreatedAt:       e.CreatedAt,
+	}
+
+	if e.CounterpartyAccountID.Valid {
+		id := int(e.CounterpartyAccountID.Int64)
+		item.CounterpartyAccountID = &id
+	}
+
+	return item
+}
+
+func buildLedgerFilter(accountID int, req presentations.ListAccountTransactions) (repository.LedgerFilter, error) {
+	filter := repository.LedgerFilter{
+		AccountID: accountID,
+		Limit:     req.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxHistoryLimit {
+		return filter, ErrInvalidLimit
+	}
+
+	for _, t := range req.Types {
+		txType := consts.TransactionType(strings.TrimSpace(t))
+		if !txType.Valid() {
+			return filter, validationError(ErrInvalidFilter, "invalid transaction type filter")
+		}
+		filter.Types = append(filter.Types, txType)
+	}
+
+	var err error
+	if req.StartDate != "" {
+		filter.From, err = parseHistoryDate(req.StartDate, false)
+		if err != nil {
+			return filter, validationError(ErrInvalidFilter, "invalid start_date format")
+		}
+	}
+
+	if req.EndDate != "" {
+		filter.To, err = parseHistoryDate(req.EndDate, true)
+		if err != nil {
+			return filter, validationError(ErrInvalidFilter, "invalid end_date format")
+		}
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, validationError(ErrInvalidFilter, "start_date must be before end_date")
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeHistoryCursor(req.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &cursor
+	}
+
+	return filter, nil
+}
+
+// parseHistoryDate accepts an RFC 3339 timestamp or a plain date. A plain
+// date used as the end of a range moves to the start of the next day so the
+// whole day is included. Results are in the local zone, which is how the
+// service writes created_at.
+func parseHistoryDate(v string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t.In(time.Local), nil
+	}
+
+	t, err := time.ParseInLocation(dateLayout, v, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// History cursors are opaque to clients: base64url of "<created_at>|<id>".
+func encodeHistoryCursor(c repository.LedgerCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+func decodeHistoryCursor(v string) (repository.LedgerCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return repository.LedgerCursor{}, ErrInvalidCursor
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return repository.LedgerCursor{}, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return repository.LedgerCursor{}, ErrInvalidCursor
+	}
+
+	return repository.LedgerCursor{CreatedAt: createdAt, ID: id}, nil
+}
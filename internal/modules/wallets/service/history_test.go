@@ -0,0 +1,186 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListAccountTransactions(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	errTest := errors.New("test")
+	ctx := staffCtx()
+
+	createdAt := time.Date(2025, 6, 1, 10, 0, 0, 123456000, time.UTC)
+	entry := func(id string) repository.HistoryEntry {
+		return repository.HistoryEntry{
+			LedgerEntry: repository.LedgerEntry{
+				ID:            id,
+				TransactionID: "trx-" + id,
+				AccountID:     2,
+				EntryType:     consts.EntryTypeDebit,
+				Amount:        utils.NewDecimal(10),
+				BalanceBefore: utils.NewDecimal(50),
+				BalanceAfter:  utils.NewDecimal(40),
+				CreatedAt:     createdAt,
+			},
+			TransactionType:       consts.TransactionTypeTransfer,
+			ReferenceNumber:       "ref-" + id,
+			CounterpartyAccountID: sql.NullInt64{Int64: 3, Valid: true},
+		}
+	}
+	cursor := encodeHistoryCursor(repository.LedgerCursor{CreatedAt: createdAt, ID: "b"})
+	counterparty := 3
+
+	testTables := []struct {
+		name   string
+		mock   func()
+		err    error
+		req    presentations.ListAccountTransactions
+		result presentations.AccountTransactions
+	}{
+		{
+			name: "FAILED validation limit",
+			err:  ErrInvalidLimit,
+			req:  presentations.ListAccountTransactions{Limit: 101},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation type",
+			err:  ErrInvalidFilter,
+			req:  presentations.ListAccountTransactions{Types: []string{"refund"}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation date range",
+			err:  ErrInvalidFilter,
+			req:  presentations.ListAccountTransactions{StartDate: "2025-06-02", EndDate: "2025-05-01"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation cursor",
+			err:  ErrInvalidCursor,
+			req:  presentations.ListAccountTransactions{Cursor: "not-a-cursor"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED data not found",
+			err:  ErrAccountNotFound,
+			req:  presentations.ListAccountTransactions{},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
+			},
+		},
+		{
+			name: "FAILED db error",
+			err:  errTest,
+			req:  presentations.ListAccountTransactions{},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{ID: 1, AccountID: 2}, nil)
+				mRepo.EXPECT().ListLedgerEntries(ctx, gomock.Any()).Return(nil, errTest)
+			},
+		},
+		{
+			name: "SUCCESS first page with next cursor",
+			req: presentations.ListAccountTransactions{
+				Limit: 2,
+				Types: []string{"transfer"},
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{ID: 1, AccountID: 2}, nil)
+				mRepo.EXPECT().ListLedgerEntries(ctx, repository.LedgerFilter{
+					AccountID: 2,
+					Types:     []consts.TransactionType{consts.TransactionTypeTransfer},
+					Limit:     3,
+				}).Return([]repository.HistoryEntry{entry("a"), entry("b"), entry("c")}, nil)
+			},
+			result: presentations.AccountTransactions{
+				AccountID: 2,
+				Items: []presentations.AccountTransaction{
+					{
+						TransactionID:         "trx-a",
+						ReferenceNumber:       "ref-a",
+						Type:                  "transfer",
+						EntryType:             "debit",
+						CounterpartyAccountID: &counterparty,
+						Amount:                utils.NewDecimal(10),
+						BalanceBefore:         utils.NewDecimal(50),
+						BalanceAfter:          utils.NewDecimal(40),
+						CreatedAt:             createdAt,
+					},
+					{
+						TransactionID:         "trx-b",
+						ReferenceNumber:       "ref-b",
+						Type:                  "transfer",
+						EntryType:             "debit",
+						CounterpartyAccountID: &counterparty,
+						Amount:                utils.NewDecimal(10),
+						BalanceBefore:         utils.NewDecimal(50),
+						BalanceAfter:          utils.NewDecimal(40),
+						CreatedAt:             createdAt,
+					},
+				},
+				NextCursor: cursor,
+			},
+		},
+		{
+			name: "SUCCESS last page",
+			req: presentations.ListAccountTransactions{
+				Limit:  2,
+				Cursor: cursor,
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{ID: 1, AccountID: 2}, nil)
+				mRepo.EXPECT().ListLedgerEntries(ctx, repository.LedgerFilter{
+					AccountID: 2,
+					After:     &repository.LedgerCursor{CreatedAt: createdAt, ID: "b"},
+					Limit:     3,
+				}).Return([]repository.HistoryEntry{}, nil)
+			},
+			result: presentations.AccountTransactions{
+				AccountID: 2,
+				Items:     []presentations.AccountTransaction{},
+			},
+		},
+	}
+
+	svc := NewWalletService(mRepo)
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			resp, err := svc.ListAccountTransactions(ctx, 2, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.result, resp)
+		})
+	}
+}
+
+func TestParseHistoryDate(t *testing.T) {
+	start, err := parseHistoryDate("2025-06-01", false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local), start)
+
+	end, err := parseHistoryDate("2025-06-01", true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 2, 0, 0, 0, 0, time.Local), end)
+
+	ts, err := parseHistoryDate("2025-06-01T10:00:00Z", true)
+	assert.NoError(t, err)
+	assert.True(t, ts.Equal(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)))
+
+	_, err = parseHistoryDate("01/06/2025", false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+const (
+	defaultHoldTTL = 7 * 24 * time.Hour
+
+	// holdExpiryBatch bounds how many holds one DB transaction releases.
+	holdExpiryBatch = 100
+)
+
+func (s *wallet) CreateHold(ctx context.Context, req presentations.CreateHold) (presentations.Hold, error) {
+	if err := validateAccountID(req.AccountID); err != nil {
+		slog.Warn("[CreateHold] failed validation", slog.Any("err", err))
+		return presentations.Hold{}, err
+	}
+
+	if err := validateAccountID(req.DestinationAccountID); err != nil {
+		slog.Warn("[CreateHold] failed validation", slog.Any("err", err))
+		return presentations.Hold{}, err
+	}
+
+	if req.AccountID == req.DestinationAccountID {
+		return presentations.Hold{}, ErrSameAccount
+	}
+
+	reqAmount, err := utils.ParseDecimal(req.Amount)
+	if err != nil {
+		slog.Warn("[CreateHold] failed validation", slog.Any("err", err))
+		return presentations.Hold{}, wrapError(err)
+	}
+
+	if err := validateAmount(reqAmount); err != nil {
+		slog.Warn("[CreateHold] failed validation", slog.Any("err", err))
+		return presentations.Hold{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.holdTTL)
+	if req.ExpiresAt != "" {
+		expiresAt, err = time.Parse(time.RFC3339Nano, req.ExpiresAt)
+		if err != nil || !expiresAt.After(now) {
+			slog.Warn("[CreateHold] failed validation", slog.String("expires_at", req.ExpiresAt))
+			return presentations.Hold{}, ErrInvalidExpiry
+		}
+	}
+
+	if _, err := s.validateAccountAmount(ctx, "[CreateHold]", req.AccountID, reqAmount); err != nil {
+		return presentations.Hold{}, err
+	}
+
+	hold := repository.Hold{
+		ID:                   uuid.NewString(),
+		AccountID:            req.AccountID,
+		DestinationAccountID: req.DestinationAccountID,
+		Amount:               reqAmount,
+		Status:               consts.HoldStatusActive,
+		Description:          req.Description,
+		ExpiresAt:            expiresAt,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+
+	err = s.repo.CreateHold(ctx, hold)
+	if err != nil {
+		slog.Warn("[CreateHold] failed create hold", slog.Any("req", req), slog.Any("err", err))
+		return presentations.Hold{}, wrapError(err)
+	}
+
+	slog.Info("[CreateHold] success", slog.String("id", hold.ID), slog.Any("req", req))
+	return toHold(hold), nil
+}
+
+func (s *wallet) GetHold(ctx context.Context, id string) (presentations.Hold, error) {
+	hold, err := s.getHold(ctx, "[GetHold]", id)
+	if err != nil {
+		return presentations.Hold{}, err
+	}
+
+	return toHold(hold), nil
+}
+
+// CaptureHold settles an active hold as a transfer from the held account to
+// the hold's destination. Whatever part of the hold is not captured is
+// released.
+func (s *wallet) CaptureHold(ctx context.Context, id string, req presentations.CaptureHold) (presentations.Transaction, error) {
+	hold, err := s.getHold(ctx, "[CaptureHold]", id)
+	if err != nil {
+		return presentations.Transaction{}, err
+	}
+
+	if hold.Status != consts.HoldStatusActive {
+		slog.Warn("[CaptureHold] failed hold not active", slog.String("id", id), slog.String("status", hold.Status))
+		return presentations.Transaction{}, ErrHoldNotActive
+	}
+
+	amount := hold.Amount
+	if req.Amount != "" {
+		amount, err = utils.ParseDecimal(req.Amount)
+		if err != nil {
+			slog.Warn("[CaptureHold] failed validation", slog.Any("err", err))
+			return presentations.Transaction{}, wrapError(err)
+		}
+
+		if err := validateAmount(amount); err != nil {
+			slog.Warn("[CaptureHold] failed validation", slog.Any("err", err))
+			return presentations.Transaction{}, err
+		}
+
+		if amount.Cmp(hold.Amount) > 0 {
+			slog.Warn("[CaptureHold] failed amount exceeds hold", slog.String("id", id))
+			return presentations.Transaction{}, ErrCaptureExceeded
+		}
+	}
+
+	payer, err := s.validateAccountAmount(ctx, "[CaptureHold]", hold.AccountID, amount)
+	if err != nil {
+		return presentations.Transaction{}, err
+	}
+
+	fee, err := s.quoteFee(consts.TransactionTypeTransfer, payer.Currency, amount)
+	if err != nil {
+		slog.Warn("[CaptureHold] failed fee", slog.String("id", id), slog.Any("err", err))
+		return presentations.Transaction{}, err
+	}
+
+	payload := repository.CapturePayload{
+		HoldID: hold.ID,
+		Transfer: prepareTrxPayload(
+			repository.Account{AccountID: hold.AccountID},
+			repository.Account{AccountID: hold.DestinationAccountID},
+			amount,
+			fee,
+		),
+	}
+	payload.Transfer.Transaction.Description = fmt.Sprintf("capture %s of hold %s", amount, hold.ID)
+
+	err = s.repo.CaptureHold(ctx, payload)
+	if err != nil {
+		slog.Warn("[CaptureHold] failed capture hold", slog.String("id", id), slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	slog.Info("[CaptureHold] success", slog.String("id", id), slog.String("transaction_id", payload.Transfer.Transaction.ID))
+	return toTransaction(payload.Transfer.Transaction, nil), nil
+}
+
+func (s *wallet) VoidHold(ctx context.Context, id string) (presentations.Hold, error) {
+	hold, err := s.getHold(ctx, "[VoidHold]", id)
+	if err != nil {
+		return presentations.Hold{}, err
+	}
+
+	err = s.repo.VoidHold(ctx, hold.ID)
+	if err != nil {
+		slog.Warn("[VoidHold] failed void hold", slog.String("id", id), slog.Any("err", err))
+		return presentations.Hold{}, wrapError(err)
+	}
+
+	hold.Status = consts.HoldStatusVoided
+
+	slog.Info("[VoidHold] success", slog.String("id", id))
+	return toHold(hold), nil
+}
+
+// ExpireHolds releases every active hold past its expiry, one batch per DB
+// transaction, and reports how many were released.
+func (s *wallet) ExpireHolds(ctx context.Context) (int, error) {
+	var total int
+	for {
+		n, err := s.repo.ExpireHolds(ctx, time.Now(), holdExpiryBatch)
+		total += n
+		if err != nil {
+			slog.Warn("[ExpireHolds] failed expire holds", slog.Int("expired", total), slog.Any("err", err))
+			return total, wrapError(err)
+		}
+
+		if n < holdExpiryBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("[ExpireHolds] success", slog.Int("expired", total))
+	}
+	return total, nil
+}
+
+func (s *wallet) getHold(ctx context.Context, logPrefix, id string) (repository.Hold, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.String("id", id))
+		return repository.Hold{}, ErrInvalidHoldID
+	}
+
+	hold, err := s.repo.GetHold(ctx, id)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetHold", slog.Any("err", err))
+		return repository.Hold{}, wrapError(err)
+	}
+
+	if hold.ID == "" {
+		slog.Warn(logPrefix+" failed data not found", slog.String("id", id))
+		return repository.Hold{}, ErrHoldNotFound
+	}
+
+	if err := s.authorizeAccountID(ctx, logPrefix, hold.AccountID); err != nil {
+		return repository.Hold{}, err
+	}
+
+	return hold, nil
+}
+
+func toHold(h repository.Hold) presentations.Hold {
+	return presentations.Hold{
+		ID:                   h.ID,
+		AccountID:            h.AccountID,
+		DestinationAccountID: h.DestinationAccountID,
+		Amount:               h.Amount,
+		Status:               h.Status,
+		TransactionID:        h.TransactionID.String,
+		Description:          h.Description,
+		ExpiresAt:            h.ExpiresAt,
+		CreatedAt:            h.CreatedAt,
+	}
+}
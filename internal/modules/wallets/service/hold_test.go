@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateHold(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	errTest := errors.New("test")
+	ctx := staffCtx()
+	account := repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency}
+
+	testTables := []struct {
+		name string
+		mock func()
+		err  error
+		req  presentations.CreateHold
+	}{
+		{
+			name: "FAILED validation account",
+			err:  ErrInvalidAccountID,
+			req:  presentations.CreateHold{AccountID: 0, DestinationAccountID: 2, Amount: "10"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation same account",
+			err:  ErrSameAccount,
+			req:  presentations.CreateHold{AccountID: 2, DestinationAccountID: 2, Amount: "10"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation amount",
+			err:  ErrAmountTooSmall,
+			req:  presentations.CreateHold{AccountID: 3, DestinationAccountID: 2, Amount: "0"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation expires_at",
+			err:  ErrInvalidExpiry,
+			req:  presentations.CreateHold{AccountID: 3, DestinationAccountID: 2, Amount: "10", ExpiresAt: "2001-01-01T00:00:00Z"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED amount beyond currency scale",
+			err:  ErrAmountScale,
+			req:  presentations.CreateHold{AccountID: 3, DestinationAccountID: 2, Amount: "10.125"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(account, nil)
+			},
+		},
+		{
+			name: "FAILED insufficient funds",
+			err:  ErrInsufficientFunds,
+			req:  presentations.CreateHold{AccountID: 3, DestinationAccountID: 2, Amount: "10"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(account, nil)
+				mRepo.EXPECT().CreateHold(ctx, gomock.Any()).Return(repository.ErrInsufficientBalance)
+			},
+		},
+		{
+			name: "FAILED db error",
+			err:  errTest,
+			req:  presentations.CreateHold{AccountID: 3, DestinationAccountID: 2, Amount: "10"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(account, nil)
+				mRepo.EXPECT().CreateHold(ctx, gomock.Any()).Return(errTest)
+			},
+		},
+		{
+			name: "SUCCESS",
+			req:  presentations.CreateHold{AccountID: 3, DestinationAccountID: 2, Amount: "10.25"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(account, nil)
+				mRepo.EXPECT().CreateHold(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, h repository.Hold) error {
+					assert.Equal(t, consts.HoldStatusActive, h.Status)
+					assert.Equal(t, "10.25", h.Amount.String())
+					assert.WithinDuration(t, time.Now().Add(time.Hour), h.ExpiresAt, time.Minute)
+					return nil
+				})
+			},
+		},
+	}
+
+	svc := NewWalletService(mRepo, WithHoldTTL(time.Hour))
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			resp, err := svc.CreateHold(ctx, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.NotEmpty(t, resp.ID)
+				assert.Equal(t, consts.HoldStatusActive, resp.Status)
+			}
+		})
+	}
+}
+
+func TestCaptureHold(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+
+	const id = "5b0c3f2e-9a51-4d0e-b7a4-1f3e7c2d9a10"
+	hold := repository.Hold{
+		ID:                   id,
+		AccountID:            3,
+		DestinationAccountID: 2,
+		Amount:               utils.NewDecimal(50),
+		Status:               consts.HoldStatusActive,
+		ExpiresAt:            time.Now().Add(time.Hour),
+	}
+	voided := hold
+	voided.Status = consts.HoldStatusVoided
+	payer := repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency}
+
+	testTables := []struct {
+		name   string
+		mock   func()
+		err    error
+		id     string
+		req    presentations.CaptureHold
+		amount string
+	}{
+		{
+			name: "FAILED validation id",
+			err:  ErrInvalidHoldID,
+			id:   "abc",
+			mock: func() {},
+		},
+		{
+			name: "FAILED data not found",
+			err:  ErrHoldNotFound,
+			id:   id,
+			mock: func() {
+				mRepo.EXPECT().GetHold(ctx, id).Return(repository.Hold{}, nil)
+			},
+		},
+		{
+			name: "FAILED not active",
+			err:  ErrHoldNotActive,
+			id:   id,
+			mock: func() {
+				mRepo.EXPECT().GetHold(ctx, id).Return(voided, nil)
+			},
+		},
+		{
+			name: "FAILED amount exceeds hold",
+			err:  ErrCaptureExceeded,
+			id:   id,
+			req:  presentations.CaptureHold{Amount: "50.01"},
+			mock: func() {
+				mRepo.EXPECT().GetHold(ctx, id).Return(hold, nil)
+			},
+		},
+		{
+			name: "FAILED lost race with void",
+			err:  ErrHoldNotActive,
+			id:   id,
+			mock: func() {
+				mRepo.EXPECT().GetHold(ctx, id).Return(hold, nil)
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(payer, nil)
+				mRepo.EXPECT().CaptureHold(ctx, gomock.Any()).Return(repository.ErrHoldNotActive)
+			},
+		},
+		{
+			name:   "SUCCESS full capture",
+			id:     id,
+			amount: "50",
+			mock: func() {
+				mRepo.EXPECT().GetHold(ctx, id).Return(hold, nil)
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(payer, nil)
+				mRepo.EXPECT().CaptureHold(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, p repository.CapturePayload) error {
+					assert.Equal(t, id, p.HoldID)
+					assert.Equal(t, 3, p.Transfer.From.AccountID)
+					assert.Equal(t, 2, p.Transfer.To.AccountID)
+					return nil
+				})
+			},
+		},
+		{
+			name:   "SUCCESS partial capture",
+			id:     id,
+			req:    presentations.CaptureHold{Amount: "20"},
+			amount: "20",
+			mock: func() {
+				mRepo.EXPECT().GetHold(ctx, id).Return(hold, nil)
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(payer, nil)
+				mRepo.EXPECT().CaptureHold(ctx, gomock.Any()).Return(nil)
+			},
+		},
+	}
+
+	svc := NewWalletService(mRepo)
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			resp, err := svc.CaptureHold(ctx, tt.id, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, "transfer", resp.Type)
+				assert.Equal(t, tt.amount, resp.Amount.String())
+			}
+		})
+	}
+}
+
+func TestVoidHold(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	svc := NewWalletService(mRepo)
+
+	const id = "5b0c3f2e-9a51-4d0e-b7a4-1f3e7c2d9a10"
+	hold := repository.Hold{ID: id, AccountID: 3, Amount: utils.NewDecimal(5), Status: consts.HoldStatusActive}
+
+	mRepo.EXPECT().GetHold(ctx, id).Return(hold, nil)
+	mRepo.EXPECT().VoidHold(ctx, id).Return(repository.ErrHoldNotActive)
+	_, err := svc.VoidHold(ctx, id)
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+
+	mRepo.EXPECT().GetHold(ctx, id).Return(hold, nil)
+	mRepo.EXPECT().VoidHold(ctx, id).Return(nil)
+	resp, err := svc.VoidHold(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, consts.HoldStatusVoided, resp.Status)
+}
+
+func TestExpireHolds(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	svc := NewWalletService(mRepo)
+
+	gomock.InOrder(
+		mRepo.EXPECT().ExpireHolds(ctx, gomock.Any(), holdExpiryBatch).Return(holdExpiryBatch, nil),
+		mRepo.EXPECT().ExpireHolds(ctx, gomock.Any(), holdExpiryBatch).Return(7, nil),
+	)
+
+	n, err := svc.ExpireHolds(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, holdExpiryBatch+7, n)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+)
+
+// maxReferenceLength matches transactions.reference_number VARCHAR(255).
+const maxReferenceLength = 255
+
+func validateReferenceNumber(ref string) error {
+	if len(ref) > maxReferenceLength || strings.TrimSpace(ref) != ref {
+		return ErrInvalidReferenceNumber
+	}
+
+	return nil
+}
+
+// requestFingerprint hashes the parts of a request that must be identical
+// for a replay of the same idempotency key to be accepted.
+func requestFingerprint(parts ...interface{}) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%v|", p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// checkReplay looks up a transaction previously stored under ref. It returns
+// the transaction when the same request was already processed,
+// ErrIdempotencyConflict when the key was used for a different request, and
+// a zero Transaction when the key is still unused.
+func (s *wallet) checkReplay(ctx context.Context, ref, fingerprint string) (repository.Transaction, error) {
+	existing, err := s.repo.GetTransactionByReference(ctx, ref)
+	if err != nil {
+		return repository.Transaction{}, err
+	}
+
+	if existing.ID != "" && existing.RequestFingerprint != fingerprint {
+		return repository.Transaction{}, ErrIdempotencyConflict
+	}
+
+	return existing, nil
+}
+
+// resolveDuplicate handles a reference_number unique violation raised while
+// inserting: a concurrent request with the same key won the race, so the
+// outcome is either a replay of that request or a conflict.
+func (s *wallet) resolveDuplicate(ctx context.Context, ref, fingerprint string) (repository.Transaction, error) {
+	existing, err := s.checkReplay(ctx, ref, fingerprint)
+	if err != nil {
+		return repository.Transaction{}, err
+	}
+
+	if existing.ID == "" {
+		return repository.Transaction{}, ErrIdempotencyConflict
+	}
+
+	return existing, nil
+}
+
+func applyReference(trx *repository.Transaction, ref, fingerprint string) {
+	if ref == "" {
+		return
+	}
+
+	trx.ReferenceNumber = ref
+	trx.RequestFingerprint = fingerprint
+}
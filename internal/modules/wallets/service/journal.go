@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+// maxJournalLegs bounds the number of accounts one journal locks.
+const maxJournalLegs = 50
+
+// journalSystemAccounts are the system accounts a journal leg may name.
+var journalSystemAccounts = map[string]bool{
+	consts.SystemAccountFunding:  true,
+	consts.SystemAccountFees:     true,
+	consts.SystemAccountFX:       true,
+	consts.SystemAccountSuspense: true,
+}
+
+// PostJournal books req's legs as one transaction. The debits must equal the
+// credits and all accounts must share a currency; the repository re-checks
+// balances and limits of the debited accounts under lock.
+func (s *wallet) PostJournal(ctx context.Context, req presentations.CreateJournal) (presentations.Transaction, error) {
+	if err := validateReferenceNumber(req.ReferenceNumber); err != nil {
+		slog.Warn("[PostJournal] failed validation", slog.Any("err", err))
+		return presentations.Transaction{}, err
+	}
+
+	legs, total, err := parseJournalLegs(req.Legs)
+	if err != nil {
+		slog.Warn("[PostJournal] failed validation", slog.Any("err", err))
+		return presentations.Transaction{}, err
+	}
+
+	fingerprint := journalFingerprint(req.Description, legs)
+	if req.ReferenceNumber != "" {
+		existing, err := s.checkReplay(ctx, req.ReferenceNumber, fingerprint)
+		if err != nil {
+			slog.Warn("[PostJournal] failed idempotency check", slog.String("reference_number", req.ReferenceNumber), slog.Any("err", err))
+			return presentations.Transaction{}, wrapError(err)
+		}
+
+		if existing.ID != "" {
+			slog.Info("[PostJournal] replayed", slog.String("reference_number", req.ReferenceNumber))
+			return s.withLedgerEntries(ctx, "[PostJournal]", existing)
+		}
+	}
+
+	currency, err := s.journalCurrency(ctx, legs)
+	if err != nil {
+		return presentations.Transaction{}, err
+	}
+
+	payload := prepareJournalPayload(currency, req.Description, total, legs)
+	applyReference(&payload.Transaction, req.ReferenceNumber, fingerprint)
+
+	entries, err := s.repo.PostJournal(ctx, payload)
+	if errors.Is(err, repository.ErrDuplicateReference) {
+		existing, err := s.resolveDuplicate(ctx, req.ReferenceNumber, fingerprint)
+		if err != nil {
+			slog.Warn("[PostJournal] failed post journal", slog.String("reference_number", req.ReferenceNumber), slog.Any("err", err))
+			return presentations.Transaction{}, wrapError(err)
+		}
+
+		slog.Info("[PostJournal] replayed", slog.String("reference_number", req.ReferenceNumber))
+		return s.withLedgerEntries(ctx, "[PostJournal]", existing)
+	}
+	if err != nil {
+		slog.Warn("[PostJournal] failed post journal", slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	slog.Info("[PostJournal] success", slog.String("id", payload.Transaction.ID), slog.Int("legs", len(entries)))
+	return toTransaction(payload.Transaction, entries), nil
+}
+
+// journalLeg is a validated presentations.JournalLeg.
+type journalLeg struct {
+	accountID   int
+	systemCode  string
+	entryType   consts.EntryType
+	amount      utils.Decimal
+	description string
+}
+
+// parseJournalLegs validates every leg and returns them with the journal's
+// total debit.
+func parseJournalLegs(req []presentations.JournalLeg) ([]journalLeg, utils.Decimal, error) {
+	if len(req) < 2 || len(req) > maxJournalLegs {
+		return nil, utils.Decimal{}, validationError(ErrInvalidJournal, fmt.Sprintf("a journal needs between 2 and %d legs", maxJournalLegs))
+	}
+
+	var (
+		legs            = make([]journalLeg, 0, len(req))
+		debits, credits utils.Decimal
+		customer        bool
+	)
+	for i, l := range req {
+		leg := journalLeg{
+			accountID:   l.AccountID,
+			systemCode:  l.SystemAccount,
+			entryType:   consts.EntryType(l.EntryType),
+			description: l.Description,
+		}
+
+		switch {
+		case l.AccountID != 0 && l.SystemAccount != "":
+			return nil, utils.Decimal{}, validationError(ErrInvalidJournal, fmt.Sprintf("legs[%d]: set either account_id or system_account", i))
+		case l.SystemAccount != "":
+			if !journalSystemAccounts[l.SystemAccount] {
+				return nil, utils.Decimal{}, validationError(ErrInvalidJournal, fmt.Sprintf("legs[%d]: unknown system_account %q", i, l.SystemAccount))
+			}
+		default:
+			if err := validateAccountID(l.AccountID); err != nil {
+				return nil, utils.Decimal{}, err
+			}
+			customer = true
+		}
+
+		amount, err := utils.ParseDecimal(l.Amount)
+		if err != nil {
+			return nil, utils.Decimal{}, wrapError(err)
+		}
+
+		if err := validateAmount(amount); err != nil {
+			return nil, utils.Decimal{}, err
+		}
+		leg.amount = amount
+
+		switch leg.entryType {
+		case consts.EntryTypeDebit:
+			debits, err = debits.CheckedAdd(amount)
+		case consts.EntryTypeCredit:
+			credits, err = credits.CheckedAdd(amount)
+		default:
+			return nil, utils.Decimal{}, validationError(ErrInvalidJournal, fmt.Sprintf("legs[%d]: entry_type must be debit or credit", i))
+		}
+
+		if err != nil {
+			return nil, utils.Decimal{}, validationError(ErrInvalidJournal, "journal total is out of range").withCause(err)
+		}
+
+		legs = append(legs, leg)
+	}
+
+	if !customer {
+		return nil, utils.Decimal{}, validationError(ErrInvalidJournal, "a journal needs at least one account_id leg")
+	}
+
+	if debits.Cmp(credits) != 0 {
+		return nil, utils.Decimal{}, ErrUnbalancedJournal
+	}
+
+	return legs, debits, nil
+}
+
+// journalCurrency returns the currency shared by the journal's customer
+// accounts, after checking they exist and every amount fits it.
+func (s *wallet) journalCurrency(ctx context.Context, legs []journalLeg) (string, error) {
+	var currency string
+	seen := make(map[int]bool)
+	for _, leg := range legs {
+		if leg.systemCode != "" || seen[leg.accountID] {
+			continue
+		}
+		seen[leg.accountID] = true
+
+		account, err := s.repo.GetAccount(ctx, leg.accountID)
+		if err != nil {
+			slog.Warn("[PostJournal] failed GetAccount", slog.Any("err", err))
+			return "", wrapError(err)
+		}
+
+		if account.ID == 0 {
+			slog.Warn("[PostJournal] failed data not found", slog.Any("accountID", leg.accountID))
+			return "", ErrAccountNotFound
+		}
+
+		if currency != "" && account.Currency != currency {
+			slog.Warn("[PostJournal] failed currency mismatch", slog.String("currency", currency), slog.String("other", account.Currency))
+			return "", validationError(ErrCurrencyMismatch, "journal accounts have different currencies")
+		}
+		currency = account.Currency
+	}
+
+	for _, leg := range legs {
+		if err := s.validateCurrencyAmount(currency, leg.amount); err != nil {
+			slog.Warn("[PostJournal] failed validation", slog.String("currency", currency), slog.Any("err", err))
+			return "", err
+		}
+	}
+
+	return currency, nil
+}
+
+func journalFingerprint(description string, legs []journalLeg) string {
+	parts := []interface{}{consts.TransactionTypeJournal, description}
+	for _, leg := range legs {
+		parts = append(parts, leg.accountID, leg.systemCode, leg.entryType, leg.amount)
+	}
+
+	return requestFingerprint(parts...)
+}
+
+func prepareJournalPayload(currency, description string, total utils.Decimal, legs []journalLeg) repository.JournalPayload {
+	now := time.Now()
+	if description == "" {
+		description = fmt.Sprintf("journal of %d legs", len(legs))
+	}
+
+	payload := repository.JournalPayload{
+		Currency: currency,
+		Transaction: repository.Transaction{
+			ID:              uuid.NewString(),
+			ReferenceNumber: uuid.NewString(),
+			Type:            consts.TransactionTypeJournal,
+			Amount:          total,
+			Status:          consts.TransactionStatusCompleted,
+			Description:     description,
+			CreatedAt:       now,
+		},
+	}
+
+	for _, leg := range legs {
+		entryDescription := leg.description
+		if entryDescription == "" {
+			entryDescription = "journal entry"
+		}
+
+		payload.Legs = append(payload.Legs, repository.JournalLeg{
+			LedgerEntry: repository.LedgerEntry{
+				ID:            uuid.NewString(),
+				TransactionID: payload.Transaction.ID,
+				AccountID:     leg.accountID,
+				EntryType:     leg.entryType,
+				Amount:        leg.amount,
+				Description:   entryDescription,
+				CreatedAt:     now,
+			},
+			SystemCode: leg.systemCode,
+		})
+	}
+
+	return payload
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPostJournal(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	svc := NewWalletService(mRepo)
+
+	usd := func(accountID int) repository.Account {
+		return repository.Account{ID: accountID, AccountID: accountID, Currency: consts.DefaultCurrency, Balance: utils.NewDecimal(100)}
+	}
+
+	split := []presentations.JournalLeg{
+		{AccountID: 1, EntryType: "debit", Amount: "100"},
+		{AccountID: 2, EntryType: "credit", Amount: "70"},
+		{AccountID: 3, EntryType: "credit", Amount: "29.5"},
+		{SystemAccount: consts.SystemAccountFees, EntryType: "credit", Amount: "0.5"},
+	}
+
+	testTables := []struct {
+		name string
+		mock func()
+		err  error
+		legs []presentations.JournalLeg
+	}{
+		{
+			name: "FAILED single leg",
+			err:  ErrInvalidJournal,
+			legs: split[:1],
+			mock: func() {},
+		},
+		{
+			name: "FAILED unbalanced",
+			err:  ErrUnbalancedJournal,
+			legs: split[:3],
+			mock: func() {},
+		},
+		{
+			name: "FAILED entry type",
+			err:  ErrInvalidJournal,
+			legs: []presentations.JournalLeg{{AccountID: 1, EntryType: "debt", Amount: "1"}, {AccountID: 2, EntryType: "credit", Amount: "1"}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED unknown system account",
+			err:  ErrInvalidJournal,
+			legs: []presentations.JournalLeg{{AccountID: 1, EntryType: "debit", Amount: "1"}, {SystemAccount: "treasury", EntryType: "credit", Amount: "1"}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED account and system account",
+			err:  ErrInvalidJournal,
+			legs: []presentations.JournalLeg{{AccountID: 1, SystemAccount: "fees", EntryType: "debit", Amount: "1"}, {AccountID: 2, EntryType: "credit", Amount: "1"}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED only system accounts",
+			err:  ErrInvalidJournal,
+			legs: []presentations.JournalLeg{{SystemAccount: "suspense", EntryType: "debit", Amount: "1"}, {SystemAccount: "fees", EntryType: "credit", Amount: "1"}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED zero amount",
+			err:  ErrAmountTooSmall,
+			legs: []presentations.JournalLeg{{AccountID: 1, EntryType: "debit", Amount: "0"}, {AccountID: 2, EntryType: "credit", Amount: "0"}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED amount above maximum",
+			err:  ErrInvalidAmount,
+			legs: []presentations.JournalLeg{{AccountID: 1, EntryType: "debit", Amount: "1000000000.01"}, {AccountID: 2, EntryType: "credit", Amount: "1000000000.01"}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED data not found",
+			err:  ErrAccountNotFound,
+			legs: split,
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 1).Return(usd(1), nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
+			},
+		},
+		{
+			name: "FAILED currency mismatch",
+			err:  ErrCurrencyMismatch,
+			legs: split,
+			mock: func() {
+				eur := usd(2)
+				eur.Currency = "EUR"
+				mRepo.EXPECT().GetAccount(ctx, 1).Return(usd(1), nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(eur, nil)
+			},
+		},
+		{
+			name: "FAILED insufficient funds",
+			err:  ErrInsufficientFunds,
+			legs: split,
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, id int) (repository.Account, error) {
+					return usd(id), nil
+				}).Times(3)
+				mRepo.EXPECT().PostJournal(ctx, gomock.Any()).Return(nil, repository.ErrInsufficientBalance)
+			},
+		},
+		{
+			name: "SUCCESS",
+			legs: split,
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, id int) (repository.Account, error) {
+					return usd(id), nil
+				}).Times(3)
+				mRepo.EXPECT().PostJournal(ctx, gomock.AssignableToTypeOf(repository.JournalPayload{})).DoAndReturn(
+					func(_ context.Context, p repository.JournalPayload) ([]repository.LedgerEntry, error) {
+						assert.Equal(t, consts.DefaultCurrency, p.Currency)
+						assert.Equal(t, consts.TransactionTypeJournal, p.Transaction.Type)
+						assert.Equal(t, "100", p.Transaction.Amount.String())
+						assert.Len(t, p.Legs, 4)
+						assert.Equal(t, consts.SystemAccountFees, p.Legs[3].SystemCode)
+						assert.Equal(t, consts.EntryTypeCredit, p.Legs[3].EntryType)
+
+						entries := make([]repository.LedgerEntry, len(p.Legs))
+						for i, leg := range p.Legs {
+							entries[i] = leg.LedgerEntry
+						}
+						entries[3].AccountID = -2
+						return entries, nil
+					})
+			},
+		},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			result, err := svc.PostJournal(ctx, presentations.CreateJournal{Legs: tt.legs})
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, "journal", result.Type)
+				assert.Len(t, result.Entries, 4)
+				assert.Equal(t, -2, result.Entries[3].AccountID)
+			}
+		})
+	}
+}
+
+func TestPostJournalReplay(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	svc := NewWalletService(mRepo)
+
+	legs := []presentations.JournalLeg{
+		{AccountID: 1, EntryType: "debit", Amount: "10"},
+		{AccountID: 2, EntryType: "credit", Amount: "10"},
+	}
+	parsed, _, err := parseJournalLegs(legs)
+	assert.NoError(t, err)
+
+	existing := repository.Transaction{
+		ID:                 "5f0c2f5e-6a3b-4d8e-9c1a-2b7d4e6f8a90",
+		Type:               consts.TransactionTypeJournal,
+		ReferenceNumber:    "payout-1",
+		RequestFingerprint: journalFingerprint("", parsed),
+	}
+
+	mRepo.EXPECT().GetTransactionByReference(ctx, "payout-1").Return(existing, nil)
+	mRepo.EXPECT().GetTransactionLedgerEntries(ctx, existing.ID).Return([]repository.LedgerEntry{{AccountID: 1}, {AccountID: 2}}, nil)
+	result, err := svc.PostJournal(ctx, presentations.CreateJournal{ReferenceNumber: "payout-1", Legs: legs})
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, result.ID)
+	assert.Len(t, result.Entries, 2)
+
+	mRepo.EXPECT().GetTransactionByReference(ctx, "payout-1").Return(existing, nil)
+	legs[1].Amount = "9"
+	legs = append(legs, presentations.JournalLeg{AccountID: 3, EntryType: "credit", Amount: "1"})
+	_, err = svc.PostJournal(ctx, presentations.CreateJournal{ReferenceNumber: "payout-1", Legs: legs})
+	assert.ErrorIs(t, err, ErrIdempotencyConflict)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+func (s *wallet) GetAccountLimits(ctx context.Context, accountID int) (presentations.AccountLimits, error) {
+	if err := s.checkAccountExists(ctx, "[GetAccountLimits]", accountID); err != nil {
+		return presentations.AccountLimits{}, err
+	}
+
+	overrides, err := s.repo.GetLimitOverrides(ctx, accountID)
+	if err != nil {
+		slog.Warn("[GetAccountLimits] failed GetLimitOverrides", slog.Any("err", err))
+		return presentations.AccountLimits{}, wrapError(err)
+	}
+
+	return s.toAccountLimits(overrides), nil
+}
+
+// SetAccountLimits replaces the account's limit overrides with req. The
+// repository picks them up from the next outgoing movement on.
+func (s *wallet) SetAccountLimits(ctx context.Context, accountID int, req presentations.SetAccountLimits) (presentations.AccountLimits, error) {
+	actor, err := principalActor(ctx)
+	if err != nil {
+		slog.Warn("[SetAccountLimits] failed validation", slog.Any("err", err))
+		return presentations.AccountLimits{}, err
+	}
+
+	overrides := repository.LimitOverrides{
+		AccountID:     accountID,
+		MinAmount:     req.MinAmount,
+		MaxAmount:     req.MaxAmount,
+		DailyAmount:   req.DailyAmount,
+		MonthlyAmount: req.MonthlyAmount,
+		DailyCount:    req.DailyCount,
+		MonthlyCount:  req.MonthlyCount,
+		UpdatedBy:     actor,
+		UpdatedAt:     time.Now(),
+	}
+
+	if err := s.validateLimitOverrides(overrides); err != nil {
+		slog.Warn("[SetAccountLimits] failed validation", slog.Any("err", err))
+		return presentations.AccountLimits{}, err
+	}
+
+	if err := s.checkAccountExists(ctx, "[SetAccountLimits]", accountID); err != nil {
+		return presentations.AccountLimits{}, err
+	}
+
+	if err := s.repo.SetLimitOverrides(ctx, overrides); err != nil {
+		slog.Warn("[SetAccountLimits] failed SetLimitOverrides", slog.Any("accountID", accountID), slog.Any("err", err))
+		return presentations.AccountLimits{}, wrapError(err)
+	}
+
+	slog.Info("[SetAccountLimits] success", slog.Any("accountID", accountID), slog.String("actor", overrides.UpdatedBy))
+	return s.toAccountLimits(overrides), nil
+}
+
+func (s *wallet) checkAccountExists(ctx context.Context, logPrefix string, accountID int) error {
+	if err := validateAccountID(accountID); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.Any("err", err))
+		return err
+	}
+
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetAccount", slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	if account.ID == 0 {
+		slog.Warn(logPrefix+" failed data not found", slog.Any("accountID", accountID))
+		return ErrAccountNotFound
+	}
+
+	return nil
+}
+
+// validateLimitOverrides refuses negative rules and a minimum above the
+// maximum once the overrides are applied to the defaults.
+func (s *wallet) validateLimitOverrides(o repository.LimitOverrides) error {
+	for _, amount := range []*utils.Decimal{o.MinAmount, o.MaxAmount, o.DailyAmount, o.MonthlyAmount} {
+		if amount != nil && amount.IsNegative() {
+			return ErrInvalidAccountLimits
+		}
+	}
+
+	for _, count := range []*int{o.DailyCount, o.MonthlyCount} {
+		if count != nil && *count < 0 {
+			return ErrInvalidAccountLimits
+		}
+	}
+
+	limits := s.limits.Apply(o)
+	if limits.MaxAmount.IsPositive() && limits.MinAmount.Cmp(limits.MaxAmount) > 0 {
+		return validationError(ErrInvalidAccountLimits, "min_amount cannot be greater than max_amount")
+	}
+
+	return nil
+}
+
+func (s *wallet) toAccountLimits(o repository.LimitOverrides) presentations.AccountLimits {
+	resp := presentations.AccountLimits{
+		AccountID: o.AccountID,
+		Effective: toEffectiveLimits(s.limits.Apply(o)),
+		Overrides: presentations.Limits{
+			MinAmount:     o.MinAmount,
+			MaxAmount:     o.MaxAmount,
+			DailyAmount:   o.DailyAmount,
+			MonthlyAmount: o.MonthlyAmount,
+			DailyCount:    o.DailyCount,
+			MonthlyCount:  o.MonthlyCount,
+		},
+		UpdatedBy: o.UpdatedBy,
+	}
+
+	if !o.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &o.UpdatedAt
+	}
+
+	return resp
+}
+
+// toEffectiveLimits leaves disabled rules out.
+func toEffectiveLimits(l repository.Limits) presentations.Limits {
+	var resp presentations.Limits
+	amounts := []struct {
+		value utils.Decimal
+		field **utils.Decimal
+	}{
+		{l.MinAmount, &resp.MinAmount},
+		{l.MaxAmount, &resp.MaxAmount},
+		{l.DailyAmount, &resp.DailyAmount},
+		{l.MonthlyAmount, &resp.MonthlyAmount},
+	}
+	for _, a := range amounts {
+		if a.value.IsPositive() {
+			v := a.value
+			*a.field = &v
+		}
+	}
+
+	if l.DailyCount > 0 {
+		resp.DailyCount = &l.DailyCount
+	}
+
+	if l.MonthlyCount > 0 {
+		resp.MonthlyCount = &l.MonthlyCount
+	}
+
+	return resp
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetAccountLimits(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	account := repository.Account{ID: 1, AccountID: 2, Currency: consts.DefaultCurrency}
+	svc := NewWalletService(mRepo, WithLimits(repository.Limits{
+		MinAmount:   utils.NewDecimal(1),
+		MaxAmount:   utils.NewDecimal(1000),
+		DailyAmount: utils.NewDecimal(5000),
+	}))
+
+	mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+	raised := utils.NewDecimal(10000)
+	lifted := utils.Decimal{}
+	mRepo.EXPECT().GetLimitOverrides(ctx, 2).Return(repository.LimitOverrides{
+		AccountID:   2,
+		DailyAmount: &raised,
+		MaxAmount:   &lifted,
+		UpdatedBy:   "ops",
+	}, nil)
+
+	result, err := svc.GetAccountLimits(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", result.Effective.MinAmount.String())
+	assert.Nil(t, result.Effective.MaxAmount)
+	assert.Equal(t, "10000", result.Effective.DailyAmount.String())
+	assert.Nil(t, result.Effective.DailyCount)
+	assert.Equal(t, "0", result.Overrides.MaxAmount.String())
+	assert.Nil(t, result.Overrides.MinAmount)
+
+	mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{}, nil)
+	_, err = svc.GetAccountLimits(ctx, 3)
+	assert.ErrorIs(t, err, ErrAccountNotFound)
+}
+
+func TestSetAccountLimits(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := auth.NewContext(context.TODO(), auth.Principal{Subject: "ops@example.com", Role: consts.RoleOperator})
+	account := repository.Account{ID: 1, AccountID: 2, Currency: consts.DefaultCurrency}
+	svc := NewWalletService(mRepo)
+
+	decimal := func(s string) *utils.Decimal {
+		d := utils.MustParseDecimal(s)
+		return &d
+	}
+	count := func(n int) *int {
+		return &n
+	}
+
+	testTables := []struct {
+		name string
+		mock func()
+		err  error
+		ctx  context.Context
+		req  presentations.SetAccountLimits
+	}{
+		{
+			name: "FAILED without principal",
+			err:  ErrActorRequired,
+			ctx:  context.TODO(),
+			req:  presentations.SetAccountLimits{Limits: presentations.Limits{DailyAmount: decimal("100")}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED negative amount",
+			err:  ErrInvalidAccountLimits,
+			req:  presentations.SetAccountLimits{Limits: presentations.Limits{DailyAmount: decimal("-1")}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED negative count",
+			err:  ErrInvalidAccountLimits,
+			req:  presentations.SetAccountLimits{Limits: presentations.Limits{MonthlyCount: count(-1)}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED minimum above default maximum",
+			err:  ErrInvalidAccountLimits,
+			req:  presentations.SetAccountLimits{Limits: presentations.Limits{MinAmount: decimal("2000000")}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED data not found",
+			err:  ErrAccountNotFound,
+			req:  presentations.SetAccountLimits{Limits: presentations.Limits{DailyCount: count(10)}},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
+			},
+		},
+		{
+			name: "SUCCESS",
+			req:  presentations.SetAccountLimits{Limits: presentations.Limits{MaxAmount: decimal("0"), DailyCount: count(10)}},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+				mRepo.EXPECT().SetLimitOverrides(ctx, gomock.AssignableToTypeOf(repository.LimitOverrides{})).DoAndReturn(
+					func(_ context.Context, o repository.LimitOverrides) error {
+						assert.Equal(t, 2, o.AccountID)
+						assert.Equal(t, "ops@example.com", o.UpdatedBy)
+						assert.True(t, o.MaxAmount.IsZero())
+						assert.Equal(t, 10, *o.DailyCount)
+						assert.Nil(t, o.MinAmount)
+						return nil
+					})
+			},
+		},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			callCtx := ctx
+			if tt.ctx != nil {
+				callCtx = tt.ctx
+			}
+
+			_, err := svc.SetAccountLimits(callCtx, 2, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
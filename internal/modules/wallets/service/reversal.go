@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+)
+
+// ReverseTransaction books a reversal of a transfer, moving the amount back
+// from the destination to the source together with the matching share of
+// the transfer's fee. Several partial reversals are allowed as long as
+// together they do not exceed the original amount.
+func (s *wallet) ReverseTransaction(ctx context.Context, id string, req presentations.CreateReversal) (presentations.Transaction, error) {
+	if err := validateTransactionID(id); err != nil {
+		slog.Warn("[ReverseTransaction] failed validation", slog.Any("err", err))
+		return presentations.Transaction{}, err
+	}
+
+	if err := validateReferenceNumber(req.ReferenceNumber); err != nil {
+		slog.Warn("[ReverseTransaction] failed validation", slog.Any("err", err))
+		return presentations.Transaction{}, err
+	}
+
+	var reqAmount utils.Decimal
+	if req.Amount != "" {
+		amount, err := utils.ParseDecimal(req.Amount)
+		if err != nil {
+			slog.Warn("[ReverseTransaction] failed validation", slog.Any("err", err))
+			return presentations.Transaction{}, wrapError(err)
+		}
+
+		if err := validateAmount(amount); err != nil {
+			slog.Warn("[ReverseTransaction] failed validation", slog.Any("err", err))
+			return presentations.Transaction{}, err
+		}
+		reqAmount = amount
+	}
+
+	fingerprint := requestFingerprint(consts.TransactionTypeReversal, id, reqAmount)
+	if req.ReferenceNumber != "" {
+		existing, err := s.checkReplay(ctx, req.ReferenceNumber, fingerprint)
+		if err != nil {
+			slog.Warn("[ReverseTransaction] failed idempotency check", slog.Any("req", req), slog.Any("err", err))
+			return presentations.Transaction{}, wrapError(err)
+		}
+
+		if existing.ID != "" {
+			slog.Info("[ReverseTransaction] replayed", slog.Any("req", req))
+			return toTransaction(existing, nil), nil
+		}
+	}
+
+	original, err := s.repo.GetTransaction(ctx, id)
+	if err != nil {
+		slog.Warn("[ReverseTransaction] failed GetTransaction", slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	if original.ID == "" {
+		slog.Warn("[ReverseTransaction] failed data not found", slog.String("id", id))
+		return presentations.Transaction{}, ErrTransactionNotFound
+	}
+
+	if original.Type != consts.TransactionTypeTransfer || isFXTransfer(original) {
+		slog.Warn("[ReverseTransaction] failed not a transfer", slog.String("id", id), slog.Any("type", original.Type))
+		return presentations.Transaction{}, ErrNotReversible
+	}
+
+	reversed, err := s.repo.GetReversedAmount(ctx, id)
+	if err != nil {
+		slog.Warn("[ReverseTransaction] failed GetReversedAmount", slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	remaining := original.Amount.Sub(reversed)
+	if !remaining.IsPositive() {
+		slog.Warn("[ReverseTransaction] failed already reversed", slog.String("id", id))
+		return presentations.Transaction{}, ErrNotReversible
+	}
+
+	if reqAmount.IsZero() {
+		reqAmount = remaining
+	}
+
+	if reqAmount.Cmp(remaining) > 0 {
+		slog.Warn("[ReverseTransaction] failed amount exceeds remaining", slog.String("id", id), slog.Any("remaining", remaining))
+		return presentations.Transaction{}, ErrReversalExceeded
+	}
+
+	// a partial refund must still be a whole number of the currency's minor
+	// units, the full remaining amount always is
+	if req.Amount != "" {
+		if _, err := s.validateAccountAmount(ctx, "[ReverseTransaction]", int(original.FromAccountID.Int64), reqAmount); err != nil {
+			return presentations.Transaction{}, err
+		}
+	}
+
+	payload := prepareReversalPayload(original, reqAmount, req.Reason)
+	payload.AllowNegativeBalance = s.allowReversalOverdraft
+	payload.FeeRefund, err = s.newFeeRefund(payload.Transaction, payload.To.AccountID, transactionFee(original))
+	if err != nil {
+		slog.Warn("[ReverseTransaction] failed fee refund", slog.String("id", id), slog.Any("err", err))
+		return presentations.Transaction{}, err
+	}
+	applyReference(&payload.Transaction, req.ReferenceNumber, fingerprint)
+
+	err = s.repo.ReverseTransaction(ctx, payload)
+	if errors.Is(err, repository.ErrDuplicateReference) {
+		existing, err := s.resolveDuplicate(ctx, req.ReferenceNumber, fingerprint)
+		if err != nil {
+			slog.Warn("[ReverseTransaction] failed reverse transaction", slog.Any("req", req), slog.Any("err", err))
+			return presentations.Transaction{}, wrapError(err)
+		}
+
+		slog.Info("[ReverseTransaction] replayed", slog.Any("req", req))
+		return toTransaction(existing, nil), nil
+	}
+	if err != nil {
+		slog.Warn("[ReverseTransaction] failed reverse transaction", slog.String("id", id), slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	slog.Info("[ReverseTransaction] success", slog.String("id", id), slog.Any("req", req))
+	return toTransaction(payload.Transaction, nil), nil
+}
+
+// prepareReversalPayload mirrors the original transfer: the destination is
+// debited and the source credited.
+func prepareReversalPayload(original repository.Transaction, amount utils.Decimal, reason string) repository.ReversalPayload {
+	var payload repository.ReversalPayload
+
+	from := int(original.ToAccountID.Int64)
+	to := int(original.FromAccountID.Int64)
+
+	description := reason
+	if description == "" {
+		description = fmt.Sprintf("reversal %s of transaction %s", amount, original.ID)
+	}
+
+	payload.From = repository.Account{AccountID: from}
+	payload.To = repository.Account{AccountID: to}
+	payload.Amount = amount
+
+	payload.Transaction = repository.Transaction{
+		ID:              uuid.NewString(),
+		ReferenceNumber: uuid.NewString(),
+		Type:            consts.TransactionTypeReversal,
+		FromAccountID: sql.NullInt64{
+			Int64: int64(from),
+			Valid: true,
+		},
+		ToAccountID: sql.NullInt64{
+			Int64: int64(to),
+			Valid: true,
+		},
+		Amount:      amount,
+		Status:      consts.TransactionStatusCompleted,
+		Description: description,
+		CreatedAt:   time.Now(),
+		OriginalTransactionID: sql.NullString{
+			String: original.ID,
+			Valid:  true,
+		},
+	}
+
+	payload.LedgerEntryFrom = repository.LedgerEntry{
+		ID:            uuid.NewString(),
+		TransactionID: payload.Transaction.ID,
+		AccountID:     from,
+		EntryType:     consts.EntryTypeDebit,
+		Amount:        amount,
+		Description:   "reversal transaction",
+		CreatedAt:     time.Now(),
+	}
+
+	payload.LedgerEntryTo = repository.LedgerEntry{
+		ID:            uuid.NewString(),
+		TransactionID: payload.Transaction.ID,
+		AccountID:     to,
+		EntryType:     consts.EntryTypeCredit,
+		Amount:        amount,
+		Description:   "reversal transaction",
+		CreatedAt:     time.Now(),
+	}
+
+	return payload
+}
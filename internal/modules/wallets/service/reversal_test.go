@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	errTest := errors.New("test")
+	ctx := staffCtx()
+
+	const id = "0b8f6f58-3c1a-4a8e-8f0e-6d0e3c8f4a21"
+	transfer := repository.Transaction{
+		ID:            id,
+		Type:          consts.TransactionTypeTransfer,
+		FromAccountID: sql.NullInt64{Int64: 3, Valid: true},
+		ToAccountID:   sql.NullInt64{Int64: 2, Valid: true},
+		Amount:        utils.NewDecimal(100),
+		Status:        consts.TransactionStatusCompleted,
+	}
+
+	testTables := []struct {
+		name      string
+		mock      func()
+		err       error
+		id        string
+		req       presentations.CreateReversal
+		overdraft bool
+		amount    string
+	}{
+		{
+			name: "FAILED validation id",
+			err:  ErrInvalidTransactionID,
+			id:   "not-a-uuid",
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation amount",
+			err:  ErrInvalidAmount,
+			id:   id,
+			req:  presentations.CreateReversal{Amount: "abc"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED data not found",
+			err:  ErrTransactionNotFound,
+			id:   id,
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(repository.Transaction{}, nil)
+			},
+		},
+		{
+			name: "FAILED not a transfer",
+			err:  ErrNotReversible,
+			id:   id,
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(repository.Transaction{
+					ID:   id,
+					Type: consts.TransactionTypeDeposit,
+				}, nil)
+			},
+		},
+		{
+			name: "FAILED cross-currency transfer",
+			err:  ErrNotReversible,
+			id:   id,
+			mock: func() {
+				fx := transfer
+				fx.Metadata = []byte(`{"fx":{"rate":"0.92","source_currency":"USD","destination_currency":"EUR"}}`)
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(fx, nil)
+			},
+		},
+		{
+			name: "FAILED already fully reversed",
+			err:  ErrNotReversible,
+			id:   id,
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(transfer, nil)
+				mRepo.EXPECT().GetReversedAmount(ctx, id).Return(utils.NewDecimal(100), nil)
+			},
+		},
+		{
+			name: "FAILED amount exceeds remaining",
+			err:  ErrReversalExceeded,
+			id:   id,
+			req:  presentations.CreateReversal{Amount: "50"},
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(transfer, nil)
+				mRepo.EXPECT().GetReversedAmount(ctx, id).Return(utils.NewDecimal(60), nil)
+			},
+		},
+		{
+			name: "FAILED destination has no funds",
+			err:  ErrInsufficientFunds,
+			id:   id,
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(transfer, nil)
+				mRepo.EXPECT().GetReversedAmount(ctx, id).Return(utils.Decimal{}, nil)
+				mRepo.EXPECT().ReverseTransaction(ctx, gomock.AssignableToTypeOf(repository.ReversalPayload{})).Return(repository.ErrInsufficientBalance)
+			},
+		},
+		{
+			name: "FAILED db error",
+			err:  errTest,
+			id:   id,
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(transfer, nil)
+				mRepo.EXPECT().GetReversedAmount(ctx, id).Return(utils.Decimal{}, errTest)
+			},
+		},
+		{
+			name:   "SUCCESS full reversal of remaining amount",
+			id:     id,
+			amount: "40",
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(transfer, nil)
+				mRepo.EXPECT().GetReversedAmount(ctx, id).Return(utils.NewDecimal(60), nil)
+				mRepo.EXPECT().ReverseTransaction(ctx, gomock.AssignableToTypeOf(repository.ReversalPayload{})).DoAndReturn(
+					func(_ context.Context, p repository.ReversalPayload) error {
+						assert.Equal(t, 2, p.From.AccountID)
+						assert.Equal(t, 3, p.To.AccountID)
+						assert.Equal(t, "40", p.Amount.String())
+						assert.False(t, p.AllowNegativeBalance)
+						assert.Nil(t, p.FeeRefund)
+						return nil
+					})
+			},
+		},
+		{
+			name:   "SUCCESS refunds the fee",
+			id:     id,
+			amount: "100",
+			mock: func() {
+				charged := transfer
+				charged.Metadata = []byte(`{"fee":{"currency":"USD","flat":"0","percent":"0.5","variable":"0.5","amount":"0.5"}}`)
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(charged, nil)
+				mRepo.EXPECT().GetReversedAmount(ctx, id).Return(utils.Decimal{}, nil)
+				mRepo.EXPECT().ReverseTransaction(ctx, gomock.AssignableToTypeOf(repository.ReversalPayload{})).DoAndReturn(
+					func(_ context.Context, p repository.ReversalPayload) error {
+						if assert.NotNil(t, p.FeeRefund) {
+							assert.Equal(t, "USD", p.FeeRefund.Currency)
+							assert.Equal(t, "0.5", p.FeeRefund.Fee.String())
+							assert.Equal(t, 2, p.FeeRefund.Scale)
+							assert.Equal(t, 3, p.FeeRefund.LedgerEntryPayer.AccountID)
+							assert.Equal(t, consts.EntryTypeCredit, p.FeeRefund.LedgerEntryPayer.EntryType)
+							assert.Equal(t, consts.EntryTypeDebit, p.FeeRefund.LedgerEntryRevenue.EntryType)
+						}
+						return nil
+					})
+			},
+		},
+		{
+			name: "FAILED partial amount beyond currency scale",
+			err:  ErrAmountScale,
+			id:   id,
+			req:  presentations.CreateReversal{Amount: "25.555"},
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(transfer, nil)
+				mRepo.EXPECT().GetReversedAmount(ctx, id).Return(utils.Decimal{}, nil)
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency}, nil)
+			},
+		},
+		{
+			name:      "SUCCESS partial reversal with overdraft allowed",
+			id:        id,
+			req:       presentations.CreateReversal{Amount: "25.5", Reason: "customer refund"},
+			overdraft: true,
+			amount:    "25.5",
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, id).Return(transfer, nil)
+				mRepo.EXPECT().GetReversedAmount(ctx, id).Return(utils.Decimal{}, nil)
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency}, nil)
+				mRepo.EXPECT().ReverseTransaction(ctx, gomock.AssignableToTypeOf(repository.ReversalPayload{})).DoAndReturn(
+					func(_ context.Context, p repository.ReversalPayload) error {
+						assert.True(t, p.AllowNegativeBalance)
+						assert.Equal(t, "customer refund", p.Transaction.Description)
+						return nil
+					})
+			},
+		},
+		{
+			name:   "SUCCESS replay",
+			id:     id,
+			req:    presentations.CreateReversal{Amount: "10", ReferenceNumber: "key-1"},
+			amount: "10",
+			mock: func() {
+				mRepo.EXPECT().GetTransactionByReference(ctx, "key-1").Return(repository.Transaction{
+					ID:                    "trx-1",
+					Type:                  consts.TransactionTypeReversal,
+					Amount:                utils.NewDecimal(10),
+					FromAccountID:         sql.NullInt64{Int64: 2, Valid: true},
+					ToAccountID:           sql.NullInt64{Int64: 3, Valid: true},
+					OriginalTransactionID: sql.NullString{String: id, Valid: true},
+					RequestFingerprint:    requestFingerprint(consts.TransactionTypeReversal, id, utils.NewDecimal(10)),
+				}, nil)
+			},
+		},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			svc := NewWalletService(mRepo, WithReversalOverdraft(tt.overdraft))
+			resp, err := svc.ReverseTransaction(ctx, tt.id, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.NotEmpty(t, resp.ID)
+				assert.Equal(t, "reversal", resp.Type)
+				assert.Equal(t, id, resp.OriginalTransactionID)
+				assert.Equal(t, tt.amount, resp.Amount.String())
+				assert.Equal(t, 2, *resp.SourceAccountID)
+				assert.Equal(t, 3, *resp.DestinationAccountID)
+			}
+		})
+	}
+}
@@ -0,0 +1,577 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+)
+
+const (
+	defaultScheduleMaxAttempts  = 5
+	defaultScheduleRetryBackoff = 5 * time.Minute
+	maxScheduleRetryBackoff     = 24 * time.Hour
+
+	// scheduleClaimBatch bounds how many schedules one worker claims at a
+	// time; scheduleLease must outlast running all of them.
+	scheduleClaimBatch = 20
+	scheduleLease      = 5 * time.Minute
+)
+
+func (s *wallet) CreateScheduledTransfer(ctx context.Context, req presentations.CreateScheduledTransfer) (presentations.ScheduledTransfer, error) {
+	if err := validateAccountID(req.SourceAccountID); err != nil {
+		slog.Warn("[CreateScheduledTransfer] failed validation", slog.Any("err", err))
+		return presentations.ScheduledTransfer{}, err
+	}
+
+	if err := validateAccountID(req.DestinationAccountID); err != nil {
+		slog.Warn("[CreateScheduledTransfer] failed validation", slog.Any("err", err))
+		return presentations.ScheduledTransfer{}, err
+	}
+
+	if req.SourceAccountID == req.DestinationAccountID {
+		return presentations.ScheduledTransfer{}, ErrSameAccount
+	}
+
+	amount, err := utils.ParseDecimal(req.Amount)
+	if err != nil {
+		slog.Warn("[CreateScheduledTransfer] failed validation", slog.Any("err", err))
+		return presentations.ScheduledTransfer{}, wrapError(err)
+	}
+
+	if err := validateAmount(amount); err != nil {
+		slog.Warn("[CreateScheduledTransfer] failed validation", slog.Any("err", err))
+		return presentations.ScheduledTransfer{}, err
+	}
+
+	now := time.Now()
+	st := repository.ScheduledTransfer{
+		ID:                   uuid.NewString(),
+		SourceAccountID:      req.SourceAccountID,
+		DestinationAccountID: req.DestinationAccountID,
+		Amount:               amount,
+		Description:          req.Description,
+		Frequency:            req.Frequency,
+		StartAt:              now,
+		Status:               consts.ScheduleStatusActive,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+
+	if err := parseSchedule(&st, req, now); err != nil {
+		slog.Warn("[CreateScheduledTransfer] failed validation", slog.Any("req", req), slog.Any("err", err))
+		return presentations.ScheduledTransfer{}, err
+	}
+	st.NextRunAt = st.StartAt
+
+	if err := s.validateScheduleAccounts(ctx, "[CreateScheduledTransfer]", st.SourceAccountID, st.DestinationAccountID, amount); err != nil {
+		return presentations.ScheduledTransfer{}, err
+	}
+
+	// the caller is authorized by now, so a principal is present
+	creator, _ := auth.FromContext(ctx)
+	st.CreatedBy, st.CreatedByRole = creator.Subject, creator.Role
+
+	err = s.repo.CreateScheduledTransfer(ctx, st)
+	if err != nil {
+		slog.Warn("[CreateScheduledTransfer] failed create scheduled transfer", slog.Any("req", req), slog.Any("err", err))
+		return presentations.ScheduledTransfer{}, wrapError(err)
+	}
+
+	slog.Info("[CreateScheduledTransfer] success", slog.String("id", st.ID), slog.Any("req", req))
+	return toScheduledTransfer(st), nil
+}
+
+func (s *wallet) GetScheduledTransfer(ctx context.Context, id string) (presentations.ScheduledTransfer, error) {
+	st, err := s.getScheduledTransfer(ctx, "[GetScheduledTransfer]", id)
+	if err != nil {
+		return presentations.ScheduledTransfer{}, err
+	}
+
+	return toScheduledTransfer(st), nil
+}
+
+func (s *wallet) ListScheduledTransfers(ctx context.Context, req presentations.ListScheduledTransfers) ([]presentations.ScheduledTransfer, error) {
+	filter := repository.ScheduledTransferFilter{
+		AccountID: req.AccountID,
+		Status:    req.Status,
+		Limit:     req.Limit,
+	}
+
+	if filter.AccountID < 0 {
+		return nil, ErrInvalidAccountID
+	}
+
+	// customers only see the schedules of their own accounts
+	if _, ok := customerPrincipal(ctx); ok {
+		if filter.AccountID == 0 {
+			return nil, validationError(ErrInvalidFilter, "account_id is required")
+		}
+
+		if err := s.authorizeAccountID(ctx, "[ListScheduledTransfers]", filter.AccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	switch filter.Status {
+	case "", consts.ScheduleStatusActive, consts.ScheduleStatusCompleted, consts.ScheduleStatusCancelled, consts.ScheduleStatusFailed:
+	default:
+		slog.Warn("[ListScheduledTransfers] failed validation", slog.String("status", filter.Status))
+		return nil, validationError(ErrInvalidFilter, fmt.Sprintf("unknown status %q", filter.Status))
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxHistoryLimit {
+		return nil, ErrInvalidLimit
+	}
+
+	list, err := s.repo.ListScheduledTransfers(ctx, filter)
+	if err != nil {
+		slog.Warn("[ListScheduledTransfers] failed ListScheduledTransfers", slog.Any("err", err))
+		return nil, wrapError(err)
+	}
+
+	result := make([]presentations.ScheduledTransfer, 0, len(list))
+	for _, st := range list {
+		result = append(result, toScheduledTransfer(st))
+	}
+
+	return result, nil
+}
+
+// UpdateScheduledTransfer changes the amount, description or bounds of an
+// active schedule. Runs already booked are not affected.
+func (s *wallet) UpdateScheduledTransfer(ctx context.Context, id string, req presentations.UpdateScheduledTransfer) (presentations.ScheduledTransfer, error) {
+	st, err := s.getScheduledTransfer(ctx, "[UpdateScheduledTransfer]", id)
+	if err != nil {
+		return presentations.ScheduledTransfer{}, err
+	}
+
+	if st.Status != consts.ScheduleStatusActive {
+		slog.Warn("[UpdateScheduledTransfer] failed schedule not active", slog.String("id", id), slog.String("status", st.Status))
+		return presentations.ScheduledTransfer{}, ErrScheduleNotActive
+	}
+
+	update := repository.ScheduledTransferUpdate{
+		ID:             st.ID,
+		Amount:         st.Amount,
+		Description:    st.Description,
+		EndAt:          st.EndAt,
+		MaxOccurrences: st.MaxOccurrences,
+	}
+
+	if req.Amount != "" {
+		update.Amount, err = utils.ParseDecimal(req.Amount)
+		if err != nil {
+			slog.Warn("[UpdateScheduledTransfer] failed validation", slog.Any("err", err))
+			return presentations.ScheduledTransfer{}, wrapError(err)
+		}
+
+		if err := validateAmount(update.Amount); err != nil {
+			slog.Warn("[UpdateScheduledTransfer] failed validation", slog.Any("err", err))
+			return presentations.ScheduledTransfer{}, err
+		}
+
+		if _, err := s.validateAccountAmount(ctx, "[UpdateScheduledTransfer]", st.SourceAccountID, update.Amount); err != nil {
+			return presentations.ScheduledTransfer{}, err
+		}
+	}
+
+	if req.Description != nil {
+		update.Description = *req.Description
+	}
+
+	if (req.EndAt != nil || req.Count != nil) && st.Frequency == consts.ScheduleFrequencyOnce {
+		return presentations.ScheduledTransfer{}, validationError(ErrInvalidSchedule, "end_at and count are not accepted for a once schedule")
+	}
+
+	if req.EndAt != nil {
+		update.EndAt = sql.NullTime{}
+		if *req.EndAt != "" {
+			endAt, err := parseScheduleTime(*req.EndAt)
+			if err != nil || endAt.Before(st.StartAt) {
+				slog.Warn("[UpdateScheduledTransfer] failed validation", slog.String("end_at", *req.EndAt))
+				return presentations.ScheduledTransfer{}, validationError(ErrInvalidSchedule, "end_at must be an RFC 3339 timestamp not before start_at")
+			}
+			update.EndAt = sql.NullTime{Time: endAt, Valid: true}
+		}
+	}
+
+	if req.Count != nil {
+		if *req.Count < 0 {
+			return presentations.ScheduledTransfer{}, validationError(ErrInvalidSchedule, "count must not be negative")
+		}
+		update.MaxOccurrences = sql.NullInt64{Int64: int64(*req.Count), Valid: *req.Count > 0}
+	}
+
+	st, err = s.repo.UpdateScheduledTransfer(ctx, update)
+	if err != nil {
+		slog.Warn("[UpdateScheduledTransfer] failed update scheduled transfer", slog.String("id", id), slog.Any("err", err))
+		return presentations.ScheduledTransfer{}, wrapError(err)
+	}
+
+	slog.Info("[UpdateScheduledTransfer] success", slog.String("id", id), slog.String("status", st.Status))
+	return toScheduledTransfer(st), nil
+}
+
+// CancelScheduledTransfer stops an active schedule. A run already in
+// progress still books its transfer.
+func (s *wallet) CancelScheduledTransfer(ctx context.Context, id string) (presentations.ScheduledTransfer, error) {
+	st, err := s.getScheduledTransfer(ctx, "[CancelScheduledTransfer]", id)
+	if err != nil {
+		return presentations.ScheduledTransfer{}, err
+	}
+
+	st, err = s.repo.CancelScheduledTransfer(ctx, st.ID)
+	if err != nil {
+		slog.Warn("[CancelScheduledTransfer] failed cancel scheduled transfer", slog.String("id", id), slog.Any("err", err))
+		return presentations.ScheduledTransfer{}, wrapError(err)
+	}
+
+	slog.Info("[CancelScheduledTransfer] success", slog.String("id", id))
+	return toScheduledTransfer(st), nil
+}
+
+// RunScheduledTransfers books every due occurrence through SubmitTransaction
+// and reports how many runs it made, failed ones included. Schedules are
+// claimed in batches, so concurrent callers share the work. Each occurrence
+// is booked under its own reference_number: a run repeated after a crash
+// replays the transfer instead of booking it twice.
+func (s *wallet) RunScheduledTransfers(ctx context.Context) (int, error) {
+	var total int
+	for ctx.Err() == nil {
+		now := time.Now()
+		due, err := s.repo.ClaimScheduledTransfers(ctx, now, now.Add(scheduleLease), scheduleClaimBatch)
+		if err != nil {
+			slog.Warn("[RunScheduledTransfers] failed claim scheduled transfers", slog.Int("runs", total), slog.Any("err", err))
+			return total, wrapError(err)
+		}
+
+		for _, st := range due {
+			if err := s.runScheduledTransfer(ctx, st); err != nil {
+				return total, err
+			}
+			total++
+		}
+
+		if len(due) < scheduleClaimBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("[RunScheduledTransfers] success", slog.Int("runs", total))
+	}
+	return total, nil
+}
+
+// runScheduledTransfer books the claimed occurrence of st and records the
+// outcome. A failure that may go away, e.g. insufficient funds, is retried
+// with backoff; once the attempts are used up a recurring schedule skips the
+// occurrence and a once schedule fails. Any other failure, e.g. a closed
+// account, fails the schedule.
+func (s *wallet) runScheduledTransfer(ctx context.Context, st repository.ScheduledTransfer) error {
+	ref := scheduleReference(st.ID, st.Occurrences)
+	run := repository.ScheduledRun{
+		ID:         st.ID,
+		Occurrence: st.Occurrences,
+		Status:     consts.ScheduleStatusActive,
+		RunAt:      time.Now(),
+	}
+
+	// the creator's access is checked again on every run
+	runCtx := scheduleContext(ctx, st)
+	trx, err := s.SubmitTransaction(runCtx, presentations.CreateTransaction{
+		SourceAccountID:      st.SourceAccountID,
+		DestinationAccountID: st.DestinationAccountID,
+		Amount:               st.Amount.String(),
+		ReferenceNumber:      ref,
+	})
+	if errors.Is(err, ErrIdempotencyConflict) {
+		// the occurrence was booked before the amount was changed and its
+		// outcome never recorded
+		existing, lookupErr := s.repo.GetTransactionByReference(runCtx, ref)
+		if lookupErr == nil && existing.ID != "" {
+			trx, err = toTransaction(existing, nil), nil
+		}
+	}
+
+	switch {
+	case err == nil:
+		run.TransactionID = trx.ID
+		advanceSchedule(st, &run)
+	case !retryScheduledRun(err):
+		run.Error = scheduleError(err)
+		run.Attempts = st.Attempts + 1
+		run.NextRunAt = st.NextRunAt
+		run.Status = consts.ScheduleStatusFailed
+	default:
+		run.Error = scheduleError(err)
+		run.Attempts = st.Attempts + 1
+		if run.Attempts < s.scheduleMaxAttempts {
+			run.NextRunAt = run.RunAt.Add(s.scheduleBackoff(run.Attempts))
+			break
+		}
+
+		if st.Frequency == consts.ScheduleFrequencyOnce {
+			run.NextRunAt = st.NextRunAt
+			run.Status = consts.ScheduleStatusFailed
+			break
+		}
+
+		run.Attempts = 0
+		advanceSchedule(st, &run)
+	}
+
+	if err != nil {
+		slog.Warn("[RunScheduledTransfers] failed run", slog.String("id", st.ID), slog.Int("occurrence", st.Occurrences), slog.Int("attempts", run.Attempts), slog.Any("err", err))
+	}
+
+	recorded, err := s.repo.RecordScheduledRun(ctx, run)
+	if err != nil {
+		slog.Warn("[RunScheduledTransfers] failed record run", slog.String("id", st.ID), slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	if !recorded {
+		slog.Info("[RunScheduledTransfers] run already recorded", slog.String("id", st.ID), slog.Int("occurrence", st.Occurrences))
+	}
+	return nil
+}
+
+// scheduleContext returns ctx carrying the principal st runs on behalf of:
+// its creator, or the system for schedules whose creator is unknown.
+func scheduleContext(ctx context.Context, st repository.ScheduledTransfer) context.Context {
+	if st.CreatedByRole == "" {
+		return SystemContext(ctx)
+	}
+
+	return auth.NewContext(ctx, auth.Principal{Subject: st.CreatedBy, Role: st.CreatedByRole})
+}
+
+// advanceSchedule moves run on to the occurrence after the claimed one and
+// completes the schedule when there is none.
+func advanceSchedule(st repository.ScheduledTransfer, run *repository.ScheduledRun) {
+	next := st.Occurrences + 1
+	run.Advance = true
+	run.NextRunAt = occurrenceAt(st.StartAt, st.Frequency, next)
+
+	if st.Frequency == consts.ScheduleFrequencyOnce ||
+		(st.MaxOccurrences.Valid && int64(next) >= st.MaxOccurrences.Int64) ||
+		(st.EndAt.Valid && run.NextRunAt.After(st.EndAt.Time)) {
+		run.Status = consts.ScheduleStatusCompleted
+	}
+}
+
+// occurrenceAt returns when occurrence n, counted from 0, of a schedule
+// starting at start is due. Monthly occurrences keep the day of month of
+// start, or the last day of shorter months.
+func occurrenceAt(start time.Time, frequency string, n int) time.Time {
+	switch frequency {
+	case consts.ScheduleFrequencyDaily:
+		return start.AddDate(0, 0, n)
+	case consts.ScheduleFrequencyWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case consts.ScheduleFrequencyMonthly:
+		y, m, d := start.Date()
+		first := time.Date(y, m+time.Month(n), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		if last := first.AddDate(0, 1, -1).Day(); d > last {
+			d = last
+		}
+		return first.AddDate(0, 0, d-1)
+	}
+
+	return start
+}
+
+// scheduleReference is the reference_number occurrence n of schedule id is
+// booked under.
+func scheduleReference(id string, n int) string {
+	return fmt.Sprintf("schedule:%s:%d", id, n)
+}
+
+// retryScheduledRun reports whether a failed run may succeed later without
+// the schedule being changed.
+func retryScheduledRun(err error) bool {
+	var e *Error
+	if !errors.As(wrapError(err), &e) {
+		return true
+	}
+
+	if errors.Is(e, ErrAccountClosed) {
+		return false
+	}
+
+	return e.Kind != KindValidation && e.Kind != KindNotFound && e.Kind != KindForbidden
+}
+
+// scheduleError is the client-safe description of a failed run.
+func scheduleError(err error) string {
+	var e *Error
+	if !errors.As(wrapError(err), &e) {
+		e = ErrInternal
+	}
+
+	return e.Code + ": " + e.Message
+}
+
+// scheduleBackoff is the wait before retrying a run that failed attempts
+// times.
+func (s *wallet) scheduleBackoff(attempts int) time.Duration {
+	backoff := s.scheduleRetryBackoff
+	for i := 1; i < attempts && backoff < maxScheduleRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxScheduleRetryBackoff {
+		backoff = maxScheduleRetryBackoff
+	}
+	return backoff
+}
+
+// parseSchedule validates the frequency and bounds of req into st.
+func parseSchedule(st *repository.ScheduledTransfer, req presentations.CreateScheduledTransfer, now time.Time) error {
+	switch req.Frequency {
+	case consts.ScheduleFrequencyOnce:
+		if req.EndAt != "" || req.Count != 0 {
+			return validationError(ErrInvalidSchedule, "end_at and count are not accepted for a once schedule")
+		}
+	case consts.ScheduleFrequencyDaily, consts.ScheduleFrequencyWeekly, consts.ScheduleFrequencyMonthly:
+	default:
+		return validationError(ErrInvalidSchedule, "frequency must be once, daily, weekly or monthly")
+	}
+
+	if req.StartAt != "" {
+		startAt, err := parseScheduleTime(req.StartAt)
+		if err != nil || startAt.Before(now) {
+			return validationError(ErrInvalidSchedule, "start_at must be an RFC 3339 timestamp not in the past")
+		}
+		st.StartAt = startAt
+	}
+
+	if req.EndAt != "" {
+		endAt, err := parseScheduleTime(req.EndAt)
+		if err != nil || endAt.Before(st.StartAt) {
+			return validationError(ErrInvalidSchedule, "end_at must be an RFC 3339 timestamp not before start_at")
+		}
+		st.EndAt = sql.NullTime{Time: endAt, Valid: true}
+	}
+
+	if req.Count < 0 {
+		return validationError(ErrInvalidSchedule, "count must not be negative")
+	}
+
+	if req.Count > 0 {
+		st.MaxOccurrences = sql.NullInt64{Int64: int64(req.Count), Valid: true}
+	}
+
+	return nil
+}
+
+// parseScheduleTime parses an RFC 3339 timestamp into the server's zone,
+// which the timestamp columns are written in.
+func parseScheduleTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return t.Local(), nil
+}
+
+// validateScheduleAccounts checks that both accounts exist and that amount
+// fits the source currency. Balances are checked when the transfer runs.
+func (s *wallet) validateScheduleAccounts(ctx context.Context, logPrefix string, sourceID, destinationID int, amount utils.Decimal) error {
+	from, err := s.validateAccountAmount(ctx, logPrefix, sourceID, amount)
+	if err != nil {
+		return err
+	}
+
+	to, err := s.repo.GetAccount(ctx, destinationID)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetAccount receiver", slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	if to.ID == 0 {
+		slog.Warn(logPrefix+" failed data not found", slog.Any("accountID", destinationID))
+		return ErrAccountNotFound
+	}
+
+	if from.Currency != to.Currency && s.fxRates == nil {
+		slog.Warn(logPrefix+" failed currency mismatch", slog.String("from", from.Currency), slog.String("to", to.Currency))
+		return ErrCurrencyMismatch
+	}
+
+	return nil
+}
+
+func (s *wallet) getScheduledTransfer(ctx context.Context, logPrefix, id string) (repository.ScheduledTransfer, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.String("id", id))
+		return repository.ScheduledTransfer{}, ErrInvalidScheduleID
+	}
+
+	st, err := s.repo.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetScheduledTransfer", slog.Any("err", err))
+		return repository.ScheduledTransfer{}, wrapError(err)
+	}
+
+	if st.ID == "" {
+		slog.Warn(logPrefix+" failed data not found", slog.String("id", id))
+		return repository.ScheduledTransfer{}, ErrScheduleNotFound
+	}
+
+	if err := s.authorizeAccountID(ctx, logPrefix, st.SourceAccountID); err != nil {
+		return repository.ScheduledTransfer{}, err
+	}
+
+	return st, nil
+}
+
+func toScheduledTransfer(st repository.ScheduledTransfer) presentations.ScheduledTransfer {
+	result := presentations.ScheduledTransfer{
+		ID:                   st.ID,
+		SourceAccountID:      st.SourceAccountID,
+		DestinationAccountID: st.DestinationAccountID,
+		Amount:               st.Amount,
+		Description:          st.Description,
+		Frequency:            st.Frequency,
+		StartAt:              st.StartAt,
+		Count:                int(st.MaxOccurrences.Int64),
+		Occurrences:          st.Occurrences,
+		Status:               st.Status,
+		Attempts:             st.Attempts,
+		LastError:            st.LastError,
+		LastTransactionID:    st.LastTransactionID.String,
+		CreatedAt:            st.CreatedAt,
+	}
+
+	if st.EndAt.Valid {
+		result.EndAt = &st.EndAt.Time
+	}
+
+	if st.Status == consts.ScheduleStatusActive {
+		result.NextRunAt = &st.NextRunAt
+	}
+
+	if st.LastRunAt.Valid {
+		result.LastRunAt = &st.LastRunAt.Time
+	}
+
+	return result
+}
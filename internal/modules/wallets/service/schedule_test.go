@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOccurrenceAt(t *testing.T) {
+	start := time.Date(2025, time.January, 31, 9, 30, 0, 0, time.UTC)
+
+	testTables := []struct {
+		name      string
+		frequency string
+		n         int
+		want      time.Time
+	}{
+		{name: "once", frequency: consts.ScheduleFrequencyOnce, n: 0, want: start},
+		{name: "daily", frequency: consts.ScheduleFrequencyDaily, n: 3, want: time.Date(2025, time.February, 3, 9, 30, 0, 0, time.UTC)},
+		{name: "weekly", frequency: consts.ScheduleFrequencyWeekly, n: 2, want: time.Date(2025, time.February, 14, 9, 30, 0, 0, time.UTC)},
+		{name: "monthly clamped to month end", frequency: consts.ScheduleFrequencyMonthly, n: 1, want: time.Date(2025, time.February, 28, 9, 30, 0, 0, time.UTC)},
+		{name: "monthly keeps day of start", frequency: consts.ScheduleFrequencyMonthly, n: 2, want: time.Date(2025, time.March, 31, 9, 30, 0, 0, time.UTC)},
+		{name: "monthly across years", frequency: consts.ScheduleFrequencyMonthly, n: 13, want: time.Date(2026, time.February, 28, 9, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, occurrenceAt(start, tt.frequency, tt.n))
+		})
+	}
+}
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	from := repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency}
+	to := repository.Account{ID: 2, AccountID: 2, Currency: consts.DefaultCurrency}
+	start := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+
+	testTables := []struct {
+		name string
+		mock func()
+		err  error
+		req  presentations.CreateScheduledTransfer
+	}{
+		{
+			name: "FAILED validation same account",
+			err:  ErrSameAccount,
+			req:  presentations.CreateScheduledTransfer{SourceAccountID: 2, DestinationAccountID: 2, Amount: "10", Frequency: consts.ScheduleFrequencyDaily},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation frequency",
+			err:  ErrInvalidSchedule,
+			req:  presentations.CreateScheduledTransfer{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10", Frequency: "hourly"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation start in the past",
+			err:  ErrInvalidSchedule,
+			req:  presentations.CreateScheduledTransfer{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10", Frequency: consts.ScheduleFrequencyDaily, StartAt: "2001-01-01T00:00:00Z"},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation end before start",
+			err:  ErrInvalidSchedule,
+			req:  presentations.CreateScheduledTransfer{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10", Frequency: consts.ScheduleFrequencyDaily, StartAt: start, EndAt: time.Now().UTC().Format(time.RFC3339)},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation count on once",
+			err:  ErrInvalidSchedule,
+			req:  presentations.CreateScheduledTransfer{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10", Frequency: consts.ScheduleFrequencyOnce, Count: 2},
+			mock: func() {},
+		},
+		{
+			name: "FAILED destination not found",
+			err:  ErrAccountNotFound,
+			req:  presentations.CreateScheduledTransfer{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10", Frequency: consts.ScheduleFrequencyOnce},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(from, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
+			},
+		},
+		{
+			name: "FAILED currency mismatch",
+			err:  ErrCurrencyMismatch,
+			req:  presentations.CreateScheduledTransfer{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10", Frequency: consts.ScheduleFrequencyOnce},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(from, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{ID: 2, AccountID: 2, Currency: "EUR"}, nil)
+			},
+		},
+		{
+			name: "SUCCESS",
+			req:  presentations.CreateScheduledTransfer{SourceAccountID: 3, DestinationAccountID: 2, Amount: "10.25", Frequency: consts.ScheduleFrequencyMonthly, StartAt: start, Count: 12},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(from, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(to, nil)
+				mRepo.EXPECT().CreateScheduledTransfer(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, st repository.ScheduledTransfer) error {
+					assert.Equal(t, consts.ScheduleStatusActive, st.Status)
+					assert.Equal(t, "10.25", st.Amount.String())
+					assert.Equal(t, st.StartAt, st.NextRunAt)
+					assert.Equal(t, start, st.StartAt.UTC().Format(time.RFC3339))
+					assert.Equal(t, sql.NullInt64{Int64: 12, Valid: true}, st.MaxOccurrences)
+					assert.Equal(t, "support", st.CreatedBy)
+					assert.Equal(t, consts.RoleOperator, st.CreatedByRole)
+					return nil
+				})
+			},
+		},
+	}
+
+	svc := NewWalletService(mRepo)
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			resp, err := svc.CreateScheduledTransfer(ctx, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.NotEmpty(t, resp.ID)
+				assert.Equal(t, 12, resp.Count)
+				assert.NotNil(t, resp.NextRunAt)
+			}
+		})
+	}
+}
+
+func TestUpdateScheduledTransfer(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+
+	const id = "5b0c3f2e-9a51-4d0e-b7a4-1f3e7c2d9a10"
+	st := repository.ScheduledTransfer{
+		ID:                   id,
+		SourceAccountID:      3,
+		DestinationAccountID: 2,
+		Amount:               utils.NewDecimal(10),
+		Frequency:            consts.ScheduleFrequencyWeekly,
+		StartAt:              time.Now(),
+		EndAt:                sql.NullTime{Time: time.Now().Add(30 * 24 * time.Hour), Valid: true},
+		Status:               consts.ScheduleStatusActive,
+	}
+
+	s := NewWalletService(mRepo)
+	_, err := s.UpdateScheduledTransfer(ctx, "nope", presentations.UpdateScheduledTransfer{})
+	assert.ErrorIs(t, err, ErrInvalidScheduleID)
+
+	cancelled := st
+	cancelled.Status = consts.ScheduleStatusCancelled
+	mRepo.EXPECT().GetScheduledTransfer(ctx, id).Return(cancelled, nil)
+	_, err = s.UpdateScheduledTransfer(ctx, id, presentations.UpdateScheduledTransfer{Amount: "5"})
+	assert.ErrorIs(t, err, ErrScheduleNotActive)
+
+	empty, count := "", 4
+	mRepo.EXPECT().GetScheduledTransfer(ctx, id).Return(st, nil)
+	mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency}, nil)
+	mRepo.EXPECT().UpdateScheduledTransfer(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, u repository.ScheduledTransferUpdate) (repository.ScheduledTransfer, error) {
+		assert.Equal(t, "12.5", u.Amount.String())
+		assert.False(t, u.EndAt.Valid)
+		assert.Equal(t, sql.NullInt64{Int64: 4, Valid: true}, u.MaxOccurrences)
+
+		updated := st
+		updated.Amount, updated.EndAt, updated.MaxOccurrences = u.Amount, u.EndAt, u.MaxOccurrences
+		return updated, nil
+	})
+
+	resp, err := s.UpdateScheduledTransfer(ctx, id, presentations.UpdateScheduledTransfer{Amount: "12.5", EndAt: &empty, Count: &count})
+	require.NoError(t, err)
+	assert.Equal(t, "12.5", resp.Amount.String())
+	assert.Nil(t, resp.EndAt)
+	assert.Equal(t, 4, resp.Count)
+}
+
+func TestRunScheduledTransfers(t *testing.T) {
+	ctx := SystemContext(context.TODO())
+	start := time.Date(2025, time.January, 31, 9, 0, 0, 0, time.UTC)
+	from := repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency, Balance: utils.NewDecimal(100)}
+	to := repository.Account{ID: 2, AccountID: 2, Currency: consts.DefaultCurrency}
+
+	schedule := func(frequency string, occurrences, attempts int) repository.ScheduledTransfer {
+		return repository.ScheduledTransfer{
+			ID:                   "5b0c3f2e-9a51-4d0e-b7a4-1f3e7c2d9a10",
+			SourceAccountID:      3,
+			DestinationAccountID: 2,
+			Amount:               utils.NewDecimal(10),
+			Frequency:            frequency,
+			StartAt:              start,
+			MaxOccurrences:       sql.NullInt64{Int64: 3, Valid: true},
+			Occurrences:          occurrences,
+			NextRunAt:            occurrenceAt(start, frequency, occurrences),
+			Status:               consts.ScheduleStatusActive,
+			Attempts:             attempts,
+		}
+	}
+
+	testTables := []struct {
+		name     string
+		schedule repository.ScheduledTransfer
+		balance  utils.Decimal
+		check    func(t *testing.T, run repository.ScheduledRun)
+	}{
+		{
+			name:     "SUCCESS advances to next occurrence",
+			schedule: schedule(consts.ScheduleFrequencyMonthly, 0, 2),
+			balance:  utils.NewDecimal(100),
+			check: func(t *testing.T, run repository.ScheduledRun) {
+				assert.True(t, run.Advance)
+				assert.NotEmpty(t, run.TransactionID)
+				assert.Empty(t, run.Error)
+				assert.Equal(t, 0, run.Attempts)
+				assert.Equal(t, consts.ScheduleStatusActive, run.Status)
+				assert.Equal(t, time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC), run.NextRunAt)
+			},
+		},
+		{
+			name:     "SUCCESS last occurrence completes",
+			schedule: schedule(consts.ScheduleFrequencyDaily, 2, 0),
+			balance:  utils.NewDecimal(100),
+			check: func(t *testing.T, run repository.ScheduledRun) {
+				assert.True(t, run.Advance)
+				assert.Equal(t, consts.ScheduleStatusCompleted, run.Status)
+			},
+		},
+		{
+			name:     "FAILED insufficient funds retried with backoff",
+			schedule: schedule(consts.ScheduleFrequencyDaily, 0, 1),
+			balance:  utils.NewDecimal(5),
+			check: func(t *testing.T, run repository.ScheduledRun) {
+				assert.False(t, run.Advance)
+				assert.Equal(t, 2, run.Attempts)
+				assert.Equal(t, "INSUFFICIENT_FUNDS: sender balance less than amount", run.Error)
+				assert.Equal(t, consts.ScheduleStatusActive, run.Status)
+				assert.Equal(t, run.RunAt.Add(2*time.Minute), run.NextRunAt)
+			},
+		},
+		{
+			name:     "FAILED attempts used up skips recurring occurrence",
+			schedule: schedule(consts.ScheduleFrequencyWeekly, 0, 2),
+			balance:  utils.NewDecimal(5),
+			check: func(t *testing.T, run repository.ScheduledRun) {
+				assert.True(t, run.Advance)
+				assert.Equal(t, 0, run.Attempts)
+				assert.NotEmpty(t, run.Error)
+				assert.Equal(t, consts.ScheduleStatusActive, run.Status)
+				assert.Equal(t, start.AddDate(0, 0, 7), run.NextRunAt)
+			},
+		},
+		{
+			name:     "FAILED attempts used up fails once schedule",
+			schedule: schedule(consts.ScheduleFrequencyOnce, 0, 2),
+			balance:  utils.NewDecimal(5),
+			check: func(t *testing.T, run repository.ScheduledRun) {
+				assert.False(t, run.Advance)
+				assert.Equal(t, 3, run.Attempts)
+				assert.Equal(t, consts.ScheduleStatusFailed, run.Status)
+			},
+		},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+			payer := from
+			payer.Balance = tt.balance
+			ref := scheduleReference(tt.schedule.ID, tt.schedule.Occurrences)
+
+			mRepo.EXPECT().ClaimScheduledTransfers(ctx, gomock.Any(), gomock.Any(), scheduleClaimBatch).Return([]repository.ScheduledTransfer{tt.schedule}, nil)
+			mRepo.EXPECT().GetTransactionByReference(gomock.Any(), ref).Return(repository.Transaction{}, nil)
+			mRepo.EXPECT().GetAccount(gomock.Any(), 3).Return(payer, nil)
+			mRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(to, nil)
+			mRepo.EXPECT().SubmitTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p repository.TransactionPayload) error {
+				assert.Equal(t, ref, p.Transaction.ReferenceNumber)
+				return nil
+			}).MaxTimes(1)
+			mRepo.EXPECT().RecordScheduledRun(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, run repository.ScheduledRun) (bool, error) {
+				assert.Equal(t, tt.schedule.ID, run.ID)
+				assert.Equal(t, tt.schedule.Occurrences, run.Occurrence)
+				tt.check(t, run)
+				return true, nil
+			})
+
+			s := NewWalletService(mRepo, WithScheduleRetry(3, time.Minute))
+			n, err := s.RunScheduledTransfers(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+		})
+	}
+}
+
+func TestRunScheduledTransfersClosedAccount(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := SystemContext(context.TODO())
+	st := repository.ScheduledTransfer{
+		ID:                   "5b0c3f2e-9a51-4d0e-b7a4-1f3e7c2d9a10",
+		SourceAccountID:      3,
+		DestinationAccountID: 2,
+		Amount:               utils.NewDecimal(10),
+		Frequency:            consts.ScheduleFrequencyDaily,
+		StartAt:              time.Now(),
+		NextRunAt:            time.Now(),
+		Status:               consts.ScheduleStatusActive,
+	}
+
+	mRepo.EXPECT().ClaimScheduledTransfers(ctx, gomock.Any(), gomock.Any(), scheduleClaimBatch).Return([]repository.ScheduledTransfer{st}, nil)
+	mRepo.EXPECT().GetTransactionByReference(gomock.Any(), gomock.Any()).Return(repository.Transaction{}, nil)
+	mRepo.EXPECT().GetAccount(gomock.Any(), 3).Return(repository.Account{ID: 1, AccountID: 3, Currency: consts.DefaultCurrency, Balance: utils.NewDecimal(100)}, nil)
+	mRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(repository.Account{ID: 2, AccountID: 2, Currency: consts.DefaultCurrency}, nil)
+	mRepo.EXPECT().SubmitTransaction(gomock.Any(), gomock.Any()).Return(repository.ErrAccountClosed)
+	mRepo.EXPECT().RecordScheduledRun(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, run repository.ScheduledRun) (bool, error) {
+		assert.False(t, run.Advance)
+		assert.Equal(t, consts.ScheduleStatusFailed, run.Status)
+		assert.Equal(t, "ACCOUNT_CLOSED: account is closed", run.Error)
+		return true, nil
+	})
+
+	n, err := NewWalletService(mRepo).RunScheduledTransfers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestRunScheduledTransfersCreatorLostAccess(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := SystemContext(context.TODO())
+	st := repository.ScheduledTransfer{
+		ID:                   "5b0c3f2e-9a51-4d0e-b7a4-1f3e7c2d9a10",
+		SourceAccountID:      3,
+		DestinationAccountID: 2,
+		Amount:               utils.NewDecimal(10),
+		Frequency:            consts.ScheduleFrequencyDaily,
+		StartAt:              time.Now(),
+		NextRunAt:            time.Now(),
+		Status:               consts.ScheduleStatusActive,
+		CreatedBy:            testCustomerID,
+		CreatedByRole:        consts.RoleCustomer,
+	}
+
+	// the source account was handed to another customer after scheduling
+	mRepo.EXPECT().ClaimScheduledTransfers(ctx, gomock.Any(), gomock.Any(), scheduleClaimBatch).Return([]repository.ScheduledTransfer{st}, nil)
+	mRepo.EXPECT().GetAccount(gomock.Any(), 3).DoAndReturn(func(ctx context.Context, _ int) (repository.Account, error) {
+		p, _ := auth.FromContext(ctx)
+		assert.Equal(t, auth.Principal{Subject: testCustomerID, Role: consts.RoleCustomer}, p)
+		return repository.Account{ID: 1, AccountID: 3, CustomerID: otherCustomerID, Currency: consts.DefaultCurrency}, nil
+	})
+	mRepo.EXPECT().RecordScheduledRun(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, run repository.ScheduledRun) (bool, error) {
+		assert.False(t, run.Advance)
+		assert.Equal(t, consts.ScheduleStatusFailed, run.Status)
+		assert.Contains(t, run.Error, ErrAccountForbidden.Code)
+		return true, nil
+	})
+
+	n, err := NewWalletService(mRepo).RunScheduledTransfers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -1,52 +1,108 @@
 package service
 
 import (
-	"errors"
-	"strconv"
+	"fmt"
 
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
 )
 
-func validateAccounts(to, from repository.Account, amount float64) error {
-	if from.Balance < amount {
-		return errors.New("sender balance less than amount")
+// validateAccounts checks that from can pay amount plus fee.
+func validateAccounts(to, from repository.Account, amount, fee utils.Decimal) error {
+	debited, err := amount.CheckedAdd(fee)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if from.Balance.Sub(from.HeldAmount).Cmp(debited) < 0 {
+		return ErrInsufficientFunds
 	}
 
-	if amount < 1 {
-		return errors.New("amount cannot be less than 1.00")
+	return validateAmount(amount)
+}
+
+// maxAmount bounds a single amount. A full batch of such amounts still sums
+// to less than utils.MaxDecimal, the largest value Decimal can hold.
+var maxAmount = utils.NewDecimal(1_000_000_000)
+
+// validateAmount requires amount to be positive and at most maxAmount. The
+// configured limits are applied by validateIncomingAmount and, for outgoing
+// movements, by the repository where the per-account overrides and usage are
+// known.
+func validateAmount(amount utils.Decimal) error {
+	if !amount.IsPositive() {
+		return validationError(ErrAmountTooSmall, "amount must be greater than 0")
 	}
 
-	if amount > 1000000 {
-		return errors.New("amount cannot be greater than than 1000000.00")
+	if amount.Cmp(maxAmount) > 0 {
+		return validationError(ErrInvalidAmount, "amount must be at most "+maxAmount.String())
 	}
 
 	return nil
 }
 
+// validateIncomingAmount checks amount credited to an account against the
+// configured per-transaction limits.
+func (s *wallet) validateIncomingAmount(amount utils.Decimal) error {
+	if err := validateAmount(amount); err != nil {
+		return err
+	}
+
+	return wrapError(s.limits.CheckAmount(amount))
+}
+
 func validateCreateAccount(p presentations.CreateAccount) error {
 	if err := validateAccountID(p.AccountID); err != nil {
 		return err
 	}
 
-	amount, err := strconv.ParseFloat(p.InitialBalance, 64)
-	if err != nil {
+	if err := validateReferenceNumber(p.ReferenceNumber); err != nil {
 		return err
 	}
-	if amount < 1 {
-		return errors.New("amount cannot be less than 1.00")
+
+	amount, err := utils.ParseDecimal(p.InitialBalance)
+	if err != nil {
+		return err
 	}
 
-	if amount > 1000000 {
-		return errors.New("amount cannot be greater than than 1000000.00")
+	// an account may open empty
+	if amount.IsZero() {
+		return nil
 	}
 
-	return nil
+	return validateAmount(amount)
 }
 
 func validateAccountID(id int) error {
 	if id < 1 {
-		return errors.New("invalid account_id format")
+		return ErrInvalidAccountID
+	}
+
+	return nil
+}
+
+func validateTransactionID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidTransactionID
+	}
+
+	return nil
+}
+
+// maxBatchTransfers bounds the size of a transfer batch.
+const maxBatchTransfers = 5000
+
+func validateBatch(p presentations.CreateTransactionBatch) error {
+	if p.Mode != consts.BatchModeAtomic && p.Mode != consts.BatchModeBestEffort {
+		return validationError(ErrInvalidBatch, "mode must be atomic or best_effort")
+	}
+
+	if len(p.Transfers) == 0 || len(p.Transfers) > maxBatchTransfers {
+		return validationError(ErrInvalidBatch, fmt.Sprintf("a batch needs between 1 and %d transfers", maxBatchTransfers))
 	}
 
 	return nil
@@ -6,7 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"strconv"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,38 +14,193 @@ import (
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
 )
 
 type wallet struct {
 	repo repository.WalletRepository
+
+	// allowReversalOverdraft lets a reversal push the original destination
+	// below zero instead of refusing it.
+	allowReversalOverdraft bool
+
+	// holdTTL is how long a hold stays active when the request does not
+	// say otherwise.
+	holdTTL time.Duration
+
+	// currencies maps every supported ISO 4217 code to its minor-unit scale.
+	currencies map[string]int
+
+	// fxRates prices cross-currency transfers. Without it transfers between
+	// accounts of different currencies are refused.
+	fxRates FXRateProvider
+
+	// limits are the configured defaults. Deposits and opening balances are
+	// checked against the per-transaction rules here, outgoing movements are
+	// checked by the repository.
+	limits repository.Limits
+
+	// fees prices outgoing movements by transaction type. Without a rule a
+	// movement is free.
+	fees FeeSchedule
+
+	// scheduleMaxAttempts is how many runs of one scheduled occurrence may
+	// fail before it is given up; scheduleRetryBackoff is the wait before
+	// the first retry and doubles with every further one.
+	scheduleMaxAttempts  int
+	scheduleRetryBackoff time.Duration
+
+	// webhookClient sends webhook deliveries. A delivery is given up after
+	// webhookMaxAttempts failed attempts; webhookRetryBackoff is the wait
+	// before the first retry and doubles with every further one.
+	webhookClient       *http.Client
+	webhookMaxAttempts  int
+	webhookRetryBackoff time.Duration
+}
+
+// Option customises the wallet service.
+type Option func(*wallet)
+
+// WithReversalOverdraft makes reversals go through even when the original
+// destination no longer holds the amount, leaving it with a negative balance.
+func WithReversalOverdraft(allow bool) Option {
+	return func(s *wallet) {
+		s.allowReversalOverdraft = allow
+	}
+}
+
+// WithHoldTTL sets the lifetime of holds created without expires_at.
+// Non-positive values keep the default.
+func WithHoldTTL(ttl time.Duration) Option {
+	return func(s *wallet) {
+		if ttl > 0 {
+			s.holdTTL = ttl
+		}
+	}
+}
+
+// WithCurrencies replaces the currency table with the given ISO 4217 codes
+// and their minor-unit scales, e.g. {"USD": 2, "JPY": 0}. An empty table
+// keeps the default.
+func WithCurrencies(currencies map[string]int) Option {
+	return func(s *wallet) {
+		if len(currencies) == 0 {
+			return
+		}
+
+		s.currencies = make(map[string]int, len(currencies))
+		for code, scale := range currencies {
+			s.currencies[normalizeCurrency(code)] = scale
+		}
+	}
+}
+
+// WithFXRateProvider enables cross-currency transfers priced by p.
+func WithFXRateProvider(p FXRateProvider) Option {
+	return func(s *wallet) {
+		s.fxRates = p
+	}
+}
+
+// WithLimits sets the default transfer limits, see repository.WithLimits.
+func WithLimits(limits repository.Limits) Option {
+	return func(s *wallet) {
+		s.limits = limits
+	}
+}
+
+// WithFees sets the fee schedule.
+func WithFees(fees FeeSchedule) Option {
+	return func(s *wallet) {
+		s.fees = fees
+	}
+}
+
+// WithScheduleRetry sets how often and how far apart failed runs of a
+// scheduled transfer are retried. Non-positive values keep the defaults.
+func WithScheduleRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(s *wallet) {
+		if maxAttempts > 0 {
+			s.scheduleMaxAttempts = maxAttempts
+		}
+		if backoff > 0 {
+			s.scheduleRetryBackoff = backoff
+		}
+	}
+}
+
+// WithWebhookRetry sets how often and how far apart failed webhook
+// deliveries are retried. Non-positive values keep the defaults.
+func WithWebhookRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(s *wallet) {
+		if maxAttempts > 0 {
+			s.webhookMaxAttempts = maxAttempts
+		}
+		if backoff > 0 {
+			s.webhookRetryBackoff = backoff
+		}
+	}
 }
 
-func NewWalletService(repo repository.WalletRepository) Wallet {
-	return &wallet{
-		repo: repo,
+// WithWebhookClient sets the HTTP client webhook deliveries are sent with.
+func WithWebhookClient(client *http.Client) Option {
+	return func(s *wallet) {
+		if client != nil {
+			s.webhookClient = client
+		}
 	}
 }
 
+func NewWalletService(repo repository.WalletRepository, opts ...Option) Wallet {
+	s := &wallet{
+		repo:       repo,
+		holdTTL:    defaultHoldTTL,
+		currencies: defaultCurrencies,
+		limits:     repository.DefaultLimits,
+
+		scheduleMaxAttempts:  defaultScheduleMaxAttempts,
+		scheduleRetryBackoff: defaultScheduleRetryBackoff,
+
+		webhookClient:       &http.Client{Timeout: defaultWebhookTimeout},
+		webhookMaxAttempts:  defaultWebhookMaxAttempts,
+		webhookRetryBackoff: defaultWebhookRetryBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
 func (s *wallet) GetAccount(ctx context.Context, accountID int) (presentations.Account, error) {
 	if err := validateAccountID(accountID); err != nil {
 		slog.Warn("[GetAccount] failed validation", slog.Any("err", err))
-		return presentations.Account{}, err
+		return presentations.Account{}, wrapError(err)
 	}
 
 	result, err := s.repo.GetAccount(ctx, accountID)
 	if err != nil {
 		slog.Warn("[GetAccount] failed GetAccount", slog.Any("err", err))
-		return presentations.Account{}, err
+		return presentations.Account{}, wrapError(err)
 	}
 
 	if result.ID == 0 {
 		slog.Warn("[GetAccount] failed data not found", slog.Any("accountID", accountID))
-		return presentations.Account{}, errors.New("data not found")
+		return presentations.Account{}, ErrAccountNotFound
+	}
+
+	if err := authorizeAccount(ctx, "[GetAccount]", result); err != nil {
+		return presentations.Account{}, err
 	}
 
 	resp := presentations.Account{
-		AccountID: result.AccountID,
-		Balance:   fmt.Sprintf("%v", result.Balance),
+		AccountID:        result.AccountID,
+		CustomerID:       result.CustomerID,
+		Currency:         result.Currency,
+		Status:           result.Status,
+		Balance:          result.Balance,
+		AvailableBalance: result.Balance.Sub(result.HeldAmount),
 	}
 
 	slog.Info("[GetAccount] success", slog.Any("accountID", accountID))
@@ -55,97 +210,384 @@ func (s *wallet) GetAccount(ctx context.Context, accountID int) (presentations.A
 func (s *wallet) CreateAccount(ctx context.Context, req presentations.CreateAccount) error {
 	if err := validateCreateAccount(req); err != nil {
 		slog.Warn("[CreateAccount] failed validation", slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	currency := normalizeCurrency(req.Currency)
+	reqAmount, _ := utils.ParseDecimal(req.InitialBalance)
+	if !reqAmount.IsZero() {
+		if err := s.validateIncomingAmount(reqAmount); err != nil {
+			slog.Warn("[CreateAccount] failed validation", slog.Any("err", err))
+			return err
+		}
+	}
+
+	if err := s.validateCurrencyAmount(currency, reqAmount); err != nil {
+		slog.Warn("[CreateAccount] failed validation", slog.String("currency", currency), slog.Any("err", err))
+		return err
+	}
+
+	customerID, err := s.accountOwner(ctx, "[CreateAccount]", req.CustomerID, reqAmount)
+	if err != nil {
 		return err
 	}
 
+	fingerprint := requestFingerprint("create_account", req.AccountID, currency, reqAmount)
+	if req.ReferenceNumber != "" {
+		existing, err := s.checkReplay(ctx, req.ReferenceNumber, fingerprint)
+		if err != nil {
+			slog.Warn("[CreateAccount] failed idempotency check", slog.Any("req", req), slog.Any("err", err))
+			return wrapError(err)
+		}
+
+		if existing.ID != "" {
+			slog.Info("[CreateAccount] replayed", slog.Any("req", req))
+			return nil
+		}
+	}
+
 	exist, err := s.repo.GetAccount(ctx, req.AccountID)
 	if err != nil {
 		slog.Warn("[GetAccount] failed GetAccount", slog.Any("err", err))
-		return err
+		return wrapError(err)
 	}
 
 	if exist.ID > 0 {
 		slog.Warn("[CreateAccount] data already exists", slog.Any("req", req))
-		return errors.New("data already exists")
+		return ErrAccountExists
 	}
 
-	reqAmount, _ := strconv.ParseFloat(req.InitialBalance, 64)
-	err = s.repo.CreateAccount(ctx, prepareDepositPayload(repository.Account{
-		AccountID: req.AccountID,
-		Balance:   reqAmount,
-	}))
+	payload := prepareDepositPayload(repository.Account{
+		AccountID:  req.AccountID,
+		Currency:   currency,
+		Balance:    reqAmount,
+		CustomerID: customerID,
+	})
+	applyReference(&payload.Transaction, req.ReferenceNumber, fingerprint)
+
+	err = s.repo.CreateAccount(ctx, payload)
+	if errors.Is(err, repository.ErrDuplicateReference) {
+		_, err = s.resolveDuplicate(ctx, req.ReferenceNumber, fingerprint)
+	}
 	if err != nil {
 		slog.Warn("[CreateAccount] failed create Account", slog.Any("req", req))
-		return err
+		return wrapError(err)
 	}
 
 	slog.Info("[CreateAccount] success", slog.Any("req", req))
 	return nil
 }
 
-func (s *wallet) SubmitTransaction(ctx context.Context, req presentations.CreateTransaction) error {
+func (s *wallet) SubmitTransaction(ctx context.Context, req presentations.CreateTransaction) (presentations.Transaction, error) {
+	payloadReq, replayed, err := s.prepareTransfer(ctx, "[SubmitTransaction]", req)
+	if err != nil {
+		return presentations.Transaction{}, err
+	}
+
+	if replayed.ID != "" {
+		slog.Info("[SubmitTransaction] replayed", slog.Any("req", req))
+		return toTransaction(replayed, nil), nil
+	}
+
+	err = s.repo.SubmitTransaction(ctx, payloadReq)
+	if errors.Is(err, repository.ErrDuplicateReference) {
+		existing, err := s.resolveDuplicate(ctx, req.ReferenceNumber, payloadReq.Transaction.RequestFingerprint)
+		if err != nil {
+			slog.Warn("[SubmitTransaction] failed submit transaction", slog.Any("req", req), slog.Any("err", err))
+			return presentations.Transaction{}, wrapError(err)
+		}
+
+		slog.Info("[SubmitTransaction] replayed", slog.Any("req", req))
+		return toTransaction(existing, nil), nil
+	}
+	if err != nil {
+		slog.Warn("[SubmitTransaction] failed submit transaction", slog.Any("req", req))
+		return presentations.Transaction{}, wrapError(err)
+	}
+	slog.Info("[SubmitTransaction] success", slog.Any("req", req))
+	return toTransaction(payloadReq.Transaction, nil), nil
+}
+
+// prepareTransfer validates req and builds the payload booking it. When req
+// repeats an earlier request under the same reference_number, the
+// transaction stored for it is returned instead of a payload.
+func (s *wallet) prepareTransfer(ctx context.Context, logPrefix string, req presentations.CreateTransaction) (repository.TransactionPayload, repository.Transaction, error) {
 	if err := validateAccountID(req.SourceAccountID); err != nil {
-		return err
+		return repository.TransactionPayload{}, repository.Transaction{}, wrapError(err)
 	}
 
 	if err := validateAccountID(req.DestinationAccountID); err != nil {
-		return err
+		return repository.TransactionPayload{}, repository.Transaction{}, wrapError(err)
 	}
 
 	if req.DestinationAccountID == req.SourceAccountID {
-		return errors.New("request payload invalid")
+		return repository.TransactionPayload{}, repository.Transaction{}, ErrSameAccount
+	}
+
+	// before the replay check, so a customer cannot read another's
+	// transfer by repeating its reference_number
+	if err := s.authorizeAccountID(ctx, logPrefix, req.SourceAccountID); err != nil {
+		return repository.TransactionPayload{}, repository.Transaction{}, err
+	}
+
+	if err := validateReferenceNumber(req.ReferenceNumber); err != nil {
+		return repository.TransactionPayload{}, repository.Transaction{}, wrapError(err)
 	}
 
-	reqAmount, err := strconv.ParseFloat(req.Amount, 64)
+	reqAmount, err := utils.ParseDecimal(req.Amount)
 	if err != nil {
-		return err
+		return repository.TransactionPayload{}, repository.Transaction{}, wrapError(err)
+	}
+
+	fingerprint := requestFingerprint(consts.TransactionTypeTransfer, req.SourceAccountID, req.DestinationAccountID, reqAmount)
+	if req.ReferenceNumber != "" {
+		existing, err := s.checkReplay(ctx, req.ReferenceNumber, fingerprint)
+		if err != nil {
+			slog.Warn(logPrefix+" failed idempotency check", slog.Any("req", req), slog.Any("err", err))
+			return repository.TransactionPayload{}, repository.Transaction{}, wrapError(err)
+		}
+
+		if existing.ID != "" {
+			return repository.TransactionPayload{}, existing, nil
+		}
 	}
 
 	dataFrom, err := s.repo.GetAccount(ctx, req.SourceAccountID)
 	if err != nil {
-		slog.Warn("[SubmitTransaction] failed GetAccount sender", slog.Any("err", err))
-		return err
+		slog.Warn(logPrefix+" failed GetAccount sender", slog.Any("err", err))
+		return repository.TransactionPayload{}, repository.Transaction{}, wrapError(err)
 	}
 
 	dataTo, err := s.repo.GetAccount(ctx, req.DestinationAccountID)
 	if err != nil {
-		slog.Warn("[SubmitTransaction] failed GetAccount receiver", slog.Any("err", err))
-		return err
+		slog.Warn(logPrefix+" failed GetAccount receiver", slog.Any("err", err))
+		return repository.TransactionPayload{}, repository.Transaction{}, wrapError(err)
 	}
 
 	if dataFrom.ID == 0 || dataTo.ID == 0 {
-		slog.Warn("[SubmitTransaction] failed data not found", slog.Any("req", req))
-		return errors.New("data not found")
+		slog.Warn(logPrefix+" failed data not found", slog.Any("req", req))
+		return repository.TransactionPayload{}, repository.Transaction{}, ErrAccountNotFound
 	}
 
-	if err := validateAccounts(dataTo, dataFrom, reqAmount); err != nil {
-		slog.Warn("[SubmitTransaction] failed validation", slog.Any("err", err))
-		return err
+	crossCurrency := dataFrom.Currency != dataTo.Currency
+	if crossCurrency && s.fxRates == nil {
+		slog.Warn(logPrefix+" failed currency mismatch", slog.String("from", dataFrom.Currency), slog.String("to", dataTo.Currency))
+		return repository.TransactionPayload{}, repository.Transaction{}, ErrCurrencyMismatch
 	}
 
-	payloadReq := prepareTrxPayload(dataFrom, dataTo, reqAmount)
+	if err := s.validateCurrencyAmount(dataFrom.Currency, reqAmount); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.Any("err", err))
+		return repository.TransactionPayload{}, repository.Transaction{}, err
+	}
 
-	err = s.repo.SubmitTransaction(ctx, payloadReq)
+	fee, err := s.quoteFee(consts.TransactionTypeTransfer, dataFrom.Currency, reqAmount)
+	if err != nil {
+		slog.Warn(logPrefix+" failed fee", slog.Any("req", req), slog.Any("err", err))
+		return repository.TransactionPayload{}, repository.Transaction{}, err
+	}
+
+	if err := validateAccounts(dataTo, dataFrom, reqAmount, feeAmount(fee)); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.Any("err", err))
+		return repository.TransactionPayload{}, repository.Transaction{}, wrapError(err)
+	}
+
+	payloadReq := prepareTrxPayload(dataFrom, dataTo, reqAmount, fee)
+	if crossCurrency {
+		if err := s.applyFX(ctx, &payloadReq, dataFrom.Currency, dataTo.Currency); err != nil {
+			slog.Warn(logPrefix+" failed fx conversion", slog.Any("req", req), slog.Any("err", err))
+			return repository.TransactionPayload{}, repository.Transaction{}, err
+		}
+	}
+	applyReference(&payloadReq.Transaction, req.ReferenceNumber, fingerprint)
+
+	return payloadReq, repository.Transaction{}, nil
+}
+
+func (s *wallet) GetTransaction(ctx context.Context, id string) (presentations.Transaction, error) {
+	if err := validateTransactionID(id); err != nil {
+		slog.Warn("[GetTransaction] failed validation", slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	result, err := s.repo.GetTransaction(ctx, id)
+	if err != nil {
+		slog.Warn("[GetTransaction] failed GetTransaction", slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	return s.withLedgerEntries(ctx, "[GetTransaction]", result)
+}
+
+func (s *wallet) GetTransactionByReference(ctx context.Context, referenceNumber string) (presentations.Transaction, error) {
+	if referenceNumber == "" {
+		return presentations.Transaction{}, ErrReferenceRequired
+	}
+
+	if err := validateReferenceNumber(referenceNumber); err != nil {
+		slog.Warn("[GetTransactionByReference] failed validation", slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	result, err := s.repo.GetTransactionByReference(ctx, referenceNumber)
+	if err != nil {
+		slog.Warn("[GetTransactionByReference] failed GetTransactionByReference", slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	return s.withLedgerEntries(ctx, "[GetTransactionByReference]", result)
+}
+
+func (s *wallet) withLedgerEntries(ctx context.Context, logPrefix string, trx repository.Transaction) (presentations.Transaction, error) {
+	if trx.ID == "" {
+		slog.Warn(logPrefix + " failed data not found")
+		return presentations.Transaction{}, ErrTransactionNotFound
+	}
+
+	if err := s.authorizeTransaction(ctx, logPrefix, trx); err != nil {
+		return presentations.Transaction{}, err
+	}
+
+	entries, err := s.repo.GetTransactionLedgerEntries(ctx, trx.ID)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetTransactionLedgerEntries", slog.Any("err", err))
+		return presentations.Transaction{}, wrapError(err)
+	}
+
+	slog.Info(logPrefix+" success", slog.String("id", trx.ID))
+	return toTransaction(trx, entries), nil
+}
+
+func toTransaction(trx repository.Transaction, entries []repository.LedgerEntry) presentations.Transaction {
+	resp := presentations.Transaction{
+		ID:              trx.ID,
+		ReferenceNumber: trx.ReferenceNumber,
+		Type:            string(trx.Type),
+		Status:          trx.Status,
+		Amount:          trx.Amount,
+		Description:     trx.Description,
+		Metadata:        trx.Metadata,
+		Fee:             transactionFee(trx),
+		CreatedAt:       trx.CreatedAt,
+	}
+
+	if trx.FromAccountID.Valid {
+		id := int(trx.FromAccountID.Int64)
+		resp.SourceAccountID = &id
+	}
+
+	if trx.ToAccountID.Valid {
+		id := int(trx.ToAccountID.Int64)
+		resp.DestinationAccountID = &id
+	}
+
+	if trx.OriginalTransactionID.Valid {
+		resp.OriginalTransactionID = trx.OriginalTransactionID.String
+	}
+
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, presentations.LedgerEntry{
+			ID:            e.ID,
+			AccountID:     e.AccountID,
+			EntryType:     string(e.EntryType),
+			Amount:        e.Amount,
+			BalanceBefore: e.BalanceBefore,
+			BalanceAfter:  e.BalanceAfter,
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+
+	return resp
+}
+
+func (s *wallet) Withdraw(ctx context.Context, req presentations.CreateWithdrawal) error {
+	if err := validateAccountID(req.AccountID); err != nil {
+		slog.Warn("[Withdraw] failed validation", slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	reqAmount, err := utils.ParseDecimal(req.Amount)
+	if err != nil {
+		slog.Warn("[Withdraw] failed validation", slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	if err := validateAmount(reqAmount); err != nil {
+		slog.Warn("[Withdraw] failed validation", slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	account, err := s.validateAccountAmount(ctx, "[Withdraw]", req.AccountID, reqAmount)
 	if err != nil {
-		slog.Warn("[SubmitTransaction] failed submit transaction", slog.Any("req", req))
 		return err
 	}
-	slog.Info("[SubmitTransaction] success", slog.Any("req", req))
+
+	fee, err := s.quoteFee(consts.TransactionTypeWithdraw, account.Currency, reqAmount)
+	if err != nil {
+		slog.Warn("[Withdraw] failed fee", slog.Any("req", req), slog.Any("err", err))
+		return err
+	}
+
+	err = s.repo.Withdraw(ctx, prepareWithdrawPayload(req.AccountID, reqAmount, fee))
+	if err != nil {
+		slog.Warn("[Withdraw] failed withdraw", slog.Any("req", req), slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	slog.Info("[Withdraw] success", slog.Any("req", req))
 	return nil
 }
 
-func prepareTrxPayload(from, to repository.Account, amount float64) repository.TransactionPayload {
+func (s *wallet) Deposit(ctx context.Context, accountID int, req presentations.CreateDeposit) error {
+	if err := validateAccountID(accountID); err != nil {
+		slog.Warn("[Deposit] failed validation", slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	reqAmount, err := utils.ParseDecimal(req.Amount)
+	if err != nil {
+		slog.Warn("[Deposit] failed validation", slog.Any("err", err))
+		return wrapError(err)
+	}
 
-	var payload repository.TransactionPayload
+	if err := s.validateIncomingAmount(reqAmount); err != nil {
+		slog.Warn("[Deposit] failed validation", slog.Any("err", err))
+		return err
+	}
 
-	fromOldBalance := from.Balance
-	from.Balance -= amount
+	if _, err := s.validateAccountAmount(ctx, "[Deposit]", accountID, reqAmount); err != nil {
+		return err
+	}
 
-	toOldBalance := to.Balance
-	to.Balance += amount
+	deposit := prepareDepositPayload(repository.Account{
+		AccountID: accountID,
+		Balance:   reqAmount,
+	})
+
+	err = s.repo.Deposit(ctx, repository.TopUpPayload{
+		AccountID:          accountID,
+		Amount:             reqAmount,
+		Transaction:        deposit.Transaction,
+		LedgerEntry:        deposit.LedgerEntry,
+		LedgerEntryFunding: deposit.LedgerEntryFunding,
+	})
+	if err != nil {
+		slog.Warn("[Deposit] failed deposit", slog.Any("accountID", accountID), slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	slog.Info("[Deposit] success", slog.Any("accountID", accountID), slog.Any("req", req))
+	return nil
+}
+
+// prepareTrxPayload builds the transfer of amount from from to to. A non-nil
+// fee is charged to from on top of amount.
+func prepareTrxPayload(from, to repository.Account, amount utils.Decimal, fee *presentations.Fee) repository.TransactionPayload {
+
+	var payload repository.TransactionPayload
 
 	payload.From = from
 	payload.To = to
+	payload.Amount = amount
 
 	payload.Transaction = repository.Transaction{
 		ID:              uuid.NewString(),
@@ -160,8 +602,8 @@ func prepareTrxPayload(from, to repository.Account, amount float64) repository.T
 			Valid: true,
 		},
 		Amount:      amount,
-		Status:      "completed",
-		Description: fmt.Sprintf("transfer %v from %d to %d", amount, from.AccountID, to.AccountID),
+		Status:      consts.TransactionStatusCompleted,
+		Description: fmt.Sprintf("transfer %s from %d to %d", amount, from.AccountID, to.AccountID),
 		CreatedAt:   time.Now(),
 	}
 
@@ -171,8 +613,6 @@ func prepareTrxPayload(from, to repository.Account, amount float64) repository.T
 		AccountID:     from.AccountID,
 		EntryType:     consts.EntryTypeDebit,
 		Amount:        amount,
-		BalanceAfter:  from.Balance,
-		BalanceBefore: fromOldBalance,
 		Description:   "transfer transaction",
 		CreatedAt:     time.Now(),
 	}
@@ -183,12 +623,12 @@ func prepareTrxPayload(from, to repository.Account, amount float64) repository.T
 		AccountID:     to.AccountID,
 		EntryType:     consts.EntryTypeCredit,
 		Amount:        amount,
-		BalanceAfter:  to.Balance,
-		BalanceBefore: toOldBalance,
 		Description:   "transfer transaction",
 		CreatedAt:     time.Now(),
 	}
 
+	payload.Fee = newFeeTransfer(&payload.Transaction, from.AccountID, fee)
+
 	return payload
 }
 
@@ -205,8 +645,8 @@ func prepareDepositPayload(acc repository.Account) repository.DepositPayload {
 			Valid: true,
 		},
 		Amount:      acc.Balance,
-		Status:      "completed",
-		Description: fmt.Sprintf("deposit %v to %d", acc.Balance, acc.AccountID),
+		Status:      consts.TransactionStatusCompleted,
+		Description: fmt.Sprintf("deposit %s to %d", acc.Balance, acc.AccountID),
 		CreatedAt:   time.Now(),
 	}
 
@@ -217,10 +657,62 @@ func prepareDepositPayload(acc repository.Account) repository.DepositPayload {
 		EntryType:     consts.EntryTypeCredit,
 		Amount:        acc.Balance,
 		BalanceAfter:  acc.Balance,
-		BalanceBefore: 0,
+		BalanceBefore: utils.Decimal{},
 		Description:   "deposit transaction",
 		CreatedAt:     time.Now(),
 	}
 
+	payload.LedgerEntryFunding = repository.LedgerEntry{
+		ID:            uuid.NewString(),
+		TransactionID: payload.Transaction.ID,
+		EntryType:     consts.EntryTypeDebit,
+		Amount:        acc.Balance,
+		Description:   "deposit funding",
+		CreatedAt:     time.Now(),
+	}
+
+	return payload
+}
+
+func prepareWithdrawPayload(accountID int, amount utils.Decimal, fee *presentations.Fee) repository.WithdrawPayload {
+	var payload repository.WithdrawPayload
+
+	payload.AccountID = accountID
+	payload.Amount = amount
+	payload.Transaction = repository.Transaction{
+		ID:              uuid.NewString(),
+		ReferenceNumber: uuid.NewString(),
+		Type:            consts.TransactionTypeWithdraw,
+		FromAccountID: sql.NullInt64{
+			Int64: int64(accountID),
+			Valid: true,
+		},
+		Amount:      amount,
+		Status:      consts.TransactionStatusCompleted,
+		Description: fmt.Sprintf("withdraw %s from %d", amount, accountID),
+		CreatedAt:   time.Now(),
+	}
+
+	payload.LedgerEntry = repository.LedgerEntry{
+		ID:            uuid.NewString(),
+		TransactionID: payload.Transaction.ID,
+		AccountID:     accountID,
+		EntryType:     consts.EntryTypeDebit,
+		Amount:        amount,
+		Description:   "withdraw transaction",
+		CreatedAt:     time.Now(),
+	}
+
+	payload.LedgerEntryFunding = repository.LedgerEntry{
+		ID:            uuid.NewString(),
+		TransactionID: payload.Transaction.ID,
+		EntryType:     consts.EntryTypeCredit,
+		Amount:        amount,
+		Description:   "withdraw funding",
+		CreatedAt:     time.Now(),
+	}
+
+	payload.Fee = newFeeTransfer(&payload.Transaction, accountID, fee)
+
 	return payload
 }
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
 	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/utils"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
@@ -18,7 +22,7 @@ func TestGetAccount(t *testing.T) {
 	mRepo := mocks.NewMockWalletRepository(mockCtl)
 
 	errTest := errors.New("test")
-	ctx := context.TODO()
+	ctx := staffCtx()
 
 	testTables := []struct {
 		name   string
@@ -29,7 +33,7 @@ func TestGetAccount(t *testing.T) {
 	}{
 		{
 			name:   "FAILED validation",
-			err:    errors.New("invalid account_id format"),
+			err:    ErrInvalidAccountID,
 			req:    0,
 			result: presentations.Account{},
 			mock:   func() {},
@@ -45,7 +49,7 @@ func TestGetAccount(t *testing.T) {
 		},
 		{
 			name:   "FAILED data not found",
-			err:    errors.New("data not found"),
+			err:    ErrAccountNotFound,
 			req:    2,
 			result: presentations.Account{},
 			mock: func() {
@@ -58,12 +62,32 @@ func TestGetAccount(t *testing.T) {
 			req:  2,
 			result: presentations.Account{
 				AccountID: 2,
-				Balance:   "0",
+				Currency:  "EUR",
+				Balance:   utils.Decimal{},
 			},
 			mock: func() {
 				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{
 					ID:        1,
 					AccountID: 2,
+					Currency:  "EUR",
+				}, nil)
+			},
+		},
+		{
+			name: "SUCCESS with active holds",
+			err:  nil,
+			req:  2,
+			result: presentations.Account{
+				AccountID:        2,
+				Balance:          utils.NewDecimal(100),
+				AvailableBalance: utils.MustParseDecimal("60.5"),
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{
+					ID:         1,
+					AccountID:  2,
+					Balance:    utils.NewDecimal(100),
+					HeldAmount: utils.MustParseDecimal("39.5"),
 				}, nil)
 			},
 		},
@@ -75,7 +99,7 @@ func TestGetAccount(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 			resp, err := svc.GetAccount(ctx, tt.req)
-			assert.Equal(t, tt.err, err)
+			assert.ErrorIs(t, err, tt.err)
 			assert.Equal(t, tt.result, resp)
 		})
 	}
@@ -87,7 +111,7 @@ func TestCreateAccount(t *testing.T) {
 	mRepo := mocks.NewMockWalletRepository(mockCtl)
 
 	errTest := errors.New("test")
-	ctx := context.TODO()
+	ctx := staffCtx()
 
 	testTables := []struct {
 		name string
@@ -97,7 +121,7 @@ func TestCreateAccount(t *testing.T) {
 	}{
 		{
 			name: "FAILED validation #1",
-			err:  errors.New("invalid account_id format"),
+			err:  ErrInvalidAccountID,
 			req: presentations.CreateAccount{
 				AccountID:      0,
 				InitialBalance: "0",
@@ -106,10 +130,38 @@ func TestCreateAccount(t *testing.T) {
 		},
 		{
 			name: "FAILED validation #2",
-			err:  errors.New("amount cannot be less than 1.00"),
+			err:  ErrAmountTooSmall,
 			req: presentations.CreateAccount{
 				AccountID:      2,
-				InitialBalance: "0",
+				InitialBalance: "-1",
+			},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation #3",
+			err:  ErrInvalidAmount,
+			req: presentations.CreateAccount{
+				AccountID:      2,
+				InitialBalance: "100.1234567",
+			},
+			mock: func() {},
+		},
+		{
+			name: "FAILED unsupported currency",
+			err:  ErrUnsupportedCurrency,
+			req: presentations.CreateAccount{
+				AccountID:      2,
+				Currency:       "XYZ",
+				InitialBalance: "100",
+			},
+			mock: func() {},
+		},
+		{
+			name: "FAILED amount beyond currency scale",
+			err:  ErrAmountScale,
+			req: presentations.CreateAccount{
+				AccountID:      2,
+				InitialBalance: "100.123",
 			},
 			mock: func() {},
 		},
@@ -126,7 +178,7 @@ func TestCreateAccount(t *testing.T) {
 		},
 		{
 			name: "FAILED data exists",
-			err:  errors.New("data already exists"),
+			err:  ErrAccountExists,
 			req: presentations.CreateAccount{
 				AccountID:      2,
 				InitialBalance: "100",
@@ -160,18 +212,107 @@ func TestCreateAccount(t *testing.T) {
 			mock: func() {
 				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
 
-				mRepo.EXPECT().CreateAccount(ctx, gomock.AssignableToTypeOf(repository.DepositPayload{})).Return(nil)
+				mRepo.EXPECT().CreateAccount(ctx, gomock.AssignableToTypeOf(repository.DepositPayload{})).DoAndReturn(
+					func(_ context.Context, payload repository.DepositPayload) error {
+						assert.Equal(t, consts.DefaultCurrency, payload.Account.Currency)
+						return nil
+					})
+			},
+		},
+		{
+			name: "SUCCESS empty",
+			err:  nil,
+			req: presentations.CreateAccount{
+				AccountID:      2,
+				InitialBalance: "0",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
+
+				mRepo.EXPECT().CreateAccount(ctx, gomock.AssignableToTypeOf(repository.DepositPayload{})).DoAndReturn(
+					func(_ context.Context, payload repository.DepositPayload) error {
+						assert.True(t, payload.Account.Balance.IsZero())
+						return nil
+					})
+			},
+		},
+		{
+			name: "SUCCESS with currency",
+			err:  nil,
+			req: presentations.CreateAccount{
+				AccountID:      2,
+				Currency:       "jpy",
+				InitialBalance: "1500",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
+
+				mRepo.EXPECT().CreateAccount(ctx, gomock.AssignableToTypeOf(repository.DepositPayload{})).DoAndReturn(
+					func(_ context.Context, payload repository.DepositPayload) error {
+						assert.Equal(t, "JPY", payload.Account.Currency)
+						return nil
+					})
+			},
+		},
+		{
+			name: "SUCCESS with idempotency key",
+			err:  nil,
+			req: presentations.CreateAccount{
+				AccountID:       2,
+				InitialBalance:  "100",
+				ReferenceNumber: "key-1",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetTransactionByReference(ctx, "key-1").Return(repository.Transaction{}, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
+
+				mRepo.EXPECT().CreateAccount(ctx, gomock.AssignableToTypeOf(repository.DepositPayload{})).DoAndReturn(
+					func(_ context.Context, payload repository.DepositPayload) error {
+						assert.Equal(t, "key-1", payload.Transaction.ReferenceNumber)
+						assert.Equal(t, requestFingerprint("create_account", 2, consts.DefaultCurrency, utils.NewDecimal(100)), payload.Transaction.RequestFingerprint)
+						return nil
+					})
+			},
+		},
+		{
+			name: "SUCCESS replay",
+			err:  nil,
+			req: presentations.CreateAccount{
+				AccountID:       2,
+				InitialBalance:  "100.000",
+				ReferenceNumber: "key-1",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetTransactionByReference(ctx, "key-1").Return(repository.Transaction{
+					ID:                 "trx-1",
+					RequestFingerprint: requestFingerprint("create_account", 2, consts.DefaultCurrency, utils.NewDecimal(100)),
+				}, nil)
+			},
+		},
+		{
+			name: "FAILED replay with different payload",
+			err:  ErrIdempotencyConflict,
+			req: presentations.CreateAccount{
+				AccountID:       2,
+				InitialBalance:  "150",
+				ReferenceNumber: "key-1",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetTransactionByReference(ctx, "key-1").Return(repository.Transaction{
+					ID:                 "trx-1",
+					RequestFingerprint: requestFingerprint("create_account", 2, consts.DefaultCurrency, utils.NewDecimal(100)),
+				}, nil)
 			},
 		},
 	}
 
-	svc := NewWalletService(mRepo)
+	svc := NewWalletService(mRepo, WithCurrencies(map[string]int{"USD": 2, "JPY": 0}))
 	for _, tt := range testTables {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 			err := svc.CreateAccount(ctx, tt.req)
-			assert.Equal(t, tt.err, err)
+			assert.ErrorIs(t, err, tt.err)
 		})
 	}
 }
@@ -182,7 +323,7 @@ func TestSubmitTransaction(t *testing.T) {
 	mRepo := mocks.NewMockWalletRepository(mockCtl)
 
 	errTest := errors.New("test")
-	ctx := context.TODO()
+	ctx := staffCtx()
 
 	testTables := []struct {
 		name string
@@ -192,7 +333,7 @@ func TestSubmitTransaction(t *testing.T) {
 	}{
 		{
 			name: "FAILED validation #1",
-			err:  errors.New("invalid account_id format"),
+			err:  ErrInvalidAccountID,
 			req: presentations.CreateTransaction{
 				DestinationAccountID: 0,
 				SourceAccountID:      0,
@@ -203,7 +344,7 @@ func TestSubmitTransaction(t *testing.T) {
 
 		{
 			name: "FAILED validation #2",
-			err:  errors.New("request payload invalid"),
+			err:  ErrSameAccount,
 			req: presentations.CreateTransaction{
 				DestinationAccountID: 1,
 				SourceAccountID:      1,
@@ -240,7 +381,7 @@ func TestSubmitTransaction(t *testing.T) {
 		},
 		{
 			name: "FAILED data not found",
-			err:  errors.New("data not found"),
+			err:  ErrAccountNotFound,
 			req: presentations.CreateTransaction{
 				DestinationAccountID: 2,
 				SourceAccountID:      3,
@@ -253,6 +394,52 @@ func TestSubmitTransaction(t *testing.T) {
 				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
 			},
 		},
+		{
+			name: "FAILED currency mismatch",
+			err:  ErrCurrencyMismatch,
+			req: presentations.CreateTransaction{
+				DestinationAccountID: 2,
+				SourceAccountID:      3,
+				Amount:               "10",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{
+					ID:        1,
+					AccountID: 3,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
+				}, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{
+					ID:        2,
+					AccountID: 2,
+					Currency:  "EUR",
+					Balance:   utils.NewDecimal(50),
+				}, nil)
+			},
+		},
+		{
+			name: "FAILED amount beyond currency scale",
+			err:  ErrAmountScale,
+			req: presentations.CreateTransaction{
+				DestinationAccountID: 2,
+				SourceAccountID:      3,
+				Amount:               "10.001",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{
+					ID:        1,
+					AccountID: 3,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
+				}, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{
+					ID:        2,
+					AccountID: 2,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
+				}, nil)
+			},
+		},
 		{
 			name: "Error Insert",
 			err:  errTest,
@@ -264,13 +451,15 @@ func TestSubmitTransaction(t *testing.T) {
 			mock: func() {
 				mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{
 					ID:        1,
-					Balance:   50,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
 					AccountID: 3,
 				}, nil)
 				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{
 					ID:        2,
 					AccountID: 2,
-					Balance:   50,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
 				}, nil)
 
 				mRepo.EXPECT().SubmitTransaction(ctx, gomock.AssignableToTypeOf(repository.TransactionPayload{})).Return(errTest)
@@ -287,18 +476,88 @@ func TestSubmitTransaction(t *testing.T) {
 			mock: func() {
 				mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{
 					ID:        1,
-					Balance:   50,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
 					AccountID: 3,
 				}, nil)
 				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{
 					ID:        2,
 					AccountID: 2,
-					Balance:   50,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
 				}, nil)
 
 				mRepo.EXPECT().SubmitTransaction(ctx, gomock.AssignableToTypeOf(repository.TransactionPayload{})).Return(nil)
 			},
 		},
+		{
+			name: "SUCCESS replay",
+			err:  nil,
+			req: presentations.CreateTransaction{
+				DestinationAccountID: 2,
+				SourceAccountID:      3,
+				Amount:               "10",
+				ReferenceNumber:      "key-2",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetTransactionByReference(ctx, "key-2").Return(repository.Transaction{
+					ID:                 "trx-2",
+					FromAccountID:      sql.NullInt64{Int64: 3, Valid: true},
+					ToAccountID:        sql.NullInt64{Int64: 2, Valid: true},
+					RequestFingerprint: requestFingerprint(consts.TransactionTypeTransfer, 3, 2, utils.NewDecimal(10)),
+				}, nil)
+			},
+		},
+		{
+			name: "FAILED replay with different payload",
+			err:  ErrIdempotencyConflict,
+			req: presentations.CreateTransaction{
+				DestinationAccountID: 2,
+				SourceAccountID:      3,
+				Amount:               "11",
+				ReferenceNumber:      "key-2",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetTransactionByReference(ctx, "key-2").Return(repository.Transaction{
+					ID:                 "trx-2",
+					RequestFingerprint: requestFingerprint(consts.TransactionTypeTransfer, 3, 2, utils.NewDecimal(10)),
+				}, nil)
+			},
+		},
+		{
+			name: "SUCCESS concurrent duplicate resolved as replay",
+			err:  nil,
+			req: presentations.CreateTransaction{
+				DestinationAccountID: 2,
+				SourceAccountID:      3,
+				Amount:               "10",
+				ReferenceNumber:      "key-3",
+			},
+			mock: func() {
+				fingerprint := requestFingerprint(consts.TransactionTypeTransfer, 3, 2, utils.NewDecimal(10))
+				first := mRepo.EXPECT().GetTransactionByReference(ctx, "key-3").Return(repository.Transaction{}, nil)
+				mRepo.EXPECT().GetAccount(ctx, 3).Return(repository.Account{
+					ID:        1,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
+					AccountID: 3,
+				}, nil)
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{
+					ID:        2,
+					AccountID: 2,
+					Currency:  consts.DefaultCurrency,
+					Balance:   utils.NewDecimal(50),
+				}, nil)
+
+				mRepo.EXPECT().SubmitTransaction(ctx, gomock.AssignableToTypeOf(repository.TransactionPayload{})).Return(repository.ErrDuplicateReference)
+				mRepo.EXPECT().GetTransactionByReference(ctx, "key-3").Return(repository.Transaction{
+					ID:                 "trx-3",
+					FromAccountID:      sql.NullInt64{Int64: 3, Valid: true},
+					ToAccountID:        sql.NullInt64{Int64: 2, Valid: true},
+					RequestFingerprint: fingerprint,
+				}, nil).After(first)
+			},
+		},
 	}
 
 	svc := NewWalletService(mRepo)
@@ -306,8 +565,360 @@ func TestSubmitTransaction(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			err := svc.SubmitTransaction(ctx, tt.req)
-			assert.Equal(t, tt.err, err)
+			resp, err := svc.SubmitTransaction(ctx, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.NotEmpty(t, resp.ID)
+				assert.Equal(t, tt.req.SourceAccountID, *resp.SourceAccountID)
+				assert.Equal(t, tt.req.DestinationAccountID, *resp.DestinationAccountID)
+			}
 		})
 	}
 }
+
+func TestWithdraw(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	account := repository.Account{ID: 1, AccountID: 2, Currency: consts.DefaultCurrency}
+
+	testTables := []struct {
+		name string
+		mock func()
+		err  error
+		req  presentations.CreateWithdrawal
+	}{
+		{
+			name: "FAILED validation #1",
+			err:  ErrInvalidAccountID,
+			req: presentations.CreateWithdrawal{
+				AccountID: 0,
+				Amount:    "10",
+			},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation #2",
+			err:  ErrAmountTooSmall,
+			req: presentations.CreateWithdrawal{
+				AccountID: 2,
+				Amount:    "0",
+			},
+			mock: func() {},
+		},
+		{
+			name: "FAILED amount beyond currency scale",
+			err:  ErrAmountScale,
+			req: presentations.CreateWithdrawal{
+				AccountID: 2,
+				Amount:    "10.505",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+			},
+		},
+		{
+			name: "FAILED insufficient balance",
+			err:  ErrInsufficientFunds,
+			req: presentations.CreateWithdrawal{
+				AccountID: 2,
+				Amount:    "10",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+				mRepo.EXPECT().Withdraw(ctx, gomock.AssignableToTypeOf(repository.WithdrawPayload{})).Return(repository.ErrInsufficientBalance)
+			},
+		},
+		{
+			name: "FAILED daily limit",
+			err:  ErrLimitExceeded,
+			req: presentations.CreateWithdrawal{
+				AccountID: 2,
+				Amount:    "10",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+				mRepo.EXPECT().Withdraw(ctx, gomock.AssignableToTypeOf(repository.WithdrawPayload{})).Return(&repository.LimitError{Rule: repository.LimitDailyAmount, Limit: "5"})
+			},
+		},
+		{
+			name: "SUCCESS",
+			err:  nil,
+			req: presentations.CreateWithdrawal{
+				AccountID: 2,
+				Amount:    "10.5",
+			},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+				mRepo.EXPECT().Withdraw(ctx, gomock.AssignableToTypeOf(repository.WithdrawPayload{})).DoAndReturn(
+					func(_ context.Context, payload repository.WithdrawPayload) error {
+						assert.Equal(t, 2, payload.AccountID)
+						assert.Equal(t, utils.MustParseDecimal("10.5"), payload.Amount)
+						assert.Equal(t, consts.EntryTypeDebit, payload.LedgerEntry.EntryType)
+						assert.Equal(t, payload.Transaction.ID, payload.LedgerEntry.TransactionID)
+						return nil
+					})
+			},
+		},
+	}
+
+	svc := NewWalletService(mRepo)
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := svc.Withdraw(ctx, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestDeposit(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	errTest := errors.New("test")
+	ctx := staffCtx()
+	account := repository.Account{ID: 1, AccountID: 2, Currency: consts.DefaultCurrency}
+
+	testTables := []struct {
+		name      string
+		mock      func()
+		err       error
+		accountID int
+		req       presentations.CreateDeposit
+	}{
+		{
+			name:      "FAILED validation #1",
+			err:       ErrInvalidAccountID,
+			accountID: 0,
+			req:       presentations.CreateDeposit{Amount: "10"},
+			mock:      func() {},
+		},
+		{
+			name:      "FAILED validation #2",
+			err:       ErrInvalidAmount,
+			accountID: 2,
+			req:       presentations.CreateDeposit{Amount: "ten"},
+			mock:      func() {},
+		},
+		{
+			name:      "FAILED below minimum",
+			err:       ErrAmountTooSmall,
+			accountID: 2,
+			req:       presentations.CreateDeposit{Amount: "0.5"},
+			mock:      func() {},
+		},
+		{
+			name:      "FAILED above maximum",
+			err:       ErrLimitExceeded,
+			accountID: 2,
+			req:       presentations.CreateDeposit{Amount: "1000000.01"},
+			mock:      func() {},
+		},
+		{
+			name:      "FAILED data not found",
+			err:       ErrAccountNotFound,
+			accountID: 2,
+			req:       presentations.CreateDeposit{Amount: "10"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(repository.Account{}, nil)
+			},
+		},
+		{
+			name:      "FAILED amount beyond currency scale",
+			err:       ErrAmountScale,
+			accountID: 2,
+			req:       presentations.CreateDeposit{Amount: "25.000001"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+			},
+		},
+		{
+			name:      "FAILED db error",
+			err:       errTest,
+			accountID: 2,
+			req:       presentations.CreateDeposit{Amount: "10"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+				mRepo.EXPECT().Deposit(ctx, gomock.AssignableToTypeOf(repository.TopUpPayload{})).Return(errTest)
+			},
+		},
+		{
+			name:      "SUCCESS",
+			err:       nil,
+			accountID: 2,
+			req:       presentations.CreateDeposit{Amount: "25.01"},
+			mock: func() {
+				mRepo.EXPECT().GetAccount(ctx, 2).Return(account, nil)
+				mRepo.EXPECT().Deposit(ctx, gomock.AssignableToTypeOf(repository.TopUpPayload{})).DoAndReturn(
+					func(_ context.Context, payload repository.TopUpPayload) error {
+						assert.Equal(t, 2, payload.AccountID)
+						assert.Equal(t, utils.MustParseDecimal("25.01"), payload.Amount)
+						assert.Equal(t, consts.TransactionTypeDeposit, payload.Transaction.Type)
+						assert.Equal(t, consts.EntryTypeCredit, payload.LedgerEntry.EntryType)
+						return nil
+					})
+			},
+		},
+	}
+
+	svc := NewWalletService(mRepo)
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			err := svc.Deposit(ctx, tt.accountID, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestGetTransaction(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	errTest := errors.New("test")
+	ctx := staffCtx()
+
+	trxID := "6f1c8a2e-3b1f-4f5e-9a51-0d3c2b1a0f9e"
+	createdAt := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	from, to := 3, 2
+
+	testTables := []struct {
+		name   string
+		mock   func()
+		err    error
+		req    string
+		result presentations.Transaction
+	}{
+		{
+			name: "FAILED validation",
+			err:  ErrInvalidTransactionID,
+			req:  "123",
+			mock: func() {},
+		},
+		{
+			name: "FAILED db error",
+			err:  errTest,
+			req:  trxID,
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, trxID).Return(repository.Transaction{}, errTest)
+			},
+		},
+		{
+			name: "FAILED data not found",
+			err:  ErrTransactionNotFound,
+			req:  trxID,
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, trxID).Return(repository.Transaction{}, nil)
+			},
+		},
+		{
+			name: "SUCCESS",
+			req:  trxID,
+			mock: func() {
+				mRepo.EXPECT().GetTransaction(ctx, trxID).Return(repository.Transaction{
+					ID:              trxID,
+					ReferenceNumber: "ref-1",
+					Type:            consts.TransactionTypeTransfer,
+					FromAccountID:   sql.NullInt64{Int64: 3, Valid: true},
+					ToAccountID:     sql.NullInt64{Int64: 2, Valid: true},
+					Amount:          utils.NewDecimal(10),
+					Status:          "completed",
+					CreatedAt:       createdAt,
+				}, nil)
+				mRepo.EXPECT().GetTransactionLedgerEntries(ctx, trxID).Return([]repository.LedgerEntry{
+					{
+						ID:            "le-1",
+						TransactionID: trxID,
+						AccountID:     3,
+						EntryType:     consts.EntryTypeDebit,
+						Amount:        utils.NewDecimal(10),
+						BalanceBefore: utils.NewDecimal(50),
+						BalanceAfter:  utils.NewDecimal(40),
+						CreatedAt:     createdAt,
+					},
+					{
+						ID:            "le-2",
+						TransactionID: trxID,
+						AccountID:     2,
+						EntryType:     consts.EntryTypeCredit,
+						Amount:        utils.NewDecimal(10),
+						BalanceBefore: utils.NewDecimal(0),
+						BalanceAfter:  utils.NewDecimal(10),
+						CreatedAt:     createdAt,
+					},
+				}, nil)
+			},
+			result: presentations.Transaction{
+				ID:                   trxID,
+				ReferenceNumber:      "ref-1",
+				Type:                 "transfer",
+				Status:               "completed",
+				Amount:               utils.NewDecimal(10),
+				SourceAccountID:      &from,
+				DestinationAccountID: &to,
+				CreatedAt:            createdAt,
+				Entries: []presentations.LedgerEntry{
+					{
+						ID:            "le-1",
+						AccountID:     3,
+						EntryType:     "debit",
+						Amount:        utils.NewDecimal(10),
+						BalanceBefore: utils.NewDecimal(50),
+						BalanceAfter:  utils.NewDecimal(40),
+						CreatedAt:     createdAt,
+					},
+					{
+						ID:            "le-2",
+						AccountID:     2,
+						EntryType:     "credit",
+						Amount:        utils.NewDecimal(10),
+						BalanceBefore: utils.NewDecimal(0),
+						BalanceAfter:  utils.NewDecimal(10),
+						CreatedAt:     createdAt,
+					},
+				},
+			},
+		},
+	}
+
+	svc := NewWalletService(mRepo)
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			resp, err := svc.GetTransaction(ctx, tt.req)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.result, resp)
+		})
+	}
+}
+
+func TestGetTransactionByReference(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	svc := NewWalletService(mRepo)
+
+	_, err := svc.GetTransactionByReference(ctx, "")
+	assert.ErrorIs(t, err, ErrReferenceRequired)
+
+	mRepo.EXPECT().GetTransactionByReference(ctx, "missing").Return(repository.Transaction{}, nil)
+	_, err = svc.GetTransactionByReference(ctx, "missing")
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+
+	mRepo.EXPECT().GetTransactionByReference(ctx, "ref-1").Return(repository.Transaction{ID: "trx-1", ReferenceNumber: "ref-1"}, nil)
+	mRepo.EXPECT().GetTransactionLedgerEntries(ctx, "trx-1").Return(nil, nil)
+	resp, err := svc.GetTransactionByReference(ctx, "ref-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "trx-1", resp.ID)
+	assert.Equal(t, "ref-1", resp.ReferenceNumber)
+}
@@ -0,0 +1,543 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/webhook"
+)
+
+const (
+	defaultWebhookTimeout      = 10 * time.Second
+	defaultWebhookMaxAttempts  = 8
+	defaultWebhookRetryBackoff = 30 * time.Second
+	maxWebhookRetryBackoff     = 6 * time.Hour
+
+	// minWebhookSecret is the shortest secret a client may choose.
+	minWebhookSecret = 16
+
+	// webhookClaimBatch bounds how many deliveries one worker claims at a
+	// time; webhookLease must outlast sending all of them.
+	webhookClaimBatch = 20
+	webhookLease      = 5 * time.Minute
+)
+
+// webhookEventTypes are the event types a webhook can subscribe to.
+var webhookEventTypes = map[string]bool{
+	consts.WebhookEventAll:           true,
+	consts.EventAccountCreated:       true,
+	consts.EventAccountStatusChanged: true,
+	consts.EventTransferCompleted:    true,
+	consts.EventDepositCompleted:     true,
+	consts.EventWithdrawalCompleted:  true,
+	consts.EventTransactionReversed:  true,
+	consts.EventJournalPosted:        true,
+}
+
+// CreateWebhook subscribes a URL to the events of one customer's accounts.
+func (s *wallet) CreateWebhook(ctx context.Context, req presentations.CreateWebhook) (presentations.Webhook, error) {
+	if req.CustomerID == "" {
+		slog.Warn("[CreateWebhook] failed validation", slog.String("url", req.URL))
+		return presentations.Webhook{}, validationError(ErrInvalidWebhook, "customer_id is required")
+	}
+
+	if err := validateWebhookURL(req.URL); err != nil {
+		slog.Warn("[CreateWebhook] failed validation", slog.String("url", req.URL))
+		return presentations.Webhook{}, err
+	}
+
+	eventTypes, err := parseWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		slog.Warn("[CreateWebhook] failed validation", slog.Any("event_types", req.EventTypes))
+		return presentations.Webhook{}, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = newWebhookSecret()
+		if err != nil {
+			slog.Warn("[CreateWebhook] failed generate secret", slog.Any("err", err))
+			return presentations.Webhook{}, wrapError(err)
+		}
+	}
+
+	if len(secret) < minWebhookSecret {
+		return presentations.Webhook{}, validationError(ErrInvalidWebhook, fmt.Sprintf("secret must be at least %d characters", minWebhookSecret))
+	}
+
+	customer, err := s.getCustomer(ctx, "[CreateWebhook]", req.CustomerID)
+	if err != nil {
+		return presentations.Webhook{}, err
+	}
+
+	now := time.Now()
+	sub := repository.WebhookSubscription{
+		ID:         uuid.NewString(),
+		CustomerID: customer.ID,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.CreateWebhookSubscription(ctx, sub); err != nil {
+		slog.Warn("[CreateWebhook] failed create webhook", slog.String("url", req.URL), slog.Any("err", err))
+		return presentations.Webhook{}, wrapError(err)
+	}
+
+	slog.Info("[CreateWebhook] success", slog.String("id", sub.ID), slog.String("url", sub.URL))
+	result := toWebhook(sub)
+	result.Secret = sub.Secret
+	return result, nil
+}
+
+func (s *wallet) GetWebhook(ctx context.Context, id string) (presentations.Webhook, error) {
+	sub, err := s.getWebhook(ctx, "[GetWebhook]", id)
+	if err != nil {
+		return presentations.Webhook{}, err
+	}
+
+	return toWebhook(sub), nil
+}
+
+func (s *wallet) ListWebhooks(ctx context.Context) ([]presentations.Webhook, error) {
+	list, err := s.repo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		slog.Warn("[ListWebhooks] failed ListWebhookSubscriptions", slog.Any("err", err))
+		return nil, wrapError(err)
+	}
+
+	result := make([]presentations.Webhook, 0, len(list))
+	for _, sub := range list {
+		result = append(result, toWebhook(sub))
+	}
+
+	return result, nil
+}
+
+// UpdateWebhook changes the URL, event types or active flag of a webhook.
+// Pending deliveries go to the new URL; a deactivated webhook keeps them
+// until it is activated again.
+func (s *wallet) UpdateWebhook(ctx context.Context, id string, req presentations.UpdateWebhook) (presentations.Webhook, error) {
+	sub, err := s.getWebhook(ctx, "[UpdateWebhook]", id)
+	if err != nil {
+		return presentations.Webhook{}, err
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			slog.Warn("[UpdateWebhook] failed validation", slog.String("url", *req.URL))
+			return presentations.Webhook{}, err
+		}
+		sub.URL = *req.URL
+	}
+
+	if req.EventTypes != nil {
+		sub.EventTypes, err = parseWebhookEventTypes(req.EventTypes)
+		if err != nil {
+			slog.Warn("[UpdateWebhook] failed validation", slog.Any("event_types", req.EventTypes))
+			return presentations.Webhook{}, err
+		}
+	}
+
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+
+	sub, err = s.repo.UpdateWebhookSubscription(ctx, sub)
+	if err != nil {
+		slog.Warn("[UpdateWebhook] failed update webhook", slog.String("id", id), slog.Any("err", err))
+		return presentations.Webhook{}, wrapError(err)
+	}
+
+	if sub.ID == "" {
+		return presentations.Webhook{}, ErrWebhookNotFound
+	}
+
+	slog.Info("[UpdateWebhook] success", slog.String("id", id))
+	return toWebhook(sub), nil
+}
+
+// DeleteWebhook removes a webhook together with its deliveries and their
+// attempt log.
+func (s *wallet) DeleteWebhook(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		slog.Warn("[DeleteWebhook] failed validation", slog.String("id", id))
+		return ErrInvalidWebhookID
+	}
+
+	deleted, err := s.repo.DeleteWebhookSubscription(ctx, id)
+	if err != nil {
+		slog.Warn("[DeleteWebhook] failed delete webhook", slog.String("id", id), slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	if !deleted {
+		slog.Warn("[DeleteWebhook] failed data not found", slog.String("id", id))
+		return ErrWebhookNotFound
+	}
+
+	slog.Info("[DeleteWebhook] success", slog.String("id", id))
+	return nil
+}
+
+func (s *wallet) ListWebhookDeliveries(ctx context.Context, id string, req presentations.ListWebhookDeliveries) ([]presentations.WebhookDelivery, error) {
+	sub, err := s.getWebhook(ctx, "[ListWebhookDeliveries]", id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.WebhookDeliveryFilter{
+		SubscriptionID: sub.ID,
+		Status:         req.Status,
+		Limit:          req.Limit,
+	}
+
+	switch filter.Status {
+	case "", consts.WebhookDeliveryPending, consts.WebhookDeliverySucceeded, consts.WebhookDeliveryFailed:
+	default:
+		slog.Warn("[ListWebhookDeliveries] failed validation", slog.String("status", filter.Status))
+		return nil, validationError(ErrInvalidFilter, fmt.Sprintf("unknown status %q", filter.Status))
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxHistoryLimit {
+		return nil, ErrInvalidLimit
+	}
+
+	list, err := s.repo.ListWebhookDeliveries(ctx, filter)
+	if err != nil {
+		slog.Warn("[ListWebhookDeliveries] failed ListWebhookDeliveries", slog.Any("err", err))
+		return nil, wrapError(err)
+	}
+
+	result := make([]presentations.WebhookDelivery, 0, len(list))
+	for _, d := range list {
+		result = append(result, toWebhookDelivery(d))
+	}
+
+	return result, nil
+}
+
+// GetWebhookDelivery returns a delivery of webhook id with its event and
+// attempt log.
+func (s *wallet) GetWebhookDelivery(ctx context.Context, id, deliveryID string) (presentations.WebhookDelivery, error) {
+	d, err := s.getWebhookDelivery(ctx, "[GetWebhookDelivery]", id, deliveryID)
+	if err != nil {
+		return presentations.WebhookDelivery{}, err
+	}
+
+	attempts, err := s.repo.ListWebhookAttempts(ctx, d.ID)
+	if err != nil {
+		slog.Warn("[GetWebhookDelivery] failed ListWebhookAttempts", slog.Any("err", err))
+		return presentations.WebhookDelivery{}, wrapError(err)
+	}
+
+	result := toWebhookDelivery(d)
+	result.Event = d.Payload
+	for _, a := range attempts {
+		result.AttemptLog = append(result.AttemptLog, presentations.WebhookAttempt{
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMs: a.Duration,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// RedeliverWebhook sends a delivery again with a fresh set of attempts, also
+// when it already succeeded or failed for good.
+func (s *wallet) RedeliverWebhook(ctx context.Context, id, deliveryID string) (presentations.WebhookDelivery, error) {
+	d, err := s.getWebhookDelivery(ctx, "[RedeliverWebhook]", id, deliveryID)
+	if err != nil {
+		return presentations.WebhookDelivery{}, err
+	}
+
+	d, err = s.repo.RedeliverWebhook(ctx, d.ID)
+	if err != nil {
+		slog.Warn("[RedeliverWebhook] failed redeliver", slog.String("id", deliveryID), slog.Any("err", err))
+		return presentations.WebhookDelivery{}, wrapError(err)
+	}
+
+	if d.ID == "" {
+		return presentations.WebhookDelivery{}, ErrDeliveryNotFound
+	}
+
+	slog.Info("[RedeliverWebhook] success", slog.String("webhook_id", id), slog.String("id", deliveryID))
+	return toWebhookDelivery(d), nil
+}
+
+// RunWebhookDeliveries sends every due webhook delivery and reports how many
+// attempts it made. Deliveries are claimed in batches, so concurrent callers
+// share the work. A delivery is at least once: a worker dying after the
+// receiver accepted it sends it again once its claim lapses.
+func (s *wallet) RunWebhookDeliveries(ctx context.Context) (int, error) {
+	var total int
+	for ctx.Err() == nil {
+		now := time.Now()
+		due, err := s.repo.ClaimWebhookDeliveries(ctx, now, now.Add(webhookLease), webhookClaimBatch)
+		if err != nil {
+			slog.Warn("[RunWebhookDeliveries] failed claim webhook deliveries", slog.Int("attempts", total), slog.Any("err", err))
+			return total, wrapError(err)
+		}
+
+		subs := make(map[string]repository.WebhookSubscription)
+		for _, d := range due {
+			sub, ok := subs[d.SubscriptionID]
+			if !ok {
+				sub, err = s.repo.GetWebhookSubscription(ctx, d.SubscriptionID)
+				if err != nil {
+					slog.Warn("[RunWebhookDeliveries] failed GetWebhookSubscription", slog.Any("err", err))
+					return total, wrapError(err)
+				}
+				subs[d.SubscriptionID] = sub
+			}
+
+			// removed since the claim, its deliveries went with it
+			if sub.ID == "" {
+				continue
+			}
+
+			if err := s.deliverWebhook(ctx, sub, d); err != nil {
+				return total, err
+			}
+			total++
+		}
+
+		if len(due) < webhookClaimBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("[RunWebhookDeliveries] success", slog.Int("attempts", total))
+	}
+	return total, nil
+}
+
+// deliverWebhook makes one attempt of d and records it. A 2xx response
+// completes the delivery; anything else is retried with backoff until the
+// attempts are used up.
+func (s *wallet) deliverWebhook(ctx context.Context, sub repository.WebhookSubscription, d repository.WebhookDelivery) error {
+	start := time.Now()
+	statusCode, sendErr := s.sendWebhook(ctx, sub, d, start)
+
+	result := repository.WebhookAttemptResult{
+		Attempt: repository.WebhookAttempt{
+			DeliveryID: d.ID,
+			StatusCode: statusCode,
+			Duration:   time.Since(start).Milliseconds(),
+			CreatedAt:  start,
+		},
+		Status:        consts.WebhookDeliverySucceeded,
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+	}
+
+	if sendErr != nil {
+		result.Attempt.Error = sendErr.Error()
+		result.Attempts = d.Attempts + 1
+		result.Status = consts.WebhookDeliveryPending
+		result.NextAttemptAt = start.Add(s.webhookBackoff(result.Attempts))
+		if result.Attempts >= s.webhookMaxAttempts {
+			result.Status = consts.WebhookDeliveryFailed
+		}
+
+		slog.Warn("[RunWebhookDeliveries] failed attempt", slog.String("id", d.ID), slog.String("url", sub.URL), slog.Int("attempts", result.Attempts), slog.Any("err", sendErr))
+	}
+
+	if err := s.repo.RecordWebhookAttempt(ctx, result); err != nil {
+		slog.Warn("[RunWebhookDeliveries] failed record attempt", slog.String("id", d.ID), slog.Any("err", err))
+		return wrapError(err)
+	}
+
+	return nil
+}
+
+// sendWebhook POSTs the event of d to sub, signed with its secret at
+// timestamp, and returns the response status.
+func (s *wallet) sendWebhook(ctx context.Context, sub repository.WebhookSubscription, d repository.WebhookDelivery, timestamp time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", d.ID)
+	req.Header.Set("X-Event-Id", d.EventID)
+	req.Header.Set("X-Event-Type", d.EventType)
+	webhook.Sign(req.Header, sub.Secret, timestamp, d.Payload)
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff is the wait before retrying a delivery that failed attempts
+// times.
+func (s *wallet) webhookBackoff(attempts int) time.Duration {
+	backoff := s.webhookRetryBackoff
+	for i := 1; i < attempts && backoff < maxWebhookRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxWebhookRetryBackoff {
+		backoff = maxWebhookRetryBackoff
+	}
+	return backoff
+}
+
+func validateWebhookURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return validationError(ErrInvalidWebhook, "url must be an absolute http or https URL")
+	}
+
+	return nil
+}
+
+// parseWebhookEventTypes validates and de-duplicates the event types of a
+// subscription.
+func parseWebhookEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		return nil, validationError(ErrInvalidWebhook, "event_types must not be empty")
+	}
+
+	seen := make(map[string]bool, len(eventTypes))
+	result := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		if !webhookEventTypes[t] {
+			return nil, validationError(ErrInvalidWebhook, fmt.Sprintf("unknown event type %q", t))
+		}
+
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+
+	return result, nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func (s *wallet) getWebhook(ctx context.Context, logPrefix, id string) (repository.WebhookSubscription, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.String("id", id))
+		return repository.WebhookSubscription{}, ErrInvalidWebhookID
+	}
+
+	sub, err := s.repo.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetWebhookSubscription", slog.Any("err", err))
+		return repository.WebhookSubscription{}, wrapError(err)
+	}
+
+	if sub.ID == "" {
+		slog.Warn(logPrefix+" failed data not found", slog.String("id", id))
+		return repository.WebhookSubscription{}, ErrWebhookNotFound
+	}
+
+	return sub, nil
+}
+
+// getWebhookDelivery returns delivery deliveryID of webhook id. A delivery
+// of another webhook is reported as not found.
+func (s *wallet) getWebhookDelivery(ctx context.Context, logPrefix, id, deliveryID string) (repository.WebhookDelivery, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.String("id", id))
+		return repository.WebhookDelivery{}, ErrInvalidWebhookID
+	}
+
+	if _, err := uuid.Parse(deliveryID); err != nil {
+		slog.Warn(logPrefix+" failed validation", slog.String("delivery_id", deliveryID))
+		return repository.WebhookDelivery{}, ErrInvalidDeliveryID
+	}
+
+	d, err := s.repo.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		slog.Warn(logPrefix+" failed GetWebhookDelivery", slog.Any("err", err))
+		return repository.WebhookDelivery{}, wrapError(err)
+	}
+
+	if d.ID == "" || d.SubscriptionID != id {
+		slog.Warn(logPrefix+" failed data not found", slog.String("webhook_id", id), slog.String("id", deliveryID))
+		return repository.WebhookDelivery{}, ErrDeliveryNotFound
+	}
+
+	return d, nil
+}
+
+func toWebhook(sub repository.WebhookSubscription) presentations.Webhook {
+	return presentations.Webhook{
+		ID:         sub.ID,
+		CustomerID: sub.CustomerID,
+		URL:        sub.URL,
+		EventTypes: sub.EventTypes,
+		Active:     sub.Active,
+		CreatedAt:  sub.CreatedAt,
+		UpdatedAt:  sub.UpdatedAt,
+	}
+}
+
+func toWebhookDelivery(d repository.WebhookDelivery) presentations.WebhookDelivery {
+	result := presentations.WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: int(d.LastStatusCode.Int64),
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+	}
+
+	if d.Status == consts.WebhookDeliveryPending {
+		result.NextAttemptAt = &d.NextAttemptAt
+	}
+
+	if d.DeliveredAt.Valid {
+		result.DeliveredAt = &d.DeliveredAt.Time
+	}
+
+	return result
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/consts"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/presentations"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository"
+	"github.com/abdussalamfaqih/wallet-service-dev/internal/modules/wallets/repository/mocks"
+	"github.com/abdussalamfaqih/wallet-service-dev/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateWebhook(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+
+	testTables := []struct {
+		name string
+		mock func()
+		err  error
+		req  presentations.CreateWebhook
+	}{
+		{
+			name: "FAILED validation url",
+			err:  ErrInvalidWebhook,
+			req:  presentations.CreateWebhook{CustomerID: testCustomerID, URL: "ftp://example.com/hook", EventTypes: []string{consts.EventTransferCompleted}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation event type",
+			err:  ErrInvalidWebhook,
+			req:  presentations.CreateWebhook{CustomerID: testCustomerID, URL: "https://example.com/hook", EventTypes: []string{"transfer.failed"}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation short secret",
+			err:  ErrInvalidWebhook,
+			req:  presentations.CreateWebhook{CustomerID: testCustomerID, URL: "https://example.com/hook", Secret: "short", EventTypes: []string{consts.WebhookEventAll}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED validation customer",
+			err:  ErrInvalidWebhook,
+			req:  presentations.CreateWebhook{URL: "https://example.com/hook", EventTypes: []string{consts.WebhookEventAll}},
+			mock: func() {},
+		},
+		{
+			name: "FAILED unknown customer",
+			err:  ErrCustomerNotFound,
+			req:  presentations.CreateWebhook{CustomerID: otherCustomerID, URL: "https://example.com/hook", EventTypes: []string{consts.WebhookEventAll}},
+			mock: func() {
+				mRepo.EXPECT().GetCustomer(ctx, otherCustomerID).Return(repository.Customer{}, nil)
+			},
+		},
+		{
+			name: "SUCCESS generates secret",
+			req:  presentations.CreateWebhook{CustomerID: testCustomerID, URL: "https://example.com/hook", EventTypes: []string{consts.EventTransferCompleted, consts.EventTransferCompleted, consts.EventDepositCompleted}},
+			mock: func() {
+				mRepo.EXPECT().GetCustomer(ctx, testCustomerID).Return(repository.Customer{ID: testCustomerID, Name: "Ada"}, nil)
+				mRepo.EXPECT().CreateWebhookSubscription(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, sub repository.WebhookSubscription) error {
+					assert.Equal(t, testCustomerID, sub.CustomerID)
+					assert.True(t, sub.Active)
+					assert.Len(t, sub.Secret, len("whsec_")+48)
+					assert.Equal(t, []string{consts.EventTransferCompleted, consts.EventDepositCompleted}, sub.EventTypes)
+					return nil
+				})
+			},
+		},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := NewWalletService(mRepo).CreateWebhook(ctx, tt.req)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, got.ID)
+			assert.Equal(t, testCustomerID, got.CustomerID)
+			assert.NotEmpty(t, got.Secret)
+		})
+	}
+}
+
+func TestGetWebhookDeliveryOfOtherWebhook(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	mRepo.EXPECT().GetWebhookDelivery(ctx, "a3f3b0c2-6f4e-4a83-9d3c-0b0b7f0f6a01").Return(repository.WebhookDelivery{
+		ID:             "a3f3b0c2-6f4e-4a83-9d3c-0b0b7f0f6a01",
+		SubscriptionID: "9d1d5b1e-4a51-4e1f-8d61-0c2a6c1e0b02",
+	}, nil)
+
+	_, err := NewWalletService(mRepo).GetWebhookDelivery(ctx, "0e4b6f3a-2b1c-4d5e-8f90-1a2b3c4d5e03", "a3f3b0c2-6f4e-4a83-9d3c-0b0b7f0f6a01")
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+}
+
+func TestRunWebhookDeliveries(t *testing.T) {
+	ctx := staffCtx()
+	const secret = "whsec_0123456789abcdef"
+	payload := json.RawMessage(`{"id":"6c0d1f6e-3c1b-4b8e-a0d4-7b5f0f0e9c11","type":"transfer.completed"}`)
+
+	delivery := func(attempts int) repository.WebhookDelivery {
+		return repository.WebhookDelivery{
+			ID:             "a3f3b0c2-6f4e-4a83-9d3c-0b0b7f0f6a01",
+			SubscriptionID: "9d1d5b1e-4a51-4e1f-8d61-0c2a6c1e0b02",
+			EventID:        "6c0d1f6e-3c1b-4b8e-a0d4-7b5f0f0e9c11",
+			EventType:      consts.EventTransferCompleted,
+			Payload:        payload,
+			Status:         consts.WebhookDeliveryPending,
+			Attempts:       attempts,
+			NextAttemptAt:  time.Now(),
+		}
+	}
+
+	testTables := []struct {
+		name     string
+		status   int
+		delivery repository.WebhookDelivery
+		check    func(t *testing.T, result repository.WebhookAttemptResult)
+	}{
+		{
+			name:     "SUCCESS delivered",
+			status:   http.StatusOK,
+			delivery: delivery(1),
+			check: func(t *testing.T, result repository.WebhookAttemptResult) {
+				assert.Equal(t, consts.WebhookDeliverySucceeded, result.Status)
+				assert.Equal(t, http.StatusOK, result.Attempt.StatusCode)
+				assert.Empty(t, result.Attempt.Error)
+			},
+		},
+		{
+			name:     "FAILED receiver error retried with backoff",
+			status:   http.StatusInternalServerError,
+			delivery: delivery(1),
+			check: func(t *testing.T, result repository.WebhookAttemptResult) {
+				assert.Equal(t, consts.WebhookDeliveryPending, result.Status)
+				assert.Equal(t, 2, result.Attempts)
+				assert.Equal(t, http.StatusInternalServerError, result.Attempt.StatusCode)
+				assert.Equal(t, "receiver responded 500", result.Attempt.Error)
+				assert.Equal(t, result.Attempt.CreatedAt.Add(2*time.Second), result.NextAttemptAt)
+			},
+		},
+		{
+			name:     "FAILED attempts used up",
+			status:   http.StatusBadGateway,
+			delivery: delivery(2),
+			check: func(t *testing.T, result repository.WebhookAttemptResult) {
+				assert.Equal(t, consts.WebhookDeliveryFailed, result.Status)
+				assert.Equal(t, 3, result.Attempts)
+			},
+		},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var received int
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received++
+				body, _ := io.ReadAll(r.Body)
+				assert.NoError(t, webhook.Verify(r.Header, secret, body, time.Now(), time.Minute))
+				assert.JSONEq(t, string(payload), string(body))
+				assert.Equal(t, tt.delivery.ID, r.Header.Get("X-Webhook-Id"))
+				assert.Equal(t, consts.EventTransferCompleted, r.Header.Get("X-Event-Type"))
+				w.WriteHeader(tt.status)
+			}))
+			defer receiver.Close()
+
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+			mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+			mRepo.EXPECT().ClaimWebhookDeliveries(ctx, gomock.Any(), gomock.Any(), webhookClaimBatch).Return([]repository.WebhookDelivery{tt.delivery}, nil)
+			mRepo.EXPECT().GetWebhookSubscription(ctx, tt.delivery.SubscriptionID).Return(repository.WebhookSubscription{
+				ID:     tt.delivery.SubscriptionID,
+				URL:    receiver.URL,
+				Secret: secret,
+				Active: true,
+			}, nil)
+			mRepo.EXPECT().RecordWebhookAttempt(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, result repository.WebhookAttemptResult) error {
+				assert.Equal(t, tt.delivery.ID, result.Attempt.DeliveryID)
+				tt.check(t, result)
+				return nil
+			})
+
+			svc := NewWalletService(mRepo, WithWebhookRetry(3, time.Second))
+			n, err := svc.RunWebhookDeliveries(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Equal(t, 1, received)
+		})
+	}
+}
+
+func TestRunWebhookDeliveriesUnreachable(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+	mRepo := mocks.NewMockWalletRepository(mockCtl)
+
+	ctx := staffCtx()
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	url := receiver.URL
+	receiver.Close()
+
+	d := repository.WebhookDelivery{
+		ID:             "a3f3b0c2-6f4e-4a83-9d3c-0b0b7f0f6a01",
+		SubscriptionID: "9d1d5b1e-4a51-4e1f-8d61-0c2a6c1e0b02",
+		Payload:        json.RawMessage(`{}`),
+		Status:         consts.WebhookDeliveryPending,
+	}
+
+	mRepo.EXPECT().ClaimWebhookDeliveries(ctx, gomock.Any(), gomock.Any(), webhookClaimBatch).Return([]repository.WebhookDelivery{d}, nil)
+	mRepo.EXPECT().GetWebhookSubscription(ctx, d.SubscriptionID).Return(repository.WebhookSubscription{ID: d.SubscriptionID, URL: url, Secret: "whsec_0123456789abcdef"}, nil)
+	mRepo.EXPECT().RecordWebhookAttempt(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, result repository.WebhookAttemptResult) error {
+		assert.Equal(t, consts.WebhookDeliveryPending, result.Status)
+		assert.Equal(t, 1, result.Attempts)
+		assert.Zero(t, result.Attempt.StatusCode)
+		assert.NotEmpty(t, result.Attempt.Error)
+		return nil
+	})
+
+	n, err := NewWalletService(mRepo).RunWebhookDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
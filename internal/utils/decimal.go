@@ -1 +1,366 @@
 package utils
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecimalScale is the number of fractional digits kept by Decimal, matching
+// the DECIMAL(20, 6) money columns in the database.
+const DecimalScale = 6
+
+const decimalUnit int64 = 1_000_000
+
+var (
+	ErrInvalidDecimal  = errors.New("invalid decimal format")
+	ErrDecimalScale    = fmt.Errorf("decimal cannot have more than %d fractional digits", DecimalScale)
+	ErrDecimalOverflow = errors.New("decimal value out of range")
+	ErrDecimalDivision = errors.New("decimal division by zero")
+)
+
+// Decimal is an exact fixed-point number stored as an integer count of
+// 10^-DecimalScale units. The zero value is 0.
+type Decimal struct {
+	units int64
+}
+
+// MaxDecimal is the largest Decimal, a little above 9.2 trillion. Amounts
+// beyond it cannot be represented even though the DECIMAL(20, 6) columns
+// could store them.
+var MaxDecimal = Decimal{units: math.MaxInt64}
+
+// NewDecimal returns the Decimal for a whole number. It panics when v is out
+// of range; it is meant for constants and tests, like MustParseDecimal.
+func NewDecimal(v int64) Decimal {
+	d, err := decimalFromWhole(v)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func decimalFromWhole(v int64) (Decimal, error) {
+	if v > math.MaxInt64/decimalUnit || v < math.MinInt64/decimalUnit {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return Decimal{units: v * decimalUnit}, nil
+}
+
+// NewDecimalFromUnits returns the Decimal holding the given count of
+// 10^-DecimalScale units.
+func NewDecimalFromUnits(units int64) Decimal {
+	return Decimal{units: units}
+}
+
+// ParseDecimal parses a plain decimal string such as "-100.12345". Inputs
+// with more than DecimalScale significant fractional digits are rejected
+// instead of being rounded.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, ErrInvalidDecimal
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	if hasDot && fracPart == "" {
+		return Decimal{}, ErrInvalidDecimal
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, ErrInvalidDecimal
+	}
+
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > DecimalScale {
+		return Decimal{}, ErrDecimalScale
+	}
+	fracPart += strings.Repeat("0", DecimalScale-len(fracPart))
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	if neg {
+		units = -units
+	}
+
+	return Decimal{units: units}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on error. It is meant for
+// constants and tests.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Units returns the value as a count of 10^-DecimalScale units.
+func (d Decimal) Units() int64 {
+	return d.units
+}
+
+// Add returns d+o. It does not check for overflow, so it is meant for values
+// already known to be small such as a balance and a validated amount; sums of
+// any number of amounts go through CheckedAdd.
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{units: d.units + o.units}
+}
+
+// Sub returns d-o without checking for overflow, see Add.
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{units: d.units - o.units}
+}
+
+// CheckedAdd returns d+o, or ErrDecimalOverflow when the sum is out of range.
+func (d Decimal) CheckedAdd(o Decimal) (Decimal, error) {
+	sum := d.units + o.units
+	// the sum overflowed when both operands have the same sign and the sum
+	// does not
+	if (d.units >= 0) == (o.units >= 0) && (sum >= 0) != (d.units >= 0) {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return Decimal{units: sum}, nil
+}
+
+// CheckedSub returns d-o, or ErrDecimalOverflow when the difference is out of
+// range.
+func (d Decimal) CheckedSub(o Decimal) (Decimal, error) {
+	diff := d.units - o.units
+	if (d.units >= 0) != (o.units >= 0) && (diff >= 0) != (d.units >= 0) {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return Decimal{units: diff}, nil
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: -d.units}
+}
+
+// Cmp returns -1, 0 or +1 depending on whether d is less than, equal to or
+// greater than o.
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.units < o.units:
+		return -1
+	case d.units > o.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+func (d Decimal) IsNegative() bool {
+	return d.units < 0
+}
+
+func (d Decimal) IsPositive() bool {
+	return d.units > 0
+}
+
+// Mul returns d*o rounded half away from zero to scale fractional digits.
+// Scales above DecimalScale are treated as DecimalScale.
+func (d Decimal) Mul(o Decimal, scale int) (Decimal, error) {
+	// the product carries 2*DecimalScale fractional digits
+	product := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(o.units))
+	return roundUnits(product, big.NewInt(decimalUnit), scale)
+}
+
+// MulDiv returns d*num/den rounded half away from zero to scale fractional
+// digits, without rounding the intermediate product. Scales above
+// DecimalScale are treated as DecimalScale.
+func (d Decimal) MulDiv(num, den Decimal, scale int) (Decimal, error) {
+	if den.units == 0 {
+		return Decimal{}, ErrDecimalDivision
+	}
+
+	// num/den is a plain ratio, so the product keeps DecimalScale digits
+	product := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(num.units))
+	divisor := big.NewInt(den.units)
+	if divisor.Sign() < 0 {
+		product.Neg(product)
+		divisor.Neg(divisor)
+	}
+
+	return roundUnits(product, divisor, scale)
+}
+
+// roundUnits returns n/divisor units rounded half away from zero to scale
+// fractional digits. divisor must be positive.
+func roundUnits(n, divisor *big.Int, scale int) (Decimal, error) {
+	if scale > DecimalScale {
+		scale = DecimalScale
+	}
+	if scale < 0 {
+		scale = 0
+	}
+
+	step := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(DecimalScale-scale)), nil)
+	divisor = new(big.Int).Mul(divisor, step)
+
+	quo, rem := new(big.Int).QuoRem(n, divisor, new(big.Int))
+	if new(big.Int).Mul(rem.Abs(rem), big.NewInt(2)).Cmp(divisor) >= 0 {
+		if n.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	quo.Mul(quo, step)
+	if !quo.IsInt64() {
+		return Decimal{}, ErrDecimalOverflow
+	}
+
+	return Decimal{units: quo.Int64()}, nil
+}
+
+// FitsScale reports whether d has at most scale significant fractional
+// digits, e.g. whether it is a whole number of cents for scale 2.
+func (d Decimal) FitsScale(scale int) bool {
+	if scale >= DecimalScale {
+		return true
+	}
+	if scale < 0 {
+		return false
+	}
+
+	step := decimalUnit
+	for i := 0; i < scale; i++ {
+		step /= 10
+	}
+
+	return d.units%step == 0
+}
+
+// String renders the canonical form: no exponent, no trailing fractional
+// zeros and no dot for whole numbers, e.g. "100", "200.23344", "-0.5".
+func (d Decimal) String() string {
+	var b strings.Builder
+
+	u := d.units
+	if u < 0 {
+		b.WriteByte('-')
+	}
+
+	var abs uint64
+	if u == math.MinInt64 {
+		abs = uint64(math.MaxInt64) + 1
+	} else if u < 0 {
+		abs = uint64(-u)
+	} else {
+		abs = uint64(u)
+	}
+
+	b.WriteString(strconv.FormatUint(abs/uint64(decimalUnit), 10))
+
+	frac := abs % uint64(decimalUnit)
+	if frac != 0 {
+		s := strconv.FormatUint(frac, 10)
+		s = strings.Repeat("0", DecimalScale-len(s)) + s
+		b.WriteByte('.')
+		b.WriteString(strings.TrimRight(s, "0"))
+	}
+
+	return b.String()
+}
+
+// MarshalJSON encodes the value as a JSON string so clients never see a
+// binary floating point representation.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts both JSON strings and bare JSON numbers.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns.
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	case int64:
+		whole, err := decimalFromWhole(v)
+		if err != nil {
+			return err
+		}
+		*d = whole
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+}
+
+func (d *Decimal) scanString(s string) error {
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Value implements driver.Valuer, sending the canonical string so the
+// database parses it as an exact NUMERIC.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecimal(t *testing.T) {
+	testTables := []struct {
+		name   string
+		req    string
+		err    error
+		result string
+	}{
+		{name: "whole number", req: "100", result: "100"},
+		{name: "fraction", req: "100.12345", result: "100.12345"},
+		{name: "full scale", req: "0.000001", result: "0.000001"},
+		{name: "trailing zeros", req: "200.2334400000", result: "200.23344"},
+		{name: "negative", req: "-0.5", result: "-0.5"},
+		{name: "leading zeros", req: "007.10", result: "7.1"},
+		{name: "zero", req: "0.000", result: "0"},
+		{name: "FAILED scale", req: "1.1234567", err: ErrDecimalScale},
+		{name: "FAILED empty", req: "", err: ErrInvalidDecimal},
+		{name: "FAILED exponent", req: "1e5", err: ErrInvalidDecimal},
+		{name: "FAILED dangling dot", req: "1.", err: ErrInvalidDecimal},
+		{name: "FAILED overflow", req: "99999999999999999999", err: ErrDecimalOverflow},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDecimal(tt.req)
+			assert.Equal(t, tt.err, err)
+			if tt.err == nil {
+				assert.Equal(t, tt.result, d.String())
+			}
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := MustParseDecimal("200.23344")
+	b := MustParseDecimal("100.12345")
+
+	assert.Equal(t, "100.10999", a.Sub(b).String())
+	assert.Equal(t, "300.35689", a.Add(b).String())
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(a))
+	assert.Equal(t, 0, a.Cmp(MustParseDecimal("200.233440")))
+
+	// 0.1 + 0.2 must be exactly 0.3, unlike float64.
+	assert.Equal(t, MustParseDecimal("0.3"), MustParseDecimal("0.1").Add(MustParseDecimal("0.2")))
+}
+
+func TestDecimalOverflow(t *testing.T) {
+	max := MustParseDecimal("9223372036854.775807")
+	assert.Equal(t, MaxDecimal, max)
+
+	_, err := ParseDecimal("9223372036854.775808")
+	assert.Equal(t, ErrDecimalOverflow, err)
+
+	unit := MustParseDecimal("0.000001")
+
+	_, err = max.CheckedAdd(unit)
+	assert.Equal(t, ErrDecimalOverflow, err)
+	_, err = max.Neg().CheckedSub(unit.Add(unit))
+	assert.Equal(t, ErrDecimalOverflow, err)
+	_, err = max.Neg().CheckedAdd(max.Neg())
+	assert.Equal(t, ErrDecimalOverflow, err)
+	_, err = max.CheckedSub(max.Neg())
+	assert.Equal(t, ErrDecimalOverflow, err)
+
+	sum, err := max.Sub(unit).CheckedAdd(unit)
+	assert.NoError(t, err)
+	assert.Equal(t, max, sum)
+
+	diff, err := max.Neg().CheckedSub(unit)
+	assert.NoError(t, err)
+	assert.Equal(t, "-9223372036854.775808", diff.String())
+
+	diff, err = max.CheckedSub(max)
+	assert.NoError(t, err)
+	assert.True(t, diff.IsZero())
+
+	assert.Equal(t, "9223372036854", NewDecimal(9223372036854).String())
+	assert.Panics(t, func() { NewDecimal(9223372036855) })
+	assert.Panics(t, func() { NewDecimal(-9223372036855) })
+
+	var scanned Decimal
+	assert.Equal(t, ErrDecimalOverflow, scanned.Scan(int64(9223372036855)))
+}
+
+func TestDecimalMul(t *testing.T) {
+	testTables := []struct {
+		name   string
+		a, b   string
+		scale  int
+		result string
+		err    error
+	}{
+		{name: "exact", a: "100", b: "0.92", scale: 2, result: "92"},
+		{name: "round half up", a: "10.05", b: "0.5", scale: 2, result: "5.03"},
+		{name: "round down", a: "10.04", b: "0.5", scale: 2, result: "5.02"},
+		{name: "negative rounds away from zero", a: "-10.05", b: "0.5", scale: 2, result: "-5.03"},
+		{name: "whole units", a: "100", b: "157.345678", scale: 0, result: "15735"},
+		{name: "full scale", a: "1.5", b: "0.000001", scale: 6, result: "0.000002"},
+		{name: "large rate", a: "1000000", b: "16250.5", scale: 2, result: "16250500000"},
+		{name: "FAILED overflow", a: "1000000000", b: "100000000000", scale: 2, err: ErrDecimalOverflow},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := MustParseDecimal(tt.a).Mul(MustParseDecimal(tt.b), tt.scale)
+			assert.Equal(t, tt.err, err)
+			if tt.err == nil {
+				assert.Equal(t, tt.result, d.String())
+			}
+		})
+	}
+}
+
+func TestDecimalMulDiv(t *testing.T) {
+	testTables := []struct {
+		name    string
+		a, b, c string
+		scale   int
+		result  string
+		err     error
+	}{
+		{name: "exact", a: "2", b: "25", c: "50", scale: 2, result: "1"},
+		{name: "round half up", a: "1", b: "1", c: "8", scale: 2, result: "0.13"},
+		{name: "round down", a: "1", b: "1", c: "3", scale: 2, result: "0.33"},
+		{name: "negative rounds away from zero", a: "-1", b: "1", c: "8", scale: 2, result: "-0.13"},
+		{name: "negative divisor", a: "1", b: "1", c: "-8", scale: 2, result: "-0.13"},
+		{name: "no intermediate rounding", a: "0.000001", b: "1000000", c: "3", scale: 6, result: "0.333333"},
+		{name: "large product", a: "9000000000", b: "9000000000", c: "9000000000", scale: 2, result: "9000000000"},
+		{name: "FAILED division by zero", a: "1", b: "1", c: "0", scale: 2, err: ErrDecimalDivision},
+		{name: "FAILED overflow", a: "9000000000", b: "9000000000", c: "1", scale: 2, err: ErrDecimalOverflow},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := MustParseDecimal(tt.a).MulDiv(MustParseDecimal(tt.b), MustParseDecimal(tt.c), tt.scale)
+			assert.Equal(t, tt.err, err)
+			if tt.err == nil {
+				assert.Equal(t, tt.result, d.String())
+			}
+		})
+	}
+}
+
+func TestDecimalFitsScale(t *testing.T) {
+	assert.True(t, MustParseDecimal("100").FitsScale(0))
+	assert.False(t, MustParseDecimal("100.5").FitsScale(0))
+	assert.True(t, MustParseDecimal("100.25").FitsScale(2))
+	assert.True(t, MustParseDecimal("-100.250").FitsScale(2))
+	assert.False(t, MustParseDecimal("100.255").FitsScale(2))
+	assert.True(t, MustParseDecimal("0.000001").FitsScale(DecimalScale))
+	assert.False(t, MustParseDecimal("1").FitsScale(-1))
+}
+
+func TestDecimalJSONAndSQL(t *testing.T) {
+	var payload struct {
+		Amount Decimal `json:"amount"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":"100.12345"}`), &payload))
+	assert.Equal(t, "100.12345", payload.Amount.String())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":12.5}`), &payload))
+	assert.Equal(t, "12.5", payload.Amount.String())
+
+	out, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"amount":"12.5"}`, string(out))
+
+	var scanned Decimal
+	assert.NoError(t, scanned.Scan([]byte("200.233440")))
+	assert.Equal(t, "200.23344", scanned.String())
+
+	v, err := scanned.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "200.23344", v)
+}
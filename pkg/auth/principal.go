@@ -0,0 +1,24 @@
+package auth
+
+import "context"
+
+// Principal is the authenticated caller of a request. Subject identifies
+// it, the token subject or the name of an API key, and Role decides what
+// it may do.
+type Principal struct {
+	Subject string
+	Role    string
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Postgres SQLSTATE codes the repositories need to tell apart
+const (
+	CodeUniqueViolation      = "23505"
+	CodeSerializationFailure = "40001"
+	CodeDeadlockDetected     = "40P01"
+)
+
+// ErrRetryable is matched by errors.Is for any failure caused by a clash
+// with a concurrent transaction. The whole transaction can be retried as is.
+var ErrRetryable = errors.New("transaction aborted by a concurrent update, please retry")
+
+// RetryableError wraps a deadlock or serialization failure reported by
+// Postgres
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrRetryable.Error(), e.Err)
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RetryableError) Is(target error) bool {
+	return target == ErrRetryable
+}
+
+// IsRetryable reports whether err is a deadlock or serialization failure,
+// either raw from the driver or already wrapped in a RetryableError
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrRetryable) {
+		return true
+	}
+
+	switch SQLState(err) {
+	case CodeSerializationFailure, CodeDeadlockDetected:
+		return true
+	}
+	return false
+}
+
+// wrapRetryable turns deadlock and serialization failures into a
+// RetryableError and returns any other error unchanged
+func wrapRetryable(err error) error {
+	if err == nil || errors.Is(err, ErrRetryable) || !IsRetryable(err) {
+		return err
+	}
+	return &RetryableError{Err: err}
+}
+
+// SQLState returns the Postgres SQLSTATE carried by err, or an empty string
+// when err did not come from the server
+func SQLState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation,
+// optionally limited to the given constraint names
+func IsUniqueViolation(err error, constraints ...string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || string(pqErr.Code) != CodeUniqueViolation {
+		return false
+	}
+
+	if len(constraints) == 0 {
+		return true
+	}
+
+	for _, c := range constraints {
+		if pqErr.Constraint == c {
+			return true
+		}
+	}
+	return false
+}
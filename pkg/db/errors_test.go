@@ -0,0 +1,44 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapRetryable(t *testing.T) {
+	deadlock := &pq.Error{Code: CodeDeadlockDetected, Message: "deadlock detected"}
+	serialization := &pq.Error{Code: CodeSerializationFailure, Message: "could not serialize access"}
+	unique := &pq.Error{Code: CodeUniqueViolation, Message: "duplicate key value"}
+
+	testTables := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "nil", err: nil, retryable: false},
+		{name: "plain error", err: errors.New("boom"), retryable: false},
+		{name: "unique violation", err: unique, retryable: false},
+		{name: "deadlock", err: deadlock, retryable: true},
+		{name: "wrapped deadlock", err: fmt.Errorf("failed to update accounts data: %w", deadlock), retryable: true},
+		{name: "serialization failure", err: serialization, retryable: true},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapRetryable(tt.err)
+			assert.Equal(t, tt.retryable, errors.Is(err, ErrRetryable))
+			assert.Equal(t, tt.retryable, IsRetryable(err))
+			if tt.retryable {
+				var pqErr *pq.Error
+				assert.True(t, errors.As(err, &pqErr))
+			} else {
+				assert.Equal(t, tt.err, err)
+			}
+		})
+	}
+}
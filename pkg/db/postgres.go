@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -24,33 +26,144 @@ type Transactor interface {
 
 type TransactionFunc func(ctx context.Context, tx Executor) error
 
+// TxOptions overrides the manager defaults for a single WithTransactionOpts
+// call. Zero values keep the defaults.
+type TxOptions struct {
+	Isolation   sql.IsolationLevel
+	ReadOnly    bool
+	MaxAttempts int
+}
+
+// TxStats counts transactions run by a TransactionManager since it was
+// created
+type TxStats struct {
+	Transactions uint64
+	Attempts     uint64
+	Retries      uint64
+	Failures     uint64
+}
+
 type TransactionManager struct {
 	db      Transactor
 	timeout time.Duration
 	options *sql.TxOptions
+	retry   RetryPolicy
+
+	transactions atomic.Uint64
+	attempts     atomic.Uint64
+	retries      atomic.Uint64
+	failures     atomic.Uint64
+}
+
+// Option customises a TransactionManager
+type Option func(tm *TransactionManager)
+
+// WithRetryPolicy sets the retry policy, unset fields use DefaultRetryPolicy
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(tm *TransactionManager) {
+		tm.retry = p.withDefaults()
+	}
 }
 
 // NewTransactionManager creates a new transaction manager
-func NewTransactionManager(db Transactor) *TransactionManager {
+func NewTransactionManager(db Transactor, opts ...Option) *TransactionManager {
 
 	txOptions := &sql.TxOptions{
 		Isolation: sql.LevelReadCommitted,
 		ReadOnly:  false,
 	}
 
-	return &TransactionManager{
+	tm := &TransactionManager{
 		db:      db,
 		timeout: 30 * time.Second,
 		options: txOptions,
+		retry:   DefaultRetryPolicy,
 	}
+
+	for _, opt := range opts {
+		opt(tm)
+	}
+
+	return tm
 }
 
+// WithTransaction runs fn inside a transaction with the default options.
+// See WithTransactionOpts.
 func (tm *TransactionManager) WithTransaction(ctx context.Context, fn TransactionFunc) error {
-	return tm.executeTransaction(ctx, fn)
+	return tm.WithTransactionOpts(ctx, TxOptions{}, fn)
+}
+
+// WithTransactionOpts runs fn inside a transaction, retrying the whole
+// transaction according to the retry policy. fn must therefore be safe to
+// run more than once. Deadlocks and serialization failures still failing
+// after the last attempt are returned as *RetryableError
+func (tm *TransactionManager) WithTransactionOpts(ctx context.Context, opts TxOptions, fn TransactionFunc) error {
+	txOptions := &sql.TxOptions{
+		Isolation: tm.options.Isolation,
+		ReadOnly:  tm.options.ReadOnly || opts.ReadOnly,
+	}
+	if opts.Isolation != sql.LevelDefault {
+		txOptions.Isolation = opts.Isolation
+	}
+
+	maxAttempts := tm.retry.MaxAttempts
+	if opts.MaxAttempts > 0 {
+		maxAttempts = opts.MaxAttempts
+	}
+
+	tm.transactions.Add(1)
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			backoff := tm.retry.Backoff(attempt)
+			slog.Warn("[WithTransaction] retrying transaction",
+				slog.Int("attempt", attempt),
+				slog.Int("max_attempts", maxAttempts),
+				slog.Duration("backoff", backoff),
+				slog.String("sqlstate", SQLState(err)),
+				slog.Any("err", err),
+			)
+
+			if sleepErr := sleepContext(ctx, backoff); sleepErr != nil {
+				break
+			}
+			tm.retries.Add(1)
+		}
+
+		tm.attempts.Add(1)
+		err = tm.executeTransaction(context.WithValue(ctx, attemptKey{}, attempt), txOptions, fn)
+		if err == nil {
+			if attempt > 1 {
+				slog.Info("[WithTransaction] transaction succeeded after retry",
+					slog.Int("attempt", attempt),
+					slog.Int("max_attempts", maxAttempts),
+				)
+			}
+			return nil
+		}
+
+		if !shouldRetry(err) {
+			break
+		}
+	}
+
+	tm.failures.Add(1)
+	return wrapRetryable(err)
+}
+
+// Stats returns a snapshot of the transaction counters
+func (tm *TransactionManager) Stats() TxStats {
+	return TxStats{
+		Transactions: tm.transactions.Load(),
+		Attempts:     tm.attempts.Load(),
+		Retries:      tm.retries.Load(),
+		Failures:     tm.failures.Load(),
+	}
 }
 
 // executeTransaction executes a single transaction attempt
-func (tm *TransactionManager) executeTransaction(ctx context.Context, fn TransactionFunc) error {
+func (tm *TransactionManager) executeTransaction(ctx context.Context, txOptions *sql.TxOptions, fn TransactionFunc) error {
 	// Create context with timeout if not already set
 	if tm.timeout > 0 {
 		var cancel context.CancelFunc
@@ -59,7 +172,7 @@ func (tm *TransactionManager) executeTransaction(ctx context.Context, fn Transac
 	}
 
 	// Begin transaction
-	tx, err := tm.db.BeginTx(ctx, tm.options)
+	tx, err := tm.db.BeginTx(ctx, txOptions)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -82,7 +195,7 @@ func (tm *TransactionManager) executeTransaction(ctx context.Context, fn Transac
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return &commitError{err: err}
 	}
 
 	return nil
@@ -95,8 +208,8 @@ type Repository struct {
 }
 
 // NewRepository creates a new repository instance
-func NewRepository(db *sql.DB) *Repository {
-	txMgr := NewTransactionManager(db)
+func NewRepository(db *sql.DB, opts ...Option) *Repository {
+	txMgr := NewTransactionManager(db, opts...)
 	return &Repository{
 		db:    db,
 		txMgr: txMgr,
@@ -105,7 +218,13 @@ func NewRepository(db *sql.DB) *Repository {
 
 // WithTransaction executes repository operations within a transaction
 func (r *Repository) WithTransaction(ctx context.Context, fn func(ctx context.Context, repo *Repository) error) error {
-	return r.txMgr.WithTransaction(ctx, func(ctx context.Context, tx Executor) error {
+	return r.WithTransactionOpts(ctx, TxOptions{}, fn)
+}
+
+// WithTransactionOpts executes repository operations within a transaction
+// using the given isolation level and retry limit
+func (r *Repository) WithTransactionOpts(ctx context.Context, opts TxOptions, fn func(ctx context.Context, repo *Repository) error) error {
+	return r.txMgr.WithTransactionOpts(ctx, opts, func(ctx context.Context, tx Executor) error {
 		txRepo := &Repository{
 			db:    tx,
 			txMgr: r.txMgr,
@@ -114,6 +233,11 @@ func (r *Repository) WithTransaction(ctx context.Context, fn func(ctx context.Co
 	})
 }
 
+// Stats returns the transaction counters of the underlying manager
+func (r *Repository) Stats() TxStats {
+	return r.txMgr.Stats()
+}
+
 // Exec executes a query without returning any rows
 func (r *Repository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	return r.db.ExecContext(ctx, query, args...)
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand/v2"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how many times a transaction is attempted and how long
+// to wait between attempts
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts
+	MaxBackoff time.Duration
+	// Multiplier grows the wait after every failed attempt
+	Multiplier float64
+}
+
+// DefaultRetryPolicy is used when no policy, or a partial one, is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 20 * time.Millisecond,
+	MaxBackoff:     500 * time.Millisecond,
+	Multiplier:     2,
+}
+
+// withDefaults fills every unset field from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultRetryPolicy.Multiplier
+	}
+	return p
+}
+
+// Backoff returns the wait before the given attempt (2 for the first retry).
+// The exponential delay is capped at MaxBackoff and jittered to a random
+// value in its upper half so that colliding transactions spread out.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 2; i < attempt; i++ {
+		d *= p.Multiplier
+		if d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	half := int64(d / 2)
+	if half <= 0 {
+		return time.Duration(d)
+	}
+	return time.Duration(half + rand.Int64N(half+1))
+}
+
+// commitError marks a failure returned by COMMIT. A broken connection at that
+// point leaves the outcome unknown, so it is never retried.
+type commitError struct {
+	err error
+}
+
+func (e *commitError) Error() string {
+	return "failed to commit transaction: " + e.err.Error()
+}
+
+func (e *commitError) Unwrap() error {
+	return e.err
+}
+
+// shouldRetry reports whether a failed attempt may run again: on
+// serialization failures and deadlocks, and on connection resets that
+// happened before COMMIT was sent
+func shouldRetry(err error) bool {
+	if IsRetryable(err) {
+		return true
+	}
+
+	var ce *commitError
+	if errors.As(err, &ce) {
+		return false
+	}
+
+	return isConnectionReset(err)
+}
+
+func isConnectionReset(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNRESET)
+}
+
+type attemptKey struct{}
+
+// Attempt returns the 1-based attempt number of the transaction running with
+// ctx, or 0 outside of WithTransaction
+func Attempt(ctx context.Context) int {
+	n, _ := ctx.Value(attemptKey{}).(int)
+	return n
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
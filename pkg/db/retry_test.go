@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver hands out connections whose transactions only record
+// commits and rollbacks, enough to drive TransactionManager without a server.
+type fakeDriver struct {
+	commits   atomic.Int32
+	rollbacks atomic.Int32
+	commitErr error
+	lastOpts  atomic.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return &fakeTx{d: c.d}, nil }
+
+func (c *fakeConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.d.lastOpts.Store(opts)
+	return &fakeTx{d: c.d}, nil
+}
+
+type fakeTx struct{ d *fakeDriver }
+
+func (t *fakeTx) Commit() error {
+	t.d.commits.Add(1)
+	return t.d.commitErr
+}
+
+func (t *fakeTx) Rollback() error {
+	t.d.rollbacks.Add(1)
+	return nil
+}
+
+var driverSeq atomic.Int32
+
+func newFakeManager(t *testing.T, d *fakeDriver, opts ...Option) *TransactionManager {
+	t.Helper()
+
+	name := fmt.Sprintf("fake-tx-%d", driverSeq.Add(1))
+	sql.Register(name, d)
+	session, err := sql.Open(name, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+
+	return NewTransactionManager(session, opts...)
+}
+
+var fastRetry = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     2 * time.Millisecond,
+	Multiplier:     2,
+}
+
+func TestWithTransactionRetries(t *testing.T) {
+	deadlock := &pq.Error{Code: CodeDeadlockDetected, Message: "deadlock detected"}
+
+	testTables := []struct {
+		name         string
+		failures     int
+		failWith     error
+		opts         TxOptions
+		err          error
+		wantAttempts int
+	}{
+		{name: "SUCCESS first attempt", failures: 0, wantAttempts: 1},
+		{name: "SUCCESS after deadlocks", failures: 3, failWith: deadlock, wantAttempts: 4},
+		{name: "SUCCESS after connection reset", failures: 1, failWith: driver.ErrBadConn, wantAttempts: 2},
+		{name: "FAILED attempts exhausted", failures: 10, failWith: deadlock, err: ErrRetryable, wantAttempts: 4},
+		{name: "FAILED per call attempt limit", failures: 10, failWith: deadlock, opts: TxOptions{MaxAttempts: 2}, err: ErrRetryable, wantAttempts: 2},
+		{name: "FAILED not retryable", failures: 10, failWith: errors.New("boom"), err: errors.New("boom"), wantAttempts: 1},
+	}
+
+	for _, tt := range testTables {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeDriver{}
+			tm := newFakeManager(t, d, WithRetryPolicy(fastRetry))
+
+			var seen []int
+			err := tm.WithTransactionOpts(context.Background(), tt.opts, func(ctx context.Context, tx Executor) error {
+				seen = append(seen, Attempt(ctx))
+				if len(seen) <= tt.failures {
+					return tt.failWith
+				}
+				return nil
+			})
+
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else if errors.Is(tt.err, ErrRetryable) {
+				assert.ErrorIs(t, err, ErrRetryable)
+			} else {
+				assert.EqualError(t, err, tt.err.Error())
+			}
+
+			assert.Len(t, seen, tt.wantAttempts)
+			for i, a := range seen {
+				assert.Equal(t, i+1, a)
+			}
+
+			stats := tm.Stats()
+			assert.Equal(t, uint64(1), stats.Transactions)
+			assert.Equal(t, uint64(tt.wantAttempts), stats.Attempts)
+			assert.Equal(t, uint64(tt.wantAttempts-1), stats.Retries)
+		})
+	}
+}
+
+func TestWithTransactionCommitConnectionResetNotRetried(t *testing.T) {
+	d := &fakeDriver{commitErr: driver.ErrBadConn}
+	tm := newFakeManager(t, d, WithRetryPolicy(fastRetry))
+
+	calls := 0
+	err := tm.WithTransaction(context.Background(), func(ctx context.Context, tx Executor) error {
+		calls++
+		return nil
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWithTransactionIsolationOverride(t *testing.T) {
+	d := &fakeDriver{}
+	tm := newFakeManager(t, d)
+
+	noop := func(ctx context.Context, tx Executor) error { return nil }
+
+	require.NoError(t, tm.WithTransaction(context.Background(), noop))
+	assert.Equal(t, driver.IsolationLevel(sql.LevelReadCommitted), d.lastOpts.Load().(driver.TxOptions).Isolation)
+
+	require.NoError(t, tm.WithTransactionOpts(context.Background(), TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}, noop))
+	opts := d.lastOpts.Load().(driver.TxOptions)
+	assert.Equal(t, driver.IsolationLevel(sql.LevelSerializable), opts.Isolation)
+	assert.True(t, opts.ReadOnly)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	for attempt, max := range map[int]time.Duration{
+		2: 100 * time.Millisecond,
+		3: 200 * time.Millisecond,
+		4: 400 * time.Millisecond,
+		5: 800 * time.Millisecond,
+		6: time.Second,
+		9: time.Second,
+	} {
+		for i := 0; i < 20; i++ {
+			d := p.Backoff(attempt)
+			assert.GreaterOrEqual(t, d, max/2, "attempt %d", attempt)
+			assert.LessOrEqual(t, d, max, "attempt %d", attempt)
+		}
+	}
+}
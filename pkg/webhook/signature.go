@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a signed request. The signature is the hex HMAC-SHA256 of
+// "{timestamp}.{body}" under the subscription secret, prefixed with
+// "sha256=", where timestamp is the value of TimestampHeader in Unix
+// seconds. Covering the timestamp lets receivers refuse replayed requests.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrMissingSignature = errors.New("webhook signature missing")
+	ErrInvalidSignature = errors.New("webhook signature invalid")
+	ErrStaleTimestamp   = errors.New("webhook timestamp outside tolerance")
+)
+
+// Signature returns the SignatureHeader value of body sent at timestamp.
+func Signature(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign sets the timestamp and signature headers of a request carrying body.
+func Sign(header http.Header, secret string, timestamp time.Time, body []byte) {
+	header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	header.Set(SignatureHeader, Signature(secret, timestamp, body))
+}
+
+// Verify checks the signature headers of a received request against body and
+// requires its timestamp to lie within tolerance of now.
+func Verify(header http.Header, secret string, body []byte, now time.Time, tolerance time.Duration) error {
+	sig, ts := header.Get(SignatureHeader), header.Get(TimestampHeader)
+	if sig == "" || ts == "" {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	timestamp := time.Unix(unix, 0)
+	if d := now.Sub(timestamp); d > tolerance || d < -tolerance {
+		return ErrStaleTimestamp
+	}
+
+	if !strings.HasPrefix(sig, signaturePrefix) ||
+		!hmac.Equal([]byte(sig), []byte(Signature(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
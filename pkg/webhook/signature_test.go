@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+	// echo -n '1720429200.{"id":"1"}' | openssl dgst -sha256 -hmac secret
+	got := Signature("secret", time.Unix(1720429200, 0), []byte(`{"id":"1"}`))
+	assert.Equal(t, "sha256=109ac9f29bb8696653480556360567ef2eb8a9c3693376e63c0d185871e7f02b", got)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1720429200, 0)
+	body := []byte(`{"id":"1"}`)
+
+	signed := func(secret string, at time.Time) http.Header {
+		h := http.Header{}
+		Sign(h, secret, at, body)
+		return h
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   error
+	}{
+		{name: "valid", header: signed("secret", now), body: body},
+		{name: "within tolerance", header: signed("secret", now.Add(-4*time.Minute)), body: body},
+		{name: "missing", header: http.Header{}, body: body, want: ErrMissingSignature},
+		{name: "wrong secret", header: signed("other", now), body: body, want: ErrInvalidSignature},
+		{name: "tampered body", header: signed("secret", now), body: []byte(`{"id":"2"}`), want: ErrInvalidSignature},
+		{name: "stale", header: signed("secret", now.Add(-10*time.Minute)), body: body, want: ErrStaleTimestamp},
+		{name: "from the future", header: signed("secret", now.Add(10*time.Minute)), body: body, want: ErrStaleTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Verify(tt.header, "secret", tt.body, now, 5*time.Minute))
+		})
+	}
+}